@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultAnomalyBaselineWeeks is how many past weeks the baselines are built from, overridable with
+// ANOMALY_BASELINE_WEEKS
+const defaultAnomalyBaselineWeeks = 4
+
+// anomalyBaselineCacheTTL is how long baselines are used before they are rebuilt, they only change hour by hour
+const anomalyBaselineCacheTTL = time.Hour
+
+func anomalyBaselineWeeks() int {
+	if value, err := strconv.Atoi(os.Getenv("ANOMALY_BASELINE_WEEKS")); err == nil && value > 0 && value <= 12 {
+		return value
+	}
+	return defaultAnomalyBaselineWeeks
+}
+
+type anomalyBaselineKey struct {
+	projectId     uuid.UUID
+	operationType string
+}
+
+type cachedBaselines struct {
+	baselines map[string]*models.OperationBaseline
+	loadedAt  time.Time
+}
+
+type anomalyBaselineCache struct {
+	baselines map[anomalyBaselineKey]*cachedBaselines
+	mu        sync.Mutex
+}
+
+// AnomalyBaselineCache holds the seasonal baselines of every endpoint and task of a project
+var AnomalyBaselineCache = &anomalyBaselineCache{
+	baselines: make(map[anomalyBaselineKey]*cachedBaselines),
+}
+
+// Get returns the baselines of the project's endpoints or tasks keyed by operation, built from the complete hours
+// of the last ANOMALY_BASELINE_WEEKS weeks
+func (c *anomalyBaselineCache) Get(ctx context.Context, projectId uuid.UUID, operationType string) (map[string]*models.OperationBaseline, error) {
+	key := anomalyBaselineKey{projectId: projectId, operationType: operationType}
+
+	c.mu.Lock()
+	cached := c.baselines[key]
+	c.mu.Unlock()
+	if cached != nil && time.Since(cached.loadedAt) < anomalyBaselineCacheTTL {
+		return cached.baselines, nil
+	}
+
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.AddDate(0, 0, -7*anomalyBaselineWeeks())
+	hourly, err := repositories.OperationStatsRepository.FindHourly(ctx, projectId, operationType, "", start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	cached = &cachedBaselines{
+		baselines: models.BuildOperationBaselines(operationType, hourly, start, end),
+		loadedAt:  time.Now(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baselines[key] = cached
+	return cached.baselines, nil
+}
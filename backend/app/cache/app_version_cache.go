@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"backend/app/repositories"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// appVersionCacheTTL is how long the first time a version was seen is remembered, it never changes once set
+const appVersionCacheTTL = time.Hour
+
+// appVersionMissTTL is how long a version that was never reported is remembered as such, short for its first report
+// to be seen soon
+const appVersionMissTTL = time.Minute
+
+type appVersionKey struct {
+	projectId uuid.UUID
+	version   string
+}
+
+type cachedFirstSeen struct {
+	// firstSeen is nil for versions that were never reported
+	firstSeen *time.Time
+	loadedAt  time.Time
+}
+
+func (c cachedFirstSeen) expired() bool {
+	ttl := appVersionCacheTTL
+	if c.firstSeen == nil {
+		ttl = appVersionMissTTL
+	}
+	return time.Since(c.loadedAt) >= ttl
+}
+
+type appVersionCache struct {
+	firstSeen map[appVersionKey]cachedFirstSeen
+	// beforeCutoff are the first reports before the cutoff of app_versions, kept for good as they never change
+	beforeCutoff map[appVersionKey]*time.Time
+	sweptAt      time.Time
+	mu           sync.Mutex
+}
+
+// AppVersionCache holds when the app versions of the projects were first reported
+var AppVersionCache = &appVersionCache{
+	firstSeen:    make(map[appVersionKey]cachedFirstSeen),
+	beforeCutoff: make(map[appVersionKey]*time.Time),
+}
+
+// FirstSeen returns when the app version was first reported by the project, nil when it never was. Versions that
+// were never reported are only remembered briefly, so their first report is seen soon. The raw tables are only
+// read once per version for the reports before the cutoff of app_versions.
+func (c *appVersionCache) FirstSeen(ctx context.Context, projectId uuid.UUID, version string) (*time.Time, error) {
+	key := appVersionKey{projectId: projectId, version: version}
+
+	c.mu.Lock()
+	beforeCutoff, loaded := c.beforeCutoff[key]
+	cached, ok := c.firstSeen[key]
+	c.mu.Unlock()
+	if !loaded {
+		var err error
+		if beforeCutoff, err = repositories.AppVersionRepository.FindFirstSeenBeforeCutoff(ctx, projectId, version); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.beforeCutoff[key] = beforeCutoff
+		c.mu.Unlock()
+	}
+	// the reports after the cutoff are later
+	if beforeCutoff != nil {
+		return beforeCutoff, nil
+	}
+	if ok && !cached.expired() {
+		return cached.firstSeen, nil
+	}
+
+	firstSeen, err := repositories.AppVersionRepository.FindFirstSeen(ctx, projectId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.sweptAt) >= appVersionMissTTL {
+		for k, v := range c.firstSeen {
+			if v.expired() {
+				delete(c.firstSeen, k)
+			}
+		}
+		c.sweptAt = time.Now()
+	}
+	c.firstSeen[key] = cachedFirstSeen{firstSeen: firstSeen, loadedAt: time.Now()}
+	return firstSeen, nil
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// metricTagCacheTTL is how long the known tag values of a project are kept before they are reloaded, which
+// lets values that stopped being reported free up room under the value limit
+const metricTagCacheTTL = 24 * time.Hour
+
+type projectMetricTags struct {
+	settings models.ProjectSettings
+	values   map[string]map[string]map[string]struct{} // metric name -> tag key -> values
+	loadedAt time.Time
+}
+
+type metricTagCache struct {
+	projects map[uuid.UUID]*projectMetricTags
+	mu       sync.Mutex
+}
+
+// MetricTagCache enforces the per-project metric tag limits at ingest
+var MetricTagCache = &metricTagCache{
+	projects: make(map[uuid.UUID]*projectMetricTags),
+}
+
+// Limit applies the project's tag limits to the tags of a metric record. Keys above the key limit are dropped
+// (in key order, so the same keys survive every time) and new values above the value limit are replaced
+// with models.MetricTagOverflowValue.
+func (c *metricTagCache) Limit(ctx context.Context, projectId uuid.UUID, name string, tags map[string]string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+
+	project, err := c.load(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if keyLimit := project.settings.TagKeyLimit(); len(keys) > keyLimit {
+		keys = keys[:keyLimit]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metricValues := project.values[name]
+	if metricValues == nil {
+		metricValues = make(map[string]map[string]struct{})
+		project.values[name] = metricValues
+	}
+
+	valueLimit := project.settings.TagValueLimit()
+	limited := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := tags[key]
+		known := metricValues[key]
+		if known == nil {
+			known = make(map[string]struct{})
+			metricValues[key] = known
+		}
+		if _, ok := known[value]; !ok {
+			if len(known) >= valueLimit {
+				value = models.MetricTagOverflowValue
+			} else {
+				known[value] = struct{}{}
+			}
+		}
+		limited[key] = value
+	}
+	return limited, nil
+}
+
+// Invalidate drops the cached settings and tag values of a project, they are reloaded on the next Limit call
+func (c *metricTagCache) Invalidate(projectId uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.projects, projectId)
+}
+
+// load returns the cached tags of a project, loading its settings and the values seen in the last TTL when needed
+func (c *metricTagCache) load(ctx context.Context, projectId uuid.UUID) (*projectMetricTags, error) {
+	c.mu.Lock()
+	project := c.projects[projectId]
+	c.mu.Unlock()
+	if project != nil && time.Since(project.loadedAt) < metricTagCacheTTL {
+		return project, nil
+	}
+
+	settings, err := repositories.ProjectSettingsRepository.FindByProjectId(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := repositories.MetricRecordRepository.GetKnownTagValues(ctx, projectId, time.Now().Add(-metricTagCacheTTL), settings.TagValueLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	project = &projectMetricTags{
+		settings: *settings,
+		values:   make(map[string]map[string]map[string]struct{}, len(known)),
+		loadedAt: time.Now(),
+	}
+	for name, keys := range known {
+		project.values[name] = make(map[string]map[string]struct{}, len(keys))
+		for key, values := range keys {
+			set := make(map[string]struct{}, len(values))
+			for _, value := range values {
+				set[value] = struct{}{}
+			}
+			project.values[name][key] = set
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// another request may have loaded the project in the meantime, keep the values it already admitted
+	if existing := c.projects[projectId]; existing != nil && time.Since(existing.loadedAt) < metricTagCacheTTL {
+		return existing, nil
+	}
+	c.projects[projectId] = project
+	return project, nil
+}
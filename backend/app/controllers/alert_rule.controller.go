@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"errors"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type alertRuleController struct{}
+
+type AlertRuleListRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type SaveAlertRuleRequest struct {
+	ProjectId         uuid.UUID                 `json:"projectId"`
+	Name              string                    `json:"name" binding:"required"`
+	Type              string                    `json:"type" binding:"required"`
+	MetricName        string                    `json:"metricName"`
+	Aggregation       string                    `json:"aggregation"`
+	MetricFilter      models.MetricSeriesFilter `json:"metricFilter"`
+	Endpoint          string                    `json:"endpoint"`
+	OperationType     string                    `json:"operationType"`
+	AnomalyMetric     string                    `json:"anomalyMetric"`
+	SloId             uuid.UUID                 `json:"sloId"`
+	Comparison        string                    `json:"comparison" binding:"required"`
+	Threshold         float64                   `json:"threshold"`
+	RecoveryThreshold *float64                  `json:"recoveryThreshold"`
+	WindowMinutes     int                       `json:"windowMinutes" binding:"min=1,max=1440"`
+	ForMinutes        int                       `json:"forMinutes" binding:"min=0,max=1440"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+type AlertHistoryRequest struct {
+	ProjectId  uuid.UUID        `json:"projectId"`
+	Pagination PaginationParams `json:"pagination"`
+}
+
+// AlertRuleResponse is a rule with the state of its last evaluation, nil until it was evaluated once
+type AlertRuleResponse struct {
+	models.AlertRule
+	State *models.AlertRuleState `json:"state"`
+}
+
+func (a alertRuleController) FindAll(c *gin.Context) {
+	var request AlertRuleListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := repositories.AlertRuleRepository.FindAll(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+	states, err := repositories.AlertRuleRepository.FindStates(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	response := make([]AlertRuleResponse, len(rules))
+	for i, rule := range rules {
+		response[i] = AlertRuleResponse{AlertRule: rule}
+		if state, ok := states[rule.Id]; ok {
+			response[i].State = &state
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (a alertRuleController) Create(c *gin.Context) {
+	var request SaveAlertRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := &models.AlertRule{
+		Id:        uuid.New(),
+		ProjectId: request.ProjectId,
+	}
+	if err := applyAlertRuleRequest(rule, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkAlertRuleSlo(c, rule) {
+		return
+	}
+
+	if err := repositories.AlertRuleRepository.Save(c, rule); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, AlertRuleResponse{AlertRule: *rule})
+}
+
+func (a alertRuleController) FindById(c *gin.Context) {
+	var request AlertRuleListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, ok := findAlertRule(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	states, err := repositories.AlertRuleRepository.FindStates(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	response := AlertRuleResponse{AlertRule: *rule}
+	if state, ok := states[rule.Id]; ok {
+		response.State = &state
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Update replaces the rule's definition, its state is kept so a firing rule doesn't re-fire because it was edited
+func (a alertRuleController) Update(c *gin.Context) {
+	var request SaveAlertRuleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, ok := findAlertRule(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := applyAlertRuleRequest(rule, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkAlertRuleSlo(c, rule) {
+		return
+	}
+
+	if err := repositories.AlertRuleRepository.Save(c, rule); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, AlertRuleResponse{AlertRule: *rule})
+}
+
+func (a alertRuleController) Delete(c *gin.Context) {
+	var request AlertRuleListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, ok := findAlertRule(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := repositories.AlertRuleRepository.Delete(c, rule.ProjectId, rule.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// FindHistory returns the state transitions of every rule of the project, or of a single rule when called with :ruleId
+func (a alertRuleController) FindHistory(c *gin.Context) {
+	var request AlertHistoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ruleId *uuid.UUID
+	if c.Param("ruleId") != "" {
+		rule, ok := findAlertRule(c, request.ProjectId)
+		if !ok {
+			return
+		}
+		ruleId = &rule.Id
+	}
+
+	events, total, err := repositories.AlertEventRepository.FindByProject(c, request.ProjectId, ruleId, request.Pagination.Page, request.Pagination.PageSize)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.AlertEvent]{
+		Data: events,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+// findAlertRule loads the rule of the :ruleId param, writing the error response and returning false when it
+// is invalid or doesn't exist
+func findAlertRule(c *gin.Context, projectId uuid.UUID) (*models.AlertRule, bool) {
+	ruleId, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert rule ID"})
+		return nil, false
+	}
+
+	rule, err := repositories.AlertRuleRepository.FindById(c, projectId, ruleId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAlertRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return rule, true
+}
+
+// checkAlertRuleSlo makes sure the SLO of a burn rate rule exists in the rule's project, writing the error
+// response and returning false when it doesn't
+func checkAlertRuleSlo(c *gin.Context, rule *models.AlertRule) bool {
+	if rule.Type != models.AlertRuleTypeSloBurnRate {
+		return true
+	}
+	if _, err := repositories.SloRepository.FindById(c, rule.ProjectId, rule.SloId); err != nil {
+		if errors.Is(err, repositories.ErrSloNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "SLO not found"})
+			return false
+		}
+		panic(err)
+	}
+	return true
+}
+
+// applyAlertRuleRequest validates the request and copies it onto the rule
+func applyAlertRuleRequest(rule *models.AlertRule, request *SaveAlertRuleRequest) error {
+	nameLen := utf8.RuneCountInString(request.Name)
+	if nameLen < 1 || nameLen > 100 {
+		return errors.New("alert rule name must be between 1 and 100 characters")
+	}
+	if !models.IsValidAlertRuleType(request.Type) {
+		return errors.New("type must be one of: metric, endpoint_p95, endpoint_error_rate, exception_count, new_issue_count, anomaly, slo_burn_rate")
+	}
+	if !models.IsValidAlertComparison(request.Comparison) {
+		return errors.New("comparison must be one of: gt, gte, lt, lte")
+	}
+
+	if request.Type == models.AlertRuleTypeMetric {
+		if request.MetricName == "" {
+			return errors.New("metric rules need a metricName")
+		}
+		if request.Aggregation != "" && !models.IsValidMetricAggregation(request.Aggregation) {
+			return errors.New("aggregation must be one of: avg, min, max, sum, p95, rate")
+		}
+		for key := range request.MetricFilter.Tags {
+			if !models.IsValidMetricTagKey(key) {
+				return errors.New("invalid tag key: " + key)
+			}
+		}
+	}
+
+	if request.Type == models.AlertRuleTypeAnomaly {
+		if !models.IsValidOperationType(request.OperationType) {
+			return errors.New("anomaly rules need an operationType of endpoint or task")
+		}
+		if !models.IsValidAnomalyMetric(request.OperationType, request.AnomalyMetric) {
+			if request.OperationType == models.OperationTypeTask {
+				return errors.New("anomalyMetric must be one of: p95, throughput")
+			}
+			return errors.New("anomalyMetric must be one of: p95, throughput, error_rate")
+		}
+	}
+
+	if request.Type == models.AlertRuleTypeSloBurnRate {
+		if request.SloId == uuid.Nil {
+			return errors.New("slo_burn_rate rules need a sloId")
+		}
+		if request.Comparison != models.AlertComparisonGreaterThan && request.Comparison != models.AlertComparisonGreaterThanOrEqual {
+			return errors.New("slo_burn_rate rules must use gt or gte")
+		}
+	}
+
+	// the recovery threshold has to be on the non-breaching side of the threshold, otherwise the rule could never resolve
+	if request.RecoveryThreshold != nil {
+		switch request.Comparison {
+		case models.AlertComparisonGreaterThan, models.AlertComparisonGreaterThanOrEqual:
+			if *request.RecoveryThreshold > request.Threshold {
+				return errors.New("recoveryThreshold must not be above the threshold")
+			}
+		default:
+			if *request.RecoveryThreshold < request.Threshold {
+				return errors.New("recoveryThreshold must not be below the threshold")
+			}
+		}
+	}
+
+	rule.Name = request.Name
+	rule.Type = request.Type
+	rule.MetricName = ""
+	rule.Aggregation = ""
+	rule.MetricFilter = models.MetricSeriesFilter{}
+	if request.Type == models.AlertRuleTypeMetric {
+		rule.MetricName = request.MetricName
+		rule.Aggregation = request.Aggregation
+		rule.MetricFilter = models.MetricSeriesFilter{Servers: request.MetricFilter.Servers, Tags: request.MetricFilter.Tags}
+	}
+	rule.Endpoint = ""
+	if request.Type == models.AlertRuleTypeEndpointP95 || request.Type == models.AlertRuleTypeEndpointErrorRate || request.Type == models.AlertRuleTypeAnomaly {
+		rule.Endpoint = request.Endpoint
+	}
+	rule.OperationType = ""
+	rule.AnomalyMetric = ""
+	if request.Type == models.AlertRuleTypeAnomaly {
+		rule.OperationType = request.OperationType
+		rule.AnomalyMetric = request.AnomalyMetric
+	}
+	rule.SloId = uuid.Nil
+	if request.Type == models.AlertRuleTypeSloBurnRate {
+		rule.SloId = request.SloId
+	}
+	rule.Comparison = request.Comparison
+	rule.Threshold = request.Threshold
+	rule.RecoveryThreshold = request.RecoveryThreshold
+	rule.WindowMinutes = request.WindowMinutes
+	rule.ForMinutes = request.ForMinutes
+	rule.Enabled = request.Enabled == nil || *request.Enabled
+	return nil
+}
+
+var AlertRuleController = alertRuleController{}
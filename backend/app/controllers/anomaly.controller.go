@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/repositories"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxAnomalyRange caps the time range anomalies are searched in
+const maxAnomalyRange = 7 * 24 * time.Hour
+
+type anomalyController struct{}
+
+type FindAnomaliesRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	// OperationType narrows the search to endpoints or tasks, both when empty
+	OperationType string `json:"operationType"`
+	// Operation narrows the search to one endpoint or task, it needs an OperationType
+	Operation string `json:"operation"`
+	Metric    string `json:"metric"`
+	// FromDate and ToDate default to the last 24 hours, only complete hours are searched
+	FromDate *time.Time `json:"fromDate"`
+	ToDate   *time.Time `json:"toDate"`
+	// Threshold is the minimum absolute score of an anomaly, models.DefaultAnomalyThreshold when 0
+	Threshold float64 `json:"threshold" binding:"min=0"`
+}
+
+type AnomalyBaselineRequest struct {
+	ProjectId     uuid.UUID `json:"projectId"`
+	OperationType string    `json:"operationType" binding:"required"`
+	Operation     string    `json:"operation" binding:"required"`
+}
+
+// FindAnomalies returns the hours in which the p95, throughput or error rate of an endpoint or task deviated
+// from its seasonal baseline, most recent first
+func (a anomalyController) FindAnomalies(c *gin.Context) {
+	var request FindAnomaliesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	operationTypes := []string{models.OperationTypeEndpoint, models.OperationTypeTask}
+	if request.OperationType != "" {
+		if !models.IsValidOperationType(request.OperationType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "operationType must be one of: endpoint, task"})
+			return
+		}
+		operationTypes = []string{request.OperationType}
+	} else if request.Operation != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operation needs an operationType"})
+		return
+	}
+	if request.Metric != "" && !models.IsValidAnomalyMetric(models.OperationTypeEndpoint, request.Metric) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric must be one of: p95, throughput, error_rate"})
+		return
+	}
+
+	end := time.Now().UTC().Truncate(time.Hour)
+	if request.ToDate != nil && request.ToDate.Before(end) {
+		end = request.ToDate.UTC().Truncate(time.Hour)
+	}
+	start := end.Add(-24 * time.Hour)
+	if request.FromDate != nil {
+		start = request.FromDate.UTC().Truncate(time.Hour)
+	}
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fromDate must be before toDate"})
+		return
+	}
+	if end.Sub(start) > maxAnomalyRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time range must be at most 7 days"})
+		return
+	}
+
+	threshold := request.Threshold
+	if threshold == 0 {
+		threshold = models.DefaultAnomalyThreshold
+	}
+
+	anomalies := []models.Anomaly{}
+	for _, operationType := range operationTypes {
+		baselines, err := cache.AnomalyBaselineCache.Get(c, request.ProjectId, operationType)
+		if err != nil {
+			panic(err)
+		}
+		hourly, err := repositories.OperationStatsRepository.FindHourly(c, request.ProjectId, operationType, request.Operation, start, end)
+		if err != nil {
+			panic(err)
+		}
+
+		byOperation := make(map[string][]models.OperationStats)
+		for _, s := range hourly {
+			byOperation[s.Operation] = append(byOperation[s.Operation], s)
+		}
+		for operation, baseline := range baselines {
+			if request.Operation != "" && operation != request.Operation {
+				continue
+			}
+			for _, anomaly := range models.DetectAnomalies(baseline, byOperation[operation], start, end, threshold) {
+				if request.Metric == "" || anomaly.Metric == request.Metric {
+					anomalies = append(anomalies, anomaly)
+				}
+			}
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if !anomalies[i].Time.Equal(anomalies[j].Time) {
+			return anomalies[i].Time.After(anomalies[j].Time)
+		}
+		return math.Abs(anomalies[i].Score) > math.Abs(anomalies[j].Score)
+	})
+
+	c.JSON(http.StatusOK, anomalies)
+}
+
+// GetBaseline returns the seasonal baseline of one endpoint or task
+func (a anomalyController) GetBaseline(c *gin.Context) {
+	var request AnomalyBaselineRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.IsValidOperationType(request.OperationType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operationType must be one of: endpoint, task"})
+		return
+	}
+
+	baselines, err := cache.AnomalyBaselineCache.Get(c, request.ProjectId, request.OperationType)
+	if err != nil {
+		panic(err)
+	}
+	baseline, ok := baselines[request.Operation]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No baseline for this operation yet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, baseline)
+}
+
+var AnomalyController = anomalyController{}
@@ -0,0 +1,174 @@
+package clientcontrollers
+
+import (
+	"backend/app/middleware"
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxTaskNameLength caps task names reported through the check-in API
+	maxTaskNameLength = 200
+	// checkInLookback is how long after its in_progress check-in a run may be finished
+	checkInLookback = 24 * time.Hour
+)
+
+type checkInController struct{}
+
+type CheckInRequest struct {
+	TaskName string `json:"taskName" binding:"required"`
+	// Status is in_progress to start a run, ok or error to finish it (or to report a whole run at once)
+	Status string `json:"status" binding:"required,oneof=in_progress ok error"`
+	// CheckInId links the finishing check-in to the in_progress one, the duration is computed from the two
+	CheckInId  *uuid.UUID `json:"checkInId"`
+	DurationMs *int64     `json:"durationMs" binding:"omitempty,min=0"`
+	// Message describes the failure of an error check-in
+	Message    string `json:"message"`
+	AppVersion string `json:"appVersion"`
+	ServerName string `json:"serverName"`
+}
+
+// Heartbeat records a run of the task in the path, for jobs that can only make a request when they are done
+func (e checkInController) Heartbeat(c *gin.Context) {
+	projectId := middleware.GetProjectId(c)
+
+	taskName := strings.TrimSpace(c.Param("taskName"))
+	if err := validateTaskName(taskName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	task := models.Task{Id: uuid.New(), ProjectId: projectId, TaskName: taskName, RecordedAt: now}
+	if err := repositories.TaskRepository.InsertAsync(c, []models.Task{task}); err != nil {
+		panic(err)
+	}
+
+	checkIn := models.TaskCheckIn{Id: uuid.New(), ProjectId: projectId, TaskName: taskName, CheckInId: task.Id, Status: models.TaskCheckInOk, RecordedAt: now}
+	if err := repositories.TaskCheckInRepository.InsertAsync(c, []models.TaskCheckIn{checkIn}); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// CheckIn starts or finishes a run of a task. Finished runs are recorded as tasks, failed ones as an issue too.
+func (e checkInController) CheckIn(c *gin.Context) {
+	projectId := middleware.GetProjectId(c)
+
+	var request CheckInRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.TaskName = strings.TrimSpace(request.TaskName)
+	if err := validateTaskName(request.TaskName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	checkIn := models.TaskCheckIn{
+		Id:         uuid.New(),
+		ProjectId:  projectId,
+		TaskName:   request.TaskName,
+		CheckInId:  uuid.New(),
+		Status:     request.Status,
+		RecordedAt: now,
+	}
+	if request.CheckInId != nil {
+		checkIn.CheckInId = *request.CheckInId
+	}
+
+	if request.Status == models.TaskCheckInInProgress {
+		if err := repositories.TaskCheckInRepository.InsertAsync(c, []models.TaskCheckIn{checkIn}); err != nil {
+			panic(err)
+		}
+		c.JSON(http.StatusOK, gin.H{"checkInId": checkIn.CheckInId})
+		return
+	}
+
+	if request.DurationMs != nil {
+		checkIn.Duration = time.Duration(*request.DurationMs) * time.Millisecond
+	} else if request.CheckInId != nil {
+		started, err := repositories.TaskCheckInRepository.FindStarted(c, projectId, request.TaskName, *request.CheckInId, now.Add(-checkInLookback))
+		if err != nil && !errors.Is(err, repositories.ErrTaskCheckInNotFound) {
+			panic(err)
+		}
+		if started != nil {
+			checkIn.Duration = now.Sub(started.RecordedAt)
+		}
+	}
+
+	task := models.Task{
+		Id:         checkIn.CheckInId,
+		ProjectId:  projectId,
+		TaskName:   request.TaskName,
+		Duration:   checkIn.Duration,
+		RecordedAt: now,
+		AppVersion: request.AppVersion,
+		ServerName: request.ServerName,
+	}
+	if err := repositories.TaskRepository.InsertAsync(c, []models.Task{task}); err != nil {
+		panic(err)
+	}
+	if err := repositories.TaskCheckInRepository.InsertAsync(c, []models.TaskCheckIn{checkIn}); err != nil {
+		panic(err)
+	}
+
+	if request.Status == models.TaskCheckInError {
+		stackTraces := []models.ExceptionStackTrace{taskFailureStackTrace(&task, request.Message)}
+		issueEvents, err := notifications.DetectIssueEvents(c, projectId, stackTraces)
+		if err != nil {
+			panic(err)
+		}
+		if err := repositories.ExceptionStackTraceRepository.InsertAsync(c, stackTraces); err != nil {
+			panic(err)
+		}
+		notifications.NotifyIssueEvents(c, issueEvents)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checkInId": checkIn.CheckInId})
+}
+
+func validateTaskName(taskName string) error {
+	if taskName == "" {
+		return errors.New("taskName is required")
+	}
+	if utf8.RuneCountInString(taskName) > maxTaskNameLength {
+		return fmt.Errorf("taskName must be at most %d characters", maxTaskNameLength)
+	}
+	return nil
+}
+
+// taskFailureStackTrace records a failed run as an occurrence of the task's issue, grouped by task name so that
+// different failure messages of the same task end up in one issue
+func taskFailureStackTrace(task *models.Task, message string) models.ExceptionStackTrace {
+	hash := sha256.Sum256([]byte("task:" + task.TaskName))
+	transactionId := task.Id
+	return models.ExceptionStackTrace{
+		Id:              uuid.New(),
+		ProjectId:       task.ProjectId,
+		TransactionId:   &transactionId,
+		TransactionType: "task",
+		ExceptionHash:   hex.EncodeToString(hash[:])[:16],
+		StackTrace:      strings.TrimSpace(fmt.Sprintf("TaskFailed: %s\n%s", task.TaskName, message)),
+		RecordedAt:      task.RecordedAt,
+		AppVersion:      task.AppVersion,
+		ServerName:      task.ServerName,
+	}
+}
+
+var CheckInController = checkInController{}
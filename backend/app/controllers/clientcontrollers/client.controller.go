@@ -1,9 +1,11 @@
 package clientcontrollers
 
 import (
+	"backend/app/cache"
 	"backend/app/middleware"
 	"backend/app/models"
 	"backend/app/models/clientmodels"
+	"backend/app/notifications"
 	"backend/app/repositories"
 	"crypto/sha256"
 	"encoding/hex"
@@ -39,9 +41,17 @@ func (e clientController) Report(c *gin.Context) {
 	exceptionStackTraceToInsert := []models.ExceptionStackTrace{}
 	metricRecordsToInsert := []models.MetricRecord{}
 	segmentsToInsert := []models.Segment{}
+	performanceIssuesToInsert := []models.PerformanceIssue{}
 	for _, cf := range request.CollectionFrames {
 		for _, ct := range cf.Transactions {
+			// pin the parsed id so the transaction, its trace id and its segments agree on it
+			transactionId := ct.ParsedId()
+			ct.Id = transactionId.String()
+			traceId, _ := ct.TraceContext()
+
+			transactionType := "endpoint"
 			if ct.IsTask {
+				transactionType = "task"
 				t := ct.ToTask(request.AppVersion, request.ServerName)
 				t.ProjectId = projectId
 				tasksToInsert = append(tasksToInsert, t)
@@ -52,10 +62,18 @@ func (e clientController) Report(c *gin.Context) {
 			}
 
 			// Extract segments from transaction
+			transactionSegments := make([]models.Segment, 0, len(ct.Segments))
 			for _, cs := range ct.Segments {
-				seg := cs.ToSegment(ct.ParsedId())
+				seg := cs.ToSegment(transactionId, traceId)
 				seg.ProjectId = projectId
-				segmentsToInsert = append(segmentsToInsert, seg)
+				transactionSegments = append(transactionSegments, seg)
+			}
+			segmentsToInsert = append(segmentsToInsert, transactionSegments...)
+
+			// Flag repeated operations (N+1 queries) inside this transaction
+			for _, pi := range detectNPlusOne(transactionId, transactionType, ct.Endpoint, ct.RecordedAt, request.AppVersion, request.ServerName, transactionSegments) {
+				pi.ProjectId = projectId
+				performanceIssuesToInsert = append(performanceIssuesToInsert, pi)
 			}
 		}
 
@@ -67,8 +85,16 @@ func (e clientController) Report(c *gin.Context) {
 		}
 
 		for _, cm := range cf.Metrics {
+			if !cm.IsValid() {
+				continue
+			}
 			mr := cm.ToMetricRecord(request.ServerName)
 			mr.ProjectId = projectId
+			tags, err := cache.MetricTagCache.Limit(c, projectId, mr.Name, mr.Tags)
+			if err != nil {
+				panic(err)
+			}
+			mr.Tags = tags
 			metricRecordsToInsert = append(metricRecordsToInsert, mr)
 		}
 	}
@@ -87,7 +113,12 @@ func (e clientController) Report(c *gin.Context) {
 		}
 	}
 
-	err := repositories.ExceptionStackTraceRepository.InsertAsync(c, exceptionStackTraceToInsert)
+	issueEvents, err := notifications.DetectIssueEvents(c, projectId, exceptionStackTraceToInsert)
+	if err != nil {
+		panic(err)
+	}
+
+	err = repositories.ExceptionStackTraceRepository.InsertAsync(c, exceptionStackTraceToInsert)
 
 	if err != nil {
 		panic(err)
@@ -105,6 +136,14 @@ func (e clientController) Report(c *gin.Context) {
 		panic(err)
 	}
 
+	err = repositories.PerformanceIssueRepository.InsertAsync(c, performanceIssuesToInsert)
+
+	if err != nil {
+		panic(err)
+	}
+
+	notifications.NotifyIssueEvents(c, issueEvents)
+
 	c.JSON(http.StatusOK, gin.H{})
 }
 
@@ -0,0 +1,105 @@
+package clientcontrollers
+
+import (
+	"backend/app/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultNPlusOneThreshold is the number of repeats of one normalized segment inside a single
+// transaction above which the transaction is flagged, overridable with N_PLUS_ONE_THRESHOLD
+const defaultNPlusOneThreshold = 10
+
+var (
+	quotedStringRe = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	numberRe       = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	inListRe       = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+)
+
+func nPlusOneThreshold() int {
+	if value, err := strconv.Atoi(os.Getenv("N_PLUS_ONE_THRESHOLD")); err == nil && value > 0 {
+		return value
+	}
+	return defaultNPlusOneThreshold
+}
+
+// normalizeSegmentName strips literal values (ids, strings, numbers, IN lists) so that
+// "SELECT * FROM users WHERE id = 1" and "... id = 2" are treated as the same operation
+func normalizeSegmentName(name string) string {
+	normalized := uuidRe.ReplaceAllString(name, "?")
+	normalized = hexRe.ReplaceAllString(normalized, "?")
+	normalized = quotedStringRe.ReplaceAllString(normalized, "?")
+	normalized = numberRe.ReplaceAllString(normalized, "?")
+	normalized = inListRe.ReplaceAllString(normalized, "IN (?)")
+	normalized = spacesRe.ReplaceAllString(normalized, " ")
+	return strings.TrimSpace(normalized)
+}
+
+func computePerformanceIssueHash(issueType, transactionType, transactionName, segmentName string) string {
+	hash := sha256.Sum256([]byte(issueType + "\x00" + transactionType + "\x00" + transactionName + "\x00" + segmentName))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// detectNPlusOne flags every normalized segment name that repeats more than the threshold inside one transaction.
+// The segments passed in must all belong to that transaction.
+func detectNPlusOne(transactionId uuid.UUID, transactionType, transactionName string, recordedAt time.Time, appVersion, serverName string, segments []models.Segment) []models.PerformanceIssue {
+	threshold := nPlusOneThreshold()
+	if len(segments) <= threshold {
+		return nil
+	}
+
+	type repeat struct {
+		count    uint32
+		duration time.Duration
+	}
+	repeats := make(map[string]*repeat)
+	for _, s := range segments {
+		name := normalizeSegmentName(s.Name)
+		if name == "" {
+			continue
+		}
+		r, ok := repeats[name]
+		if !ok {
+			r = &repeat{}
+			repeats[name] = r
+		}
+		r.count++
+		r.duration += s.Duration
+	}
+
+	var issues []models.PerformanceIssue
+	for name, r := range repeats {
+		if int(r.count) <= threshold {
+			continue
+		}
+		issues = append(issues, models.PerformanceIssue{
+			Id:              uuid.New(),
+			IssueType:       models.PerformanceIssueTypeNPlusOne,
+			IssueHash:       computePerformanceIssueHash(models.PerformanceIssueTypeNPlusOne, transactionType, transactionName, name),
+			TransactionId:   transactionId,
+			TransactionType: transactionType,
+			TransactionName: transactionName,
+			SegmentName:     name,
+			RepeatCount:     r.count,
+			TotalDuration:   r.duration,
+			RecordedAt:      recordedAt,
+			AppVersion:      appVersion,
+			ServerName:      serverName,
+		})
+	}
+
+	// keep the output stable for the same input
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].SegmentName < issues[j].SegmentName
+	})
+
+	return issues
+}
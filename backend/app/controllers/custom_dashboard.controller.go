@@ -0,0 +1,351 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxDashboardWidgets caps the widgets of a dashboard, each one is a query on render
+const maxDashboardWidgets = 50
+
+type customDashboardController struct{}
+
+type CustomDashboardListRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type SaveCustomDashboardRequest struct {
+	ProjectId        uuid.UUID                `json:"projectId"`
+	Name             string                   `json:"name" binding:"required"`
+	Description      string                   `json:"description"`
+	DefaultTimeRange string                   `json:"defaultTimeRange"`
+	Widgets          []models.DashboardWidget `json:"widgets"`
+}
+
+type RenderCustomDashboardRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	// FromDate and ToDate override the dashboard's default time range
+	FromDate *time.Time `json:"fromDate"`
+	ToDate   *time.Time `json:"toDate"`
+}
+
+// RenderedWidget is the result of a widget's query, a failing widget reports its error without failing the dashboard
+type RenderedWidget struct {
+	WidgetId string                  `json:"widgetId"`
+	Type     string                  `json:"type"`
+	Title    string                  `json:"title"`
+	Layout   models.WidgetLayout     `json:"layout"`
+	Series   []models.MetricSeries   `json:"series,omitempty"`
+	Issues   []models.ExceptionGroup `json:"issues,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+type RenderCustomDashboardResponse struct {
+	Dashboard       *models.CustomDashboard `json:"dashboard"`
+	FromDate        time.Time               `json:"fromDate"`
+	ToDate          time.Time               `json:"toDate"`
+	IntervalMinutes int                     `json:"intervalMinutes"`
+	Widgets         []RenderedWidget        `json:"widgets"`
+}
+
+func (d customDashboardController) FindAll(c *gin.Context) {
+	var request CustomDashboardListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dashboards, err := repositories.CustomDashboardRepository.FindAll(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, dashboards)
+}
+
+func (d customDashboardController) Create(c *gin.Context) {
+	var request SaveCustomDashboardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dashboard := &models.CustomDashboard{
+		Id:        uuid.New(),
+		ProjectId: request.ProjectId,
+	}
+	if err := applyDashboardRequest(dashboard, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.CustomDashboardRepository.Save(c, dashboard); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, dashboard)
+}
+
+func (d customDashboardController) FindById(c *gin.Context) {
+	var request CustomDashboardListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dashboard, ok := findCustomDashboard(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+func (d customDashboardController) Update(c *gin.Context) {
+	var request SaveCustomDashboardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dashboard, ok := findCustomDashboard(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := applyDashboardRequest(dashboard, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.CustomDashboardRepository.Save(c, dashboard); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+func (d customDashboardController) Delete(c *gin.Context) {
+	var request CustomDashboardListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dashboard, ok := findCustomDashboard(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := repositories.CustomDashboardRepository.Delete(c, dashboard.ProjectId, dashboard.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// Render runs the queries of every widget of a dashboard over the requested (or default) time range
+func (d customDashboardController) Render(c *gin.Context) {
+	var request RenderCustomDashboardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dashboard, ok := findCustomDashboard(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	if request.ToDate != nil {
+		end = *request.ToDate
+	}
+	start := end.Add(-models.DashboardTimeRanges[dashboard.DefaultTimeRange])
+	if request.FromDate != nil {
+		start = *request.FromDate
+	}
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fromDate must be before toDate"})
+		return
+	}
+	intervalMinutes := calculateIntervalMinutes(end.Sub(start))
+
+	widgets := make([]RenderedWidget, 0, len(dashboard.Widgets))
+	for _, w := range dashboard.Widgets {
+		rendered := RenderedWidget{
+			WidgetId: w.Id,
+			Type:     w.Type,
+			Title:    w.Title,
+			Layout:   w.Layout,
+		}
+		if err := renderWidget(c, dashboard.ProjectId, &w, start, end, intervalMinutes, &rendered); err != nil {
+			rendered.Error = err.Error()
+		}
+		widgets = append(widgets, rendered)
+	}
+
+	c.JSON(http.StatusOK, RenderCustomDashboardResponse{
+		Dashboard:       dashboard,
+		FromDate:        start,
+		ToDate:          end,
+		IntervalMinutes: intervalMinutes,
+		Widgets:         widgets,
+	})
+}
+
+// findCustomDashboard loads the dashboard of the :dashboardId param, writing the error response and returning
+// false when it is invalid or doesn't exist
+func findCustomDashboard(c *gin.Context, projectId uuid.UUID) (*models.CustomDashboard, bool) {
+	dashboardId, err := uuid.Parse(c.Param("dashboardId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dashboard ID"})
+		return nil, false
+	}
+
+	dashboard, err := repositories.CustomDashboardRepository.FindById(c, projectId, dashboardId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCustomDashboardNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dashboard not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return dashboard, true
+}
+
+// applyDashboardRequest validates the request and copies it onto the dashboard
+func applyDashboardRequest(dashboard *models.CustomDashboard, request *SaveCustomDashboardRequest) error {
+	nameLen := utf8.RuneCountInString(request.Name)
+	if nameLen < 1 || nameLen > 100 {
+		return errors.New("dashboard name must be between 1 and 100 characters")
+	}
+
+	timeRange := request.DefaultTimeRange
+	if timeRange == "" {
+		timeRange = "24h"
+	}
+	if _, ok := models.DashboardTimeRanges[timeRange]; !ok {
+		return errors.New("defaultTimeRange must be one of: 1h, 6h, 24h, 7d, 30d")
+	}
+
+	if len(request.Widgets) > maxDashboardWidgets {
+		return fmt.Errorf("a dashboard can have at most %d widgets", maxDashboardWidgets)
+	}
+	widgets := make([]models.DashboardWidget, len(request.Widgets))
+	for i, w := range request.Widgets {
+		if err := validateDashboardWidget(&w); err != nil {
+			return fmt.Errorf("widget %d: %w", i+1, err)
+		}
+		if w.Id == "" {
+			w.Id = uuid.NewString()
+		}
+		widgets[i] = w
+	}
+
+	dashboard.Name = request.Name
+	dashboard.Description = request.Description
+	dashboard.DefaultTimeRange = timeRange
+	dashboard.Widgets = widgets
+	return nil
+}
+
+func validateDashboardWidget(w *models.DashboardWidget) error {
+	if w.Layout.X < 0 || w.Layout.Y < 0 || w.Layout.W < 1 || w.Layout.H < 1 {
+		return errors.New("layout needs a non-negative position and a positive size")
+	}
+
+	switch w.Type {
+	case models.WidgetTypeMetric:
+		if w.Query == nil || w.Query.Name == "" {
+			return errors.New("metric widgets need a query with a metric name")
+		}
+		if w.Query.Aggregation != "" && !models.IsValidMetricAggregation(w.Query.Aggregation) {
+			return errors.New("aggregation must be one of: avg, min, max, sum, p95, rate")
+		}
+		if w.Query.IntervalMinutes < 0 || w.Query.IntervalMinutes > 1440 {
+			return errors.New("intervalMinutes must be between 0 and 1440")
+		}
+		if !isValidMetricGroupBy(w.Query.GroupBy) {
+			return errors.New("groupBy must be server, none or a valid tag key")
+		}
+		for key := range w.Query.Tags {
+			if !models.IsValidMetricTagKey(key) {
+				return errors.New("invalid tag key: " + key)
+			}
+		}
+	case models.WidgetTypeEndpointLatency, models.WidgetTypeErrorRate:
+	case models.WidgetTypeTopIssues:
+		if w.Limit < 0 || w.Limit > 50 {
+			return errors.New("limit must be between 0 and 50")
+		}
+	case models.WidgetTypeUptimeAvailability, models.WidgetTypeUptimeResponseTime:
+		if w.CheckId == nil {
+			return errors.New("uptime widgets need a checkId")
+		}
+	default:
+		return errors.New("type must be one of: metric, endpoint_latency, error_rate, top_issues, uptime_availability, uptime_response_time")
+	}
+	return nil
+}
+
+// renderWidget runs the query of a single widget into rendered
+func renderWidget(c *gin.Context, projectId uuid.UUID, w *models.DashboardWidget, start, end time.Time, intervalMinutes int, rendered *RenderedWidget) error {
+	switch w.Type {
+	case models.WidgetTypeMetric:
+		interval := intervalMinutes
+		if w.Query.IntervalMinutes > 0 && end.Sub(start)/(time.Duration(w.Query.IntervalMinutes)*time.Minute) <= maxMetricQueryPoints {
+			interval = w.Query.IntervalMinutes
+		}
+		series, _, _, err := queryMetricSeries(c, projectId, w.Query.Name, w.Query.Aggregation, start, end, interval, w.Query.MetricSeriesFilter)
+		if err != nil {
+			return err
+		}
+		rendered.Series = toMetricSeries(series)
+	case models.WidgetTypeEndpointLatency:
+		series, err := repositories.EndpointRepository.LatencyByInterval(c, projectId, w.Endpoint, start, end, intervalMinutes)
+		if err != nil {
+			return err
+		}
+		rendered.Series = toMetricSeries(series)
+	case models.WidgetTypeErrorRate:
+		points, err := repositories.EndpointRepository.ErrorRateByIntervalForEndpoint(c, projectId, w.Endpoint, start, end, intervalMinutes)
+		if err != nil {
+			return err
+		}
+		rendered.Series = toMetricSeries(map[string][]models.TimeSeriesPoint{"error_rate": points})
+	case models.WidgetTypeTopIssues:
+		limit := w.Limit
+		if limit == 0 {
+			limit = 10
+		}
+		issues, _, err := repositories.ExceptionStackTraceRepository.FindGrouped(c, projectId, start, end, 1, limit, "count", "", "issues", models.IssueFilter{Statuses: []string{models.IssueStatusUnresolved}})
+		if err != nil {
+			return err
+		}
+		rendered.Issues = issues
+	case models.WidgetTypeUptimeAvailability:
+		points, err := repositories.UptimeResultRepository.AvailabilityByInterval(c, projectId, *w.CheckId, start, end, intervalMinutes)
+		if err != nil {
+			return err
+		}
+		rendered.Series = toMetricSeries(map[string][]models.TimeSeriesPoint{"availability": points})
+	case models.WidgetTypeUptimeResponseTime:
+		series, err := repositories.UptimeResultRepository.ResponseTimeByInterval(c, projectId, *w.CheckId, start, end, intervalMinutes)
+		if err != nil {
+			return err
+		}
+		rendered.Series = toMetricSeries(series)
+	}
+	return nil
+}
+
+var CustomDashboardController = customDashboardController{}
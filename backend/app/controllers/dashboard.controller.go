@@ -30,7 +30,7 @@ func (d dashboardController) GetDashboardOverview(c *gin.Context) {
 	start := now.Add(-24 * time.Hour)
 
 	// Get last 10 issues in the last 24 hours (only exceptions, not messages)
-	recentIssues, _, err := repositories.ExceptionStackTraceRepository.FindGrouped(c, projectId, start, now, 1, 10, "last_seen", "", "issues", false)
+	recentIssues, _, err := repositories.ExceptionStackTraceRepository.FindGrouped(c, projectId, start, now, 1, 10, "last_seen", "", "issues", models.IssueFilter{Statuses: []string{models.IssueStatusUnresolved}})
 	if err != nil {
 		panic(err)
 	}
@@ -180,28 +180,23 @@ func (d dashboardController) GetDashboard(c *gin.Context) {
 	heapObjectsPrev, _ := repositories.MetricRecordRepository.GetAverageBetween(c, projectId, models.MetricNameHeapObjects, prevStart, prevEnd)
 	metrics = append(metrics, buildMetricWithServers("heap_objects", "Heap Objects", "", heapObjectsPerServer, heapObjectsPrev, "heap_objects"))
 
-	// 10. Num GC
-	numGCPerServer, err := repositories.MetricRecordRepository.GetAverageByIntervalPerServer(c, projectId, models.MetricNameNumGC, start, end, intervalMinutes, selectedServers)
+	// 10. GC Cycles (counter, shown as cycles per minute)
+	numGCPerServer, err := repositories.MetricRecordRepository.GetRateByIntervalPerServer(c, projectId, models.MetricNameNumGC, start, end, intervalMinutes, selectedServers)
 	if err != nil {
 		panic(err)
 	}
-	numGCPrev, _ := repositories.MetricRecordRepository.GetAverageBetween(c, projectId, models.MetricNameNumGC, prevStart, prevEnd)
-	metrics = append(metrics, buildMetricWithServers("num_gc", "GC Cycles", "", numGCPerServer, numGCPrev, "num_gc"))
+	scaleServerSeries(numGCPerServer, 60)
+	numGCPrev, _ := repositories.MetricRecordRepository.GetRateBetween(c, projectId, models.MetricNameNumGC, prevStart, prevEnd)
+	metrics = append(metrics, buildMetricWithServers("num_gc", "GC Cycles", "/min", numGCPerServer, numGCPrev*60, "num_gc"))
 
-	// 11. GC Pause Total (convert from nanoseconds to milliseconds)
-	gcPausePerServer, err := repositories.MetricRecordRepository.GetAverageByIntervalPerServer(c, projectId, models.MetricNameGCPauseTotal, start, end, intervalMinutes, selectedServers)
+	// 11. GC Pause (counter of total pause nanoseconds, shown as milliseconds paused per minute)
+	gcPausePerServer, err := repositories.MetricRecordRepository.GetRateByIntervalPerServer(c, projectId, models.MetricNameGCPauseTotal, start, end, intervalMinutes, selectedServers)
 	if err != nil {
 		panic(err)
 	}
-	// Convert nanoseconds to milliseconds for each server's data
-	for serverName, points := range gcPausePerServer {
-		for i := range points {
-			gcPausePerServer[serverName][i].Value = points[i].Value / 1_000_000
-		}
-	}
-	gcPausePrevRaw, _ := repositories.MetricRecordRepository.GetAverageBetween(c, projectId, models.MetricNameGCPauseTotal, prevStart, prevEnd)
-	gcPausePrev := gcPausePrevRaw / 1_000_000
-	metrics = append(metrics, buildMetricWithServers("gc_pause", "GC Pause", "ms", gcPausePerServer, gcPausePrev, "gc_pause"))
+	scaleServerSeries(gcPausePerServer, 60.0/1_000_000)
+	gcPausePrev, _ := repositories.MetricRecordRepository.GetRateBetween(c, projectId, models.MetricNameGCPauseTotal, prevStart, prevEnd)
+	metrics = append(metrics, buildMetricWithServers("gc_pause", "GC Pause", "ms/min", gcPausePerServer, gcPausePrev*60/1_000_000, "gc_pause"))
 
 	c.JSON(http.StatusOK, models.DashboardResponse{
 		Metrics:          metrics,
@@ -414,6 +409,15 @@ func calculateStatus(value float64, metricType string) string {
 	return "healthy"
 }
 
+// scaleServerSeries multiplies every point of every server's series by factor (unit conversions)
+func scaleServerSeries(serverData map[string][]models.TimeSeriesPoint, factor float64) {
+	for serverName, points := range serverData {
+		for i := range points {
+			serverData[serverName][i].Value = points[i].Value * factor
+		}
+	}
+}
+
 func getLastValue(points []models.TimeSeriesPoint) float64 {
 	if len(points) == 0 {
 		return 0
@@ -3,6 +3,7 @@ package controllers
 import (
 	"backend/app/models"
 	"backend/app/repositories"
+	"errors"
 	"net/http"
 	"net/url"
 	"time"
@@ -20,6 +21,15 @@ type EndpointSearchRequest struct {
 	OrderBy       string           `json:"orderBy"`
 	SortDirection string           `json:"sortDirection"`
 	Pagination    PaginationParams `json:"pagination"`
+	// ScopeFilters limit the results to the records whose scope matches every filter
+	ScopeFilters []models.ScopeFilter `json:"scopeFilters"`
+	// Filter is a filter expression such as status_code >= 500 AND scope.tenant = "acme"
+	Filter string `json:"filter"`
+	// Cursor switches the listing to keyset pagination, "" asking for the first page and the nextCursor of a page
+	// for the one after it. Pagination.Page is ignored.
+	Cursor *string `json:"cursor"`
+	// IncludeTotal counts the exact total of a cursor listing, which reads every matching row
+	IncludeTotal bool `json:"includeTotal"`
 }
 
 type EndpointInstancesRequest struct {
@@ -29,6 +39,15 @@ type EndpointInstancesRequest struct {
 	OrderBy       string           `json:"orderBy"`
 	SortDirection string           `json:"sortDirection"`
 	Pagination    PaginationParams `json:"pagination"`
+	// ScopeFilters limit the results to the records whose scope matches every filter
+	ScopeFilters []models.ScopeFilter `json:"scopeFilters"`
+	// Filter is a filter expression such as status_code >= 500 AND scope.tenant = "acme"
+	Filter string `json:"filter"`
+	// Cursor switches the listing to keyset pagination, "" asking for the first page and the nextCursor of a page
+	// for the one after it. Pagination.Page is ignored.
+	Cursor *string `json:"cursor"`
+	// IncludeTotal counts the exact total of a cursor listing, which reads every matching row
+	IncludeTotal bool `json:"includeTotal"`
 }
 
 type EndpointInstancesResponse struct {
@@ -37,14 +56,37 @@ type EndpointInstancesResponse struct {
 	Pagination Pagination                  `json:"pagination"`
 }
 
+type EndpointInstancesCursorResponse struct {
+	Data       []models.Endpoint           `json:"data"`
+	Stats      *models.EndpointDetailStats `json:"stats"`
+	Pagination CursorPagination            `json:"pagination"`
+}
+
 func (e endpointController) FindAllEndpoints(c *gin.Context) {
-	var request EndpointSearchRequest
+	request := EndpointSearchRequest{Pagination: PaginationParams{Page: 1}}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.EndpointFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	endpoints, total, err := repositories.EndpointRepository.FindAll(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy)
+	if request.Cursor != nil {
+		endpoints, pagination, ok := findEndpointsPage(c, request, "", expression)
+		if ok {
+			c.JSON(http.StatusOK, CursorPaginatedResponse[models.Endpoint]{Data: endpoints, Pagination: pagination})
+		}
+		return
+	}
+
+	endpoints, total, err := repositories.EndpointRepository.FindAll(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.ScopeFilters, expression)
 	if err != nil {
 		panic(err)
 	}
@@ -66,8 +108,17 @@ func (e endpointController) FindGroupedByEndpoint(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.EndpointFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	stats, total, err := repositories.EndpointRepository.FindGroupedByEndpoint(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection)
+	stats, total, err := repositories.EndpointRepository.FindGroupedByEndpoint(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection, request.ScopeFilters, expression)
 	if err != nil {
 		panic(err)
 	}
@@ -96,19 +147,41 @@ func (e endpointController) FindByEndpoint(c *gin.Context) {
 		endpoint = rawEndpoint // fallback to raw value if decoding fails
 	}
 
-	var request EndpointInstancesRequest
+	request := EndpointInstancesRequest{Pagination: PaginationParams{Page: 1}}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.EndpointFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Cursor != nil {
+		endpoints, pagination, ok := findEndpointsPage(c, EndpointSearchRequest(request), endpoint, expression)
+		if !ok {
+			return
+		}
+		stats, err := repositories.EndpointRepository.GetEndpointStats(c, request.ProjectId, endpoint, request.FromDate, request.ToDate, request.ScopeFilters, expression)
+		if err != nil {
+			stats = nil
+		}
+		c.JSON(http.StatusOK, EndpointInstancesCursorResponse{Data: endpoints, Stats: stats, Pagination: pagination})
+		return
+	}
 
-	endpoints, total, err := repositories.EndpointRepository.FindByEndpoint(c, request.ProjectId, endpoint, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection)
+	endpoints, total, err := repositories.EndpointRepository.FindByEndpoint(c, request.ProjectId, endpoint, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection, request.ScopeFilters, expression)
 	if err != nil {
 		panic(err)
 	}
 
 	// Get aggregate stats for this endpoint
-	stats, err := repositories.EndpointRepository.GetEndpointStats(c, request.ProjectId, endpoint, request.FromDate, request.ToDate)
+	stats, err := repositories.EndpointRepository.GetEndpointStats(c, request.ProjectId, endpoint, request.FromDate, request.ToDate, request.ScopeFilters, expression)
 	if err != nil {
 		// Don't fail the request if stats fail, just return nil stats
 		stats = nil
@@ -126,4 +199,32 @@ func (e endpointController) FindByEndpoint(c *gin.Context) {
 	})
 }
 
+// findEndpointsPage lists the requests of a project, or of one endpoint when endpoint isn't empty, from the cursor of the
+// request. Nothing is counted unless the total is asked for.
+func findEndpointsPage(c *gin.Context, request EndpointSearchRequest, endpoint string, expression *models.FilterNode) ([]models.Endpoint, CursorPagination, bool) {
+	cursor, ok := decodePageCursor(c, *request.Cursor)
+	if !ok {
+		return nil, CursorPagination{}, false
+	}
+
+	endpoints, next, err := repositories.EndpointRepository.FindPage(c, request.ProjectId, endpoint, request.FromDate, request.ToDate, request.Pagination.PageSize, request.OrderBy, request.SortDirection, cursor, request.ScopeFilters, expression)
+	if errors.Is(err, models.ErrInvalidPageCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cursor doesn't match the sort of the listing"})
+		return nil, CursorPagination{}, false
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	pagination := newCursorPagination(request.Pagination.PageSize, next)
+	if request.IncludeTotal {
+		total, err := repositories.EndpointRepository.Count(c, request.ProjectId, endpoint, request.FromDate, request.ToDate, request.ScopeFilters, expression)
+		if err != nil {
+			panic(err)
+		}
+		pagination.Total = &total
+	}
+	return endpoints, pagination, true
+}
+
 var EndpointController = endpointController{}
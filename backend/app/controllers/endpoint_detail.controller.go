@@ -30,11 +30,13 @@ type EndpointMessageInfo struct {
 }
 
 type EndpointDetailResponse struct {
-	Endpoint    *models.Endpoint       `json:"endpoint"`
-	Segments    []models.Segment       `json:"segments"`
-	HasSegments bool                   `json:"hasSegments"`
-	Exception   *EndpointExceptionInfo `json:"exception,omitempty"`
-	Messages    []EndpointMessageInfo  `json:"messages"`
+	Endpoint          *models.Endpoint          `json:"endpoint"`
+	Segments          []models.Segment          `json:"segments"`
+	SegmentTree       []*models.SegmentNode     `json:"segmentTree"`
+	HasSegments       bool                      `json:"hasSegments"`
+	Exception         *EndpointExceptionInfo    `json:"exception,omitempty"`
+	Messages          []EndpointMessageInfo     `json:"messages"`
+	PerformanceIssues []models.PerformanceIssue `json:"performanceIssues"`
 }
 
 func (c endpointDetailController) GetEndpointDetail(ctx *gin.Context) {
@@ -57,7 +59,7 @@ func (c endpointDetailController) GetEndpointDetail(ctx *gin.Context) {
 		return
 	}
 
-	// Get segments (flat list ordered by start_time)
+	// Get segments (flat list ordered by start_time) and nest them by parent_id
 	segments, err := repositories.SegmentRepository.FindByTransactionId(ctx, request.ProjectId, endpointId)
 	if err != nil {
 		panic(err)
@@ -96,12 +98,23 @@ func (c endpointDetailController) GetEndpointDetail(ctx *gin.Context) {
 		messages = []EndpointMessageInfo{}
 	}
 
+	// Get performance issues (N+1 queries) detected in this transaction
+	performanceIssues, err := repositories.PerformanceIssueRepository.FindByTransactionId(ctx, request.ProjectId, endpointId)
+	if err != nil {
+		panic(err)
+	}
+	if performanceIssues == nil {
+		performanceIssues = []models.PerformanceIssue{}
+	}
+
 	ctx.JSON(http.StatusOK, EndpointDetailResponse{
-		Endpoint:    endpoint,
-		Segments:    segments,
-		HasSegments: len(segments) > 0,
-		Exception:   exceptionInfo,
-		Messages:    messages,
+		Endpoint:          endpoint,
+		Segments:          segments,
+		SegmentTree:       models.BuildSegmentTree(segments),
+		HasSegments:       len(segments) > 0,
+		Exception:         exceptionInfo,
+		Messages:          messages,
+		PerformanceIssues: performanceIssues,
 	})
 }
 
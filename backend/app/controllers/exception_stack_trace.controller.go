@@ -14,14 +14,24 @@ import (
 type exceptionStackTraceController struct{}
 
 type ExceptionSearchRequest struct {
-	ProjectId       uuid.UUID        `json:"projectId"`
-	FromDate        time.Time        `json:"fromDate"`
-	ToDate          time.Time        `json:"toDate"`
-	OrderBy         string           `json:"orderBy"`
-	Pagination      PaginationParams `json:"pagination"`
-	Search          string           `json:"search"`
-	SearchType      string           `json:"searchType"`
-	IncludeArchived bool             `json:"includeArchived"`
+	ProjectId  uuid.UUID        `json:"projectId"`
+	FromDate   time.Time        `json:"fromDate"`
+	ToDate     time.Time        `json:"toDate"`
+	OrderBy    string           `json:"orderBy"`
+	Pagination PaginationParams `json:"pagination"`
+	// Search matches whole words and "quoted phrases" of stack traces and messages, a leading - excludes them
+	Search          string `json:"search"`
+	SearchType      string `json:"searchType"`
+	IncludeArchived bool   `json:"includeArchived"`
+	// Statuses defaults to unresolved issues only, or every status with IncludeArchived
+	Statuses []string `json:"statuses"`
+	// Assignee filters on the assignee, the empty string listing unassigned issues
+	Assignee   *string  `json:"assignee"`
+	Priorities []string `json:"priorities"`
+	// ScopeFilters only count the occurrences whose scope matches every filter
+	ScopeFilters []models.ScopeFilter `json:"scopeFilters"`
+	// Filter is a filter expression on the occurrences such as server_name IN (a, b) AND scope.tenant = "acme"
+	Filter string `json:"filter"`
 }
 
 type ArchiveRequest struct {
@@ -32,14 +42,37 @@ type ArchiveRequest struct {
 type ExceptionDetailRequest struct {
 	ProjectId  uuid.UUID        `json:"projectId"`
 	Pagination PaginationParams `json:"pagination"`
+	// ScopeFilters limit the occurrences listed to those whose scope matches every filter
+	ScopeFilters []models.ScopeFilter `json:"scopeFilters"`
+	// Cursor switches the occurrences to keyset pagination, "" asking for the first page and the nextCursor of a
+	// page for the one after it. Pagination.Page is ignored.
+	Cursor *string `json:"cursor"`
+	// IncludeTotal counts the occurrences matching the scope filters of a cursor listing
+	IncludeTotal bool `json:"includeTotal"`
+}
+
+type ExceptionFacetsRequest struct {
+	ProjectId uuid.UUID  `json:"projectId"`
+	FromDate  *time.Time `json:"fromDate"`
+	ToDate    *time.Time `json:"toDate"`
+	// Limit is the number of top values returned per facet, 10 by default
+	Limit int `json:"limit" binding:"omitempty,min=1,max=100"`
 }
 
 type ExceptionDetailResponse struct {
 	Group       *models.ExceptionGroup       `json:"group"`
+	Issue       models.Issue                 `json:"issue"`
 	Occurrences []models.ExceptionStackTrace `json:"occurrences"`
 	Pagination  Pagination                   `json:"pagination"`
 }
 
+type ExceptionDetailCursorResponse struct {
+	Group       *models.ExceptionGroup       `json:"group"`
+	Issue       models.Issue                 `json:"issue"`
+	Occurrences []models.ExceptionStackTrace `json:"occurrences"`
+	Pagination  CursorPagination             `json:"pagination"`
+}
+
 func (e exceptionStackTraceController) FindGrouppedExceptionStackTraces(c *gin.Context) {
 	var request ExceptionSearchRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -47,7 +80,39 @@ func (e exceptionStackTraceController) FindGrouppedExceptionStackTraces(c *gin.C
 		return
 	}
 
-	exceptions, total, err := repositories.ExceptionStackTraceRepository.FindGrouped(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.Search, request.SearchType, request.IncludeArchived)
+	filter := models.IssueFilter{
+		Statuses:   request.Statuses,
+		Assignee:   request.Assignee,
+		Priorities: request.Priorities,
+		Scope:      request.ScopeFilters,
+	}
+	for _, status := range filter.Statuses {
+		if !models.IssueStatuses[status] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "statuses must be one of: unresolved, resolved, ignored, snoozed"})
+			return
+		}
+	}
+	for _, priority := range filter.Priorities {
+		if !models.IsValidIssuePriority(priority) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priorities must be one of: low, medium, high, critical"})
+			return
+		}
+	}
+	if err := models.ValidateScopeFilters(filter.Scope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.ExceptionFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Expression = expression
+	if len(filter.Statuses) == 0 && !request.IncludeArchived {
+		filter.Statuses = []string{models.IssueStatusUnresolved}
+	}
+
+	exceptions, total, err := repositories.ExceptionStackTraceRepository.FindGrouped(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.Search, request.SearchType, filter)
 	if err != nil {
 		panic(err)
 	}
@@ -101,8 +166,17 @@ func (e exceptionStackTraceController) FindByHash(c *gin.Context) {
 		// Default pagination if not provided
 		request.Pagination = PaginationParams{Page: 1, PageSize: 20}
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Cursor != nil {
+		e.findOccurrencesPage(c, exceptionHash, request)
+		return
+	}
 
-	group, occurrences, total, err := repositories.ExceptionStackTraceRepository.FindByHash(c, request.ProjectId, exceptionHash, request.Pagination.Page, request.Pagination.PageSize)
+	group, occurrences, total, err := repositories.ExceptionStackTraceRepository.FindByHash(c, request.ProjectId, exceptionHash, request.Pagination.Page, request.Pagination.PageSize, request.ScopeFilters)
 	if err != nil {
 		if errors.Is(err, repositories.ErrExceptionNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Exception not found"})
@@ -111,8 +185,17 @@ func (e exceptionStackTraceController) FindByHash(c *gin.Context) {
 		panic(err)
 	}
 
+	issue, err := repositories.IssueRepository.FindByHash(c, request.ProjectId, exceptionHash)
+	if err != nil {
+		panic(err)
+	}
+	group.Status = issue.Status
+	group.Assignee = issue.Assignee
+	group.Priority = issue.Priority
+
 	c.JSON(http.StatusOK, ExceptionDetailResponse{
 		Group:       group,
+		Issue:       issue,
 		Occurrences: occurrences,
 		Pagination: Pagination{
 			Page:       request.Pagination.Page,
@@ -123,6 +206,99 @@ func (e exceptionStackTraceController) FindByHash(c *gin.Context) {
 	})
 }
 
+// findOccurrencesPage answers the detail of an exception group with its occurrences listed from the cursor of the
+// request. The occurrences are only counted when the total is asked for and scope filters are set.
+func (e exceptionStackTraceController) findOccurrencesPage(c *gin.Context, exceptionHash string, request ExceptionDetailRequest) {
+	cursor, ok := decodePageCursor(c, *request.Cursor)
+	if !ok {
+		return
+	}
+
+	group, err := repositories.ExceptionStackTraceRepository.FindGroupByHash(c, request.ProjectId, exceptionHash)
+	if err != nil {
+		if errors.Is(err, repositories.ErrExceptionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exception not found"})
+			return
+		}
+		panic(err)
+	}
+
+	occurrences, next, err := repositories.ExceptionStackTraceRepository.FindOccurrencesPage(c, request.ProjectId, exceptionHash, request.Pagination.PageSize, cursor, request.ScopeFilters)
+	if errors.Is(err, models.ErrInvalidPageCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cursor doesn't match the sort of the listing"})
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	pagination := newCursorPagination(request.Pagination.PageSize, next)
+	if request.IncludeTotal {
+		total := int64(group.Count)
+		if len(request.ScopeFilters) > 0 {
+			if total, err = repositories.ExceptionStackTraceRepository.CountOccurrences(c, request.ProjectId, exceptionHash, request.ScopeFilters); err != nil {
+				panic(err)
+			}
+		}
+		pagination.Total = &total
+	}
+
+	issue, err := repositories.IssueRepository.FindByHash(c, request.ProjectId, exceptionHash)
+	if err != nil {
+		panic(err)
+	}
+	group.Status = issue.Status
+	group.Assignee = issue.Assignee
+	group.Priority = issue.Priority
+
+	c.JSON(http.StatusOK, ExceptionDetailCursorResponse{
+		Group:       group,
+		Issue:       issue,
+		Occurrences: occurrences,
+		Pagination:  pagination,
+	})
+}
+
+// FindFacets breaks the occurrences of an exception group down by server, app version, transaction type and each
+// scope key, over the last 30 days by default
+func (e exceptionStackTraceController) FindFacets(c *gin.Context) {
+	exceptionHash := c.Param("hash")
+	if exceptionHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exception hash is required"})
+		return
+	}
+
+	var request ExceptionFacetsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	toDate := time.Now()
+	if request.ToDate != nil {
+		toDate = *request.ToDate
+	}
+	fromDate := toDate.AddDate(0, 0, -30)
+	if request.FromDate != nil {
+		fromDate = *request.FromDate
+	}
+	if !fromDate.Before(toDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fromDate must be before toDate"})
+		return
+	}
+	limit := request.Limit
+	if limit == 0 {
+		limit = 10
+	}
+
+	facets, err := repositories.ExceptionStackTraceRepository.FindFacets(c, request.ProjectId, exceptionHash, fromDate, toDate, limit)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, facets)
+}
+
 func (e exceptionStackTraceController) ArchiveExceptions(c *gin.Context) {
 	var request ArchiveRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -135,10 +311,24 @@ func (e exceptionStackTraceController) ArchiveExceptions(c *gin.Context) {
 		return
 	}
 
-	err := repositories.ExceptionStackTraceRepository.ArchiveByHashes(c, request.ProjectId, request.Hashes)
+	// performance issues are archived with their issue hash, they have no issue to resolve
+	performanceHashes, err := repositories.PerformanceIssueRepository.FindExistingHashes(c, request.ProjectId, request.Hashes)
 	if err != nil {
 		panic(err)
 	}
+	if err := repositories.PerformanceIssueRepository.ArchiveByHashes(c, request.ProjectId, performanceHashes); err != nil {
+		panic(err)
+	}
+
+	// archiving predates the issue workflow and resolves the issues
+	resolved := models.IssueStatusResolved
+	if _, err := updateIssues(c, request.ProjectId, request.Hashes, IssueChanges{Status: &resolved}); err != nil {
+		if errors.Is(err, repositories.ErrIssueConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		panic(err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"archived": len(request.Hashes)})
 }
@@ -155,10 +345,22 @@ func (e exceptionStackTraceController) UnarchiveExceptions(c *gin.Context) {
 		return
 	}
 
-	err := repositories.ExceptionStackTraceRepository.UnarchiveByHashes(c, request.ProjectId, request.Hashes)
+	performanceHashes, err := repositories.PerformanceIssueRepository.FindExistingHashes(c, request.ProjectId, request.Hashes)
 	if err != nil {
 		panic(err)
 	}
+	if err := repositories.PerformanceIssueRepository.UnarchiveByHashes(c, request.ProjectId, performanceHashes); err != nil {
+		panic(err)
+	}
+
+	unresolved := models.IssueStatusUnresolved
+	if _, err := updateIssues(c, request.ProjectId, request.Hashes, IssueChanges{Status: &unresolved}); err != nil {
+		if errors.Is(err, repositories.ErrIssueConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		panic(err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"unarchived": len(request.Hashes)})
 }
@@ -0,0 +1,469 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type issueController struct{}
+
+// IssueChanges are the fields of an issue to change, unset fields are left as they are
+type IssueChanges struct {
+	Status *string `json:"status"`
+	// Resolution is how a resolved status resolves the issue, now (the default) or next_release
+	Resolution string `json:"resolution"`
+	// Snooze snoozes the issue until any of its conditions is met
+	Snooze *models.IssueSnooze `json:"snooze"`
+	// Assignee is who the issue is assigned to, the empty string unassigning it
+	Assignee *string `json:"assignee"`
+	Priority *string `json:"priority"`
+	// Actor is who made the change, recorded in the timeline
+	Actor string `json:"actor"`
+}
+
+type IssueRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type UpdateIssueRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	IssueChanges
+}
+
+type UpdateIssuesRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	Hashes    []string  `json:"hashes" binding:"required,min=1,max=100"`
+	IssueChanges
+}
+
+type IssueActivityRequest struct {
+	ProjectId  uuid.UUID        `json:"projectId"`
+	Pagination PaginationParams `json:"pagination"`
+}
+
+type SaveIssueCommentRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body" binding:"required"`
+}
+
+func (i issueController) FindByHash(c *gin.Context) {
+	var request IssueRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	issue, ok := findIssue(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, issue)
+}
+
+func (i issueController) Update(c *gin.Context) {
+	var request UpdateIssueRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIssueChanges(&request.IssueChanges); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	issue, ok := findIssue(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if _, err := updateIssues(c, request.ProjectId, []string{issue.ExceptionHash}, request.IssueChanges); err != nil {
+		if errors.Is(err, repositories.ErrIssueConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		panic(err)
+	}
+
+	updated, err := repositories.IssueRepository.FindByHash(c, request.ProjectId, issue.ExceptionHash)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// UpdateMany applies the same changes to several issues at once
+func (i issueController) UpdateMany(c *gin.Context) {
+	var request UpdateIssuesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIssueChanges(&request.IssueChanges); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := updateIssues(c, request.ProjectId, request.Hashes, request.IssueChanges)
+	if err != nil {
+		if errors.Is(err, repositories.ErrIssueConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// FindActivity returns the timeline of an issue, most recent first
+func (i issueController) FindActivity(c *gin.Context) {
+	var request IssueActivityRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	issue, ok := findIssue(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	activities, total, err := repositories.IssueRepository.FindActivities(c, request.ProjectId, issue.ExceptionHash, request.Pagination.Page, request.Pagination.PageSize)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.IssueActivity]{
+		Data: activities,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+func (i issueController) FindComments(c *gin.Context) {
+	var request IssueRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	issue, ok := findIssue(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	comments, err := repositories.IssueCommentRepository.FindByHash(c, request.ProjectId, issue.ExceptionHash)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+func (i issueController) CreateComment(c *gin.Context) {
+	var request SaveIssueCommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIssueComment(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	issue, ok := findIssue(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	comment := &models.IssueComment{
+		Id:            uuid.New(),
+		ProjectId:     request.ProjectId,
+		ExceptionHash: issue.ExceptionHash,
+		Author:        strings.TrimSpace(request.Author),
+		Body:          strings.TrimSpace(request.Body),
+	}
+	if err := repositories.IssueCommentRepository.Save(c, comment); err != nil {
+		panic(err)
+	}
+
+	activity := models.IssueActivity{
+		Id:            uuid.New(),
+		ProjectId:     comment.ProjectId,
+		ExceptionHash: comment.ExceptionHash,
+		Actor:         comment.Author,
+		Action:        models.IssueActivityCommented,
+		NewValue:      comment.Id.String(),
+		CreatedAt:     comment.CreatedAt,
+	}
+	if err := repositories.IssueRepository.InsertActivities(c, []models.IssueActivity{activity}); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (i issueController) UpdateComment(c *gin.Context) {
+	var request SaveIssueCommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIssueComment(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, ok := findIssueComment(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	comment.Body = strings.TrimSpace(request.Body)
+	if err := repositories.IssueCommentRepository.Save(c, comment); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+func (i issueController) DeleteComment(c *gin.Context) {
+	var request IssueRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, ok := findIssueComment(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := repositories.IssueCommentRepository.Delete(c, comment.ProjectId, comment.ExceptionHash, comment.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// findIssue loads the issue of the :hash param, writing the error response and returning false when the hash
+// never occurred in the project
+func findIssue(c *gin.Context, projectId uuid.UUID) (*models.Issue, bool) {
+	exceptionHash := c.Param("hash")
+	if exceptionHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exception hash is required"})
+		return nil, false
+	}
+
+	exists, err := repositories.ExceptionStackTraceRepository.Exists(c, projectId, exceptionHash)
+	if err != nil {
+		panic(err)
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Issue not found"})
+		return nil, false
+	}
+
+	issue, err := repositories.IssueRepository.FindByHash(c, projectId, exceptionHash)
+	if err != nil {
+		panic(err)
+	}
+	return &issue, true
+}
+
+// findIssueComment loads the comment of the :hash and :commentId params, writing the error response and
+// returning false when it is invalid or doesn't exist
+func findIssueComment(c *gin.Context, projectId uuid.UUID) (*models.IssueComment, bool) {
+	commentId, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return nil, false
+	}
+
+	comment, err := repositories.IssueCommentRepository.FindById(c, projectId, c.Param("hash"), commentId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrIssueCommentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return comment, true
+}
+
+func validateIssueChanges(changes *IssueChanges) error {
+	if changes.Status == nil && changes.Snooze == nil && changes.Assignee == nil && changes.Priority == nil {
+		return errors.New("one of status, snooze, assignee or priority is required")
+	}
+	if changes.Status != nil && !models.IssueStatuses[*changes.Status] {
+		return errors.New("status must be one of: unresolved, resolved, ignored, snoozed")
+	}
+	if changes.Snooze != nil {
+		if changes.Status != nil && *changes.Status != models.IssueStatusSnoozed {
+			return errors.New("snooze can't be combined with status " + *changes.Status)
+		}
+		if err := validateIssueSnooze(changes.Snooze); err != nil {
+			return err
+		}
+	} else if changes.Status != nil && *changes.Status == models.IssueStatusSnoozed {
+		return errors.New("snooze conditions are required to snooze an issue")
+	}
+	switch changes.Resolution {
+	case "", models.IssueResolutionNow:
+	case models.IssueResolutionNextRelease:
+		if changes.Status == nil || *changes.Status != models.IssueStatusResolved {
+			return errors.New("resolution next_release requires status resolved")
+		}
+	default:
+		return errors.New("resolution must be one of: now, next_release")
+	}
+	if changes.Priority != nil && !models.IsValidIssuePriority(*changes.Priority) {
+		return errors.New("priority must be one of: low, medium, high, critical")
+	}
+	if changes.Assignee != nil {
+		assignee := strings.TrimSpace(*changes.Assignee)
+		if utf8.RuneCountInString(assignee) > 200 {
+			return errors.New("assignee must be at most 200 characters")
+		}
+		changes.Assignee = &assignee
+	}
+	changes.Actor = strings.TrimSpace(changes.Actor)
+	if utf8.RuneCountInString(changes.Actor) > 200 {
+		return errors.New("actor must be at most 200 characters")
+	}
+	return nil
+}
+
+func validateIssueSnooze(snooze *models.IssueSnooze) error {
+	if snooze.IsEmpty() {
+		return errors.New("snooze needs at least one of until, occurrences, occurrencesPerHour, users or servers")
+	}
+	if snooze.Until != nil && !snooze.Until.After(time.Now()) {
+		return errors.New("snooze until must be in the future")
+	}
+	if snooze.Occurrences < 0 || snooze.OccurrencesPerHour < 0 || snooze.Users < 0 || snooze.Servers < 0 {
+		return errors.New("snooze occurrences, occurrencesPerHour, users and servers must not be negative")
+	}
+	return nil
+}
+
+func validateIssueComment(request *SaveIssueCommentRequest) error {
+	bodyLen := utf8.RuneCountInString(strings.TrimSpace(request.Body))
+	if bodyLen < 1 || bodyLen > 10000 {
+		return errors.New("comment body must be between 1 and 10000 characters")
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(request.Author)) > 200 {
+		return errors.New("author must be at most 200 characters")
+	}
+	return nil
+}
+
+// issueUpdateAttempts is how many times updateIssues reads the issues again when they change concurrently
+const issueUpdateAttempts = 3
+
+// updateIssues applies the changes to the issues of the hashes, recording every field that changed in their
+// timelines, and returns the number of issues updated. Hashes without any occurrence in the project are skipped.
+// Issues changed concurrently are read again and the changes applied to their new state, failing with
+// repositories.ErrIssueConflict when they keep changing.
+func updateIssues(ctx context.Context, projectId uuid.UUID, hashes []string, changes IssueChanges) (int, error) {
+	hashes, err := repositories.ExceptionStackTraceRepository.FindExistingHashes(ctx, projectId, hashes)
+	if err != nil {
+		return 0, err
+	}
+
+	// issues resolved in the next release reopen on versions released after the latest one
+	var currentVersion string
+	if changes.Resolution == models.IssueResolutionNextRelease {
+		if currentVersion, err = repositories.AppVersionRepository.FindLatest(ctx, projectId); err != nil {
+			return 0, err
+		}
+	}
+
+	updated := 0
+	var activities []models.IssueActivity
+	for attempt := 0; attempt < issueUpdateAttempts && len(hashes) > 0; attempt++ {
+		stored, err := repositories.IssueRepository.FindByHashes(ctx, projectId, hashes)
+		if err != nil {
+			return 0, err
+		}
+
+		now := time.Now()
+		var issues []models.Issue
+		changedByHash := make(map[string][]models.IssueActivity)
+		for _, hash := range hashes {
+			if _, ok := changedByHash[hash]; ok {
+				continue
+			}
+
+			issue, ok := stored[hash]
+			if !ok {
+				issue = models.NewIssue(projectId, hash)
+			}
+
+			var changed []*models.IssueActivity
+			if changes.Snooze != nil {
+				changed = append(changed, issue.SetSnooze(*changes.Snooze, changes.Actor, now))
+			} else if changes.Resolution == models.IssueResolutionNextRelease {
+				changed = append(changed, issue.ResolveInNextRelease(currentVersion, changes.Actor, now))
+			} else if changes.Status != nil {
+				changed = append(changed, issue.SetStatus(*changes.Status, changes.Actor, now))
+			}
+			if changes.Assignee != nil {
+				changed = append(changed, issue.SetAssignee(*changes.Assignee, changes.Actor, now))
+			}
+			if changes.Priority != nil {
+				changed = append(changed, issue.SetPriority(*changes.Priority, changes.Actor, now))
+			}
+
+			changedByHash[hash] = []models.IssueActivity{}
+			for _, activity := range changed {
+				if activity != nil {
+					changedByHash[hash] = append(changedByHash[hash], *activity)
+				}
+			}
+			if len(changedByHash[hash]) > 0 {
+				issues = append(issues, issue)
+			}
+		}
+
+		conflicts, err := repositories.IssueRepository.Save(ctx, issues)
+		if err != nil {
+			return 0, err
+		}
+		hashes = nil
+		for _, issue := range issues {
+			if conflicts[models.IssueKey(projectId, issue.ExceptionHash)] {
+				hashes = append(hashes, issue.ExceptionHash)
+				continue
+			}
+			activities = append(activities, changedByHash[issue.ExceptionHash]...)
+			updated++
+		}
+	}
+
+	if err := repositories.IssueRepository.InsertActivities(ctx, activities); err != nil {
+		return 0, err
+	}
+	if len(hashes) > 0 {
+		return updated, repositories.ErrIssueConflict
+	}
+	return updated, nil
+}
+
+var IssueController = issueController{}
@@ -4,6 +4,7 @@ import (
 	"backend/app/models"
 	"backend/app/repositories"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +31,39 @@ type ServerMetricsResponse struct {
 	LastUpdated      time.Time                `json:"lastUpdated"`
 }
 
+// maxMetricQueryPoints caps the number of intervals a single metric query may return per series
+const maxMetricQueryPoints = 1500
+
+// MetricQueryRequest selects a metric, how to aggregate it, the servers and tag values to include and how to split it into series
+type MetricQueryRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	Name      string    `json:"name" binding:"required"`
+	FromDate  time.Time `json:"fromDate"`
+	ToDate    time.Time `json:"toDate"`
+	// Aggregation is avg, min, max, sum, p95 or rate, defaults to the usual aggregation for the metric's type
+	Aggregation string `json:"aggregation"`
+	// IntervalMinutes is the bucket size, derived from the time range when 0
+	IntervalMinutes int `json:"intervalMinutes" binding:"min=0,max=1440"`
+	models.MetricSeriesFilter
+}
+
+type MetricQueryResponse struct {
+	Name            string                `json:"name"`
+	Type            string                `json:"type"`
+	Aggregation     string                `json:"aggregation"`
+	IntervalMinutes int                   `json:"intervalMinutes"`
+	Series          []models.MetricSeries `json:"series"`
+}
+
+type MetricCatalogResponse struct {
+	Metrics []models.MetricCatalogEntry `json:"metrics"`
+}
+
+type MetricTagsResponse struct {
+	Name string                  `json:"name"`
+	Tags []models.MetricTagStats `json:"tags"`
+}
+
 // GetApplicationMetrics returns Go application metrics (Go Routines, Heap Objects, GC Cycles, GC Pause)
 // Always returns ALL servers' data - ignores server selector
 func (m metricsController) GetApplicationMetrics(c *gin.Context) {
@@ -76,28 +110,23 @@ func (m metricsController) GetApplicationMetrics(c *gin.Context) {
 	heapObjectsPrev, _ := repositories.MetricRecordRepository.GetAverageBetween(c, projectId, models.MetricNameHeapObjects, prevStart, prevEnd)
 	metrics = append(metrics, buildMetricWithServers("heap_objects", "Heap Objects", "", heapObjectsPerServer, heapObjectsPrev, "heap_objects"))
 
-	// 3. GC Cycles (Num GC)
-	numGCPerServer, err := repositories.MetricRecordRepository.GetAverageByIntervalPerServer(c, projectId, models.MetricNameNumGC, start, end, intervalMinutes, emptyServers)
+	// 3. GC Cycles (counter, shown as cycles per minute)
+	numGCPerServer, err := repositories.MetricRecordRepository.GetRateByIntervalPerServer(c, projectId, models.MetricNameNumGC, start, end, intervalMinutes, emptyServers)
 	if err != nil {
 		panic(err)
 	}
-	numGCPrev, _ := repositories.MetricRecordRepository.GetAverageBetween(c, projectId, models.MetricNameNumGC, prevStart, prevEnd)
-	metrics = append(metrics, buildMetricWithServers("num_gc", "GC Cycles", "", numGCPerServer, numGCPrev, "num_gc"))
+	scaleServerSeries(numGCPerServer, 60)
+	numGCPrev, _ := repositories.MetricRecordRepository.GetRateBetween(c, projectId, models.MetricNameNumGC, prevStart, prevEnd)
+	metrics = append(metrics, buildMetricWithServers("num_gc", "GC Cycles", "/min", numGCPerServer, numGCPrev*60, "num_gc"))
 
-	// 4. GC Pause Total (convert from nanoseconds to milliseconds)
-	gcPausePerServer, err := repositories.MetricRecordRepository.GetAverageByIntervalPerServer(c, projectId, models.MetricNameGCPauseTotal, start, end, intervalMinutes, emptyServers)
+	// 4. GC Pause (counter of total pause nanoseconds, shown as milliseconds paused per minute)
+	gcPausePerServer, err := repositories.MetricRecordRepository.GetRateByIntervalPerServer(c, projectId, models.MetricNameGCPauseTotal, start, end, intervalMinutes, emptyServers)
 	if err != nil {
 		panic(err)
 	}
-	// Convert nanoseconds to milliseconds for each server's data
-	for serverName, points := range gcPausePerServer {
-		for i := range points {
-			gcPausePerServer[serverName][i].Value = points[i].Value / 1_000_000
-		}
-	}
-	gcPausePrevRaw, _ := repositories.MetricRecordRepository.GetAverageBetween(c, projectId, models.MetricNameGCPauseTotal, prevStart, prevEnd)
-	gcPausePrev := gcPausePrevRaw / 1_000_000
-	metrics = append(metrics, buildMetricWithServers("gc_pause", "GC Pause", "ms", gcPausePerServer, gcPausePrev, "gc_pause"))
+	scaleServerSeries(gcPausePerServer, 60.0/1_000_000)
+	gcPausePrev, _ := repositories.MetricRecordRepository.GetRateBetween(c, projectId, models.MetricNameGCPauseTotal, prevStart, prevEnd)
+	metrics = append(metrics, buildMetricWithServers("gc_pause", "GC Pause", "ms/min", gcPausePerServer, gcPausePrev*60/1_000_000, "gc_pause"))
 
 	c.JSON(http.StatusOK, ApplicationMetricsResponse{
 		Metrics:          metrics,
@@ -232,6 +261,170 @@ func (m metricsController) GetServerMetrics(c *gin.Context) {
 	})
 }
 
+// QueryMetric returns any metric (built-in or custom) aggregated per interval as series split by server, by a tag
+// or not at all, optionally filtered by servers and tag values
+func (m metricsController) QueryMetric(c *gin.Context) {
+	var request MetricQueryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Aggregation != "" && !models.IsValidMetricAggregation(request.Aggregation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "aggregation must be one of: avg, min, max, sum, p95, rate"})
+		return
+	}
+	if !isValidMetricGroupBy(request.GroupBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "groupBy must be server, none or a valid tag key"})
+		return
+	}
+	for key := range request.Tags {
+		if !models.IsValidMetricTagKey(key) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag key: " + key})
+			return
+		}
+	}
+
+	end := request.ToDate
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := request.FromDate
+	if start.IsZero() {
+		start = end.Add(-24 * time.Hour)
+	}
+	if !start.Before(end) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fromDate must be before toDate"})
+		return
+	}
+
+	intervalMinutes := request.IntervalMinutes
+	if intervalMinutes == 0 {
+		intervalMinutes = calculateIntervalMinutes(end.Sub(start))
+	}
+	if end.Sub(start)/(time.Duration(intervalMinutes)*time.Minute) > maxMetricQueryPoints {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval is too small for the time range"})
+		return
+	}
+
+	series, metricType, aggregation, err := queryMetricSeries(c, request.ProjectId, request.Name, request.Aggregation, start, end, intervalMinutes, request.MetricSeriesFilter)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, MetricQueryResponse{
+		Name:            request.Name,
+		Type:            metricType,
+		Aggregation:     aggregation,
+		IntervalMinutes: intervalMinutes,
+		Series:          toMetricSeries(series),
+	})
+}
+
+// queryMetricSeries runs a metric query with the given aggregation (or the default one for the metric's type)
+// and returns the series along with the metric type and the aggregation used
+func queryMetricSeries(c *gin.Context, projectId uuid.UUID, name, aggregation string, start, end time.Time, intervalMinutes int, filter models.MetricSeriesFilter) (map[string][]models.TimeSeriesPoint, string, string, error) {
+	metricType, err := repositories.MetricRecordRepository.GetMetricType(c, projectId, name)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if aggregation == "" {
+		aggregation = models.DefaultMetricAggregation(metricType)
+	}
+
+	var series map[string][]models.TimeSeriesPoint
+	switch {
+	case aggregation == models.MetricAggregationRate:
+		series, err = repositories.MetricRecordRepository.GetRateByIntervalGrouped(c, projectId, name, start, end, intervalMinutes, filter)
+	case aggregation == models.MetricAggregationP95 && metricType == models.MetricTypeHistogram:
+		series, err = repositories.MetricRecordRepository.GetPercentileByIntervalGrouped(c, projectId, name, start, end, intervalMinutes, filter, 0.95)
+	default:
+		series, err = repositories.MetricRecordRepository.GetAggregateByIntervalGrouped(c, projectId, name, aggregation, start, end, intervalMinutes, filter)
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	return series, metricType, aggregation, nil
+}
+
+// GetMetricCatalog lists every metric name the project reported, defaulting to the last 30 days
+func (m metricsController) GetMetricCatalog(c *gin.Context) {
+	projectId, err := uuid.Parse(c.Query("projectId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	now := time.Now()
+	start, end := parseTimeRange(c, now)
+	if c.Query("fromDate") == "" {
+		start = end.Add(-30 * 24 * time.Hour)
+	}
+
+	catalog, err := repositories.MetricRecordRepository.GetCatalog(c, projectId, start, end)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, MetricCatalogResponse{
+		Metrics: catalog,
+	})
+}
+
+// GetMetricTags lists the tag keys recorded for a metric with their cardinality and sample values
+func (m metricsController) GetMetricTags(c *gin.Context) {
+	projectId, err := uuid.Parse(c.Query("projectId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	start, end := parseTimeRange(c, time.Now())
+
+	tags, err := repositories.MetricRecordRepository.GetTagStats(c, projectId, name, start, end)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, MetricTagsResponse{
+		Name: name,
+		Tags: tags,
+	})
+}
+
+func isValidMetricGroupBy(groupBy string) bool {
+	return groupBy == "" || groupBy == models.MetricGroupByServer || groupBy == models.MetricGroupByNone || models.IsValidMetricTagKey(groupBy)
+}
+
+// toMetricSeries converts query results into series sorted by key
+func toMetricSeries(data map[string][]models.TimeSeriesPoint) []models.MetricSeries {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	series := make([]models.MetricSeries, 0, len(keys))
+	for _, key := range keys {
+		points := data[key]
+		trend := make([]models.DashboardTrendPoint, len(points))
+		var lastValue float64
+		for i, p := range points {
+			trend[i] = models.DashboardTrendPoint{Timestamp: p.Timestamp, Value: p.Value}
+			lastValue = p.Value
+		}
+		series = append(series, models.MetricSeries{
+			Key:   key,
+			Value: lastValue,
+			Trend: trend,
+		})
+	}
+	return series
+}
+
 // parseTimeRange extracts fromDate and toDate from query params, defaults to last 24h
 func parseTimeRange(c *gin.Context, now time.Time) (start, end time.Time) {
 	// Parse fromDate parameter
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type notificationChannelController struct{}
+
+type NotificationChannelListRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type SaveNotificationChannelRequest struct {
+	ProjectId  uuid.UUID                        `json:"projectId"`
+	Name       string                           `json:"name" binding:"required"`
+	Type       string                           `json:"type" binding:"required"`
+	Config     models.NotificationChannelConfig `json:"config"`
+	EventTypes []string                         `json:"eventTypes"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+type NotificationDeliverySearchRequest struct {
+	ProjectId  uuid.UUID        `json:"projectId"`
+	ChannelId  *uuid.UUID       `json:"channelId"`
+	Status     string           `json:"status"`
+	Pagination PaginationParams `json:"pagination"`
+}
+
+func (n notificationChannelController) FindAll(c *gin.Context) {
+	var request NotificationChannelListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channels, err := repositories.NotificationChannelRepository.FindAll(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := range channels {
+		channels[i] = channels[i].Redacted()
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+func (n notificationChannelController) Create(c *gin.Context) {
+	var request SaveNotificationChannelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel := &models.NotificationChannel{
+		Id:        uuid.New(),
+		ProjectId: request.ProjectId,
+	}
+	if err := applyNotificationChannelRequest(channel, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.NotificationChannelRepository.Save(c, channel); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, channel.Redacted())
+}
+
+func (n notificationChannelController) FindById(c *gin.Context) {
+	var request NotificationChannelListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, ok := findNotificationChannel(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, channel.Redacted())
+}
+
+// Update replaces the channel's settings, an empty webhook secret and redacted header values keep the current ones
+func (n notificationChannelController) Update(c *gin.Context) {
+	var request SaveNotificationChannelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, ok := findNotificationChannel(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if request.Config.Secret == "" && request.Config.SecretSet {
+		request.Config.Secret = channel.Config.Secret
+	}
+	request.Config.RestoreRedactedHeaders(channel.Config.Headers)
+	if err := applyNotificationChannelRequest(channel, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.NotificationChannelRepository.Save(c, channel); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, channel.Redacted())
+}
+
+func (n notificationChannelController) Delete(c *gin.Context) {
+	var request NotificationChannelListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, ok := findNotificationChannel(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := repositories.NotificationChannelRepository.Delete(c, channel.ProjectId, channel.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// SendTest sends a test notification to the channel right away and returns the resulting delivery
+func (n notificationChannelController) SendTest(c *gin.Context) {
+	var request NotificationChannelListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, ok := findNotificationChannel(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	delivery, err := notifications.SendTest(c, channel)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// FindDeliveries returns the delivery log of the project's notifications
+func (n notificationChannelController) FindDeliveries(c *gin.Context) {
+	var request NotificationDeliverySearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, total, err := repositories.NotificationDeliveryRepository.FindByProject(c, request.ProjectId, request.ChannelId, request.Status, request.Pagination.Page, request.Pagination.PageSize)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.NotificationDelivery]{
+		Data: deliveries,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+// findNotificationChannel loads the channel of the :channelId param, writing the error response and returning
+// false when it is invalid or doesn't exist
+func findNotificationChannel(c *gin.Context, projectId uuid.UUID) (*models.NotificationChannel, bool) {
+	channelId, err := uuid.Parse(c.Param("channelId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return nil, false
+	}
+
+	channel, err := repositories.NotificationChannelRepository.FindById(c, projectId, channelId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotificationChannelNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return channel, true
+}
+
+// applyNotificationChannelRequest validates the request and copies it onto the channel
+func applyNotificationChannelRequest(channel *models.NotificationChannel, request *SaveNotificationChannelRequest) error {
+	nameLen := utf8.RuneCountInString(request.Name)
+	if nameLen < 1 || nameLen > 100 {
+		return errors.New("channel name must be between 1 and 100 characters")
+	}
+	if !models.IsValidNotificationChannelType(request.Type) {
+		return errors.New("type must be one of: webhook, slack, teams, email")
+	}
+	for _, eventType := range request.EventTypes {
+		if !models.NotificationEventTypes[eventType] {
+			return errors.New("unknown event type: " + eventType)
+		}
+	}
+
+	config := models.NotificationChannelConfig{
+		SubjectTemplate: request.Config.SubjectTemplate,
+		BodyTemplate:    request.Config.BodyTemplate,
+	}
+	switch request.Type {
+	case models.NotificationChannelEmail:
+		if len(request.Config.Recipients) == 0 {
+			return errors.New("email channels need at least one recipient")
+		}
+		for _, recipient := range request.Config.Recipients {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return errors.New("invalid recipient: " + recipient)
+			}
+		}
+		config.Recipients = request.Config.Recipients
+	default:
+		parsed, err := url.Parse(request.Config.Url)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New("url must be a valid http or https URL")
+		}
+		config.Url = request.Config.Url
+		if request.Type == models.NotificationChannelWebhook {
+			config.Secret = request.Config.Secret
+			config.Headers = request.Config.Headers
+		}
+	}
+	if err := notifications.ValidateTemplates(&config); err != nil {
+		return err
+	}
+
+	channel.Name = request.Name
+	channel.Type = request.Type
+	channel.Config = config
+	channel.EventTypes = request.EventTypes
+	channel.Enabled = request.Enabled == nil || *request.Enabled
+	return nil
+}
+
+var NotificationChannelController = notificationChannelController{}
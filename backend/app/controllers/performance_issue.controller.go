@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type performanceIssueController struct{}
+
+type PerformanceIssueSearchRequest struct {
+	ProjectId       uuid.UUID        `json:"projectId"`
+	FromDate        time.Time        `json:"fromDate"`
+	ToDate          time.Time        `json:"toDate"`
+	OrderBy         string           `json:"orderBy"`
+	Pagination      PaginationParams `json:"pagination"`
+	IssueType       string           `json:"issueType"`
+	IncludeArchived bool             `json:"includeArchived"`
+}
+
+type PerformanceIssueDetailResponse struct {
+	Group       *models.PerformanceIssueGroup `json:"group"`
+	Occurrences []models.PerformanceIssue     `json:"occurrences"`
+	Pagination  Pagination                    `json:"pagination"`
+}
+
+// FindGroupedPerformanceIssues lists detected performance issues (e.g. N+1 queries) grouped by issue hash.
+// Issues are archived/unarchived through the exception archive endpoints using their issue hash.
+func (p performanceIssueController) FindGroupedPerformanceIssues(c *gin.Context) {
+	var request PerformanceIssueSearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups, total, err := repositories.PerformanceIssueRepository.FindGrouped(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.IssueType, request.IncludeArchived)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.PerformanceIssueGroup]{
+		Data: groups,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+func (p performanceIssueController) FindByHash(c *gin.Context) {
+	issueHash := c.Param("hash")
+	if issueHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "issue hash is required"})
+		return
+	}
+
+	var request ExceptionDetailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		// Default pagination if not provided
+		request.Pagination = PaginationParams{Page: 1, PageSize: 20}
+	}
+
+	group, occurrences, total, err := repositories.PerformanceIssueRepository.FindByHash(c, request.ProjectId, issueHash, request.Pagination.Page, request.Pagination.PageSize)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPerformanceIssueNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Performance issue not found"})
+			return
+		}
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PerformanceIssueDetailResponse{
+		Group:       group,
+		Occurrences: occurrences,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+var PerformanceIssueController = performanceIssueController{}
@@ -32,6 +32,26 @@ type CreateProjectRequest struct {
 	Framework string `json:"framework" binding:"required"`
 }
 
+// UpdateProjectSettingsRequest sets the project's limits, 0 restores the default, and the number of days each data
+// type is kept for, 0 keeping it forever
+type UpdateProjectSettingsRequest struct {
+	MetricTagKeyLimit      uint16 `json:"metricTagKeyLimit" binding:"max=50"`
+	MetricTagValueLimit    uint32 `json:"metricTagValueLimit" binding:"max=100000"`
+	EndpointRetentionDays  uint16 `json:"endpointRetentionDays" binding:"max=3650"`
+	TaskRetentionDays      uint16 `json:"taskRetentionDays" binding:"max=3650"`
+	SegmentRetentionDays   uint16 `json:"segmentRetentionDays" binding:"max=3650"`
+	ExceptionRetentionDays uint16 `json:"exceptionRetentionDays" binding:"max=3650"`
+	MessageRetentionDays   uint16 `json:"messageRetentionDays" binding:"max=3650"`
+	MetricRetentionDays    uint16 `json:"metricRetentionDays" binding:"max=3650"`
+}
+
+// ProjectSettingsResponse returns the saved settings with the limits currently in effect
+type ProjectSettingsResponse struct {
+	models.ProjectSettings
+	EffectiveMetricTagKeyLimit   int `json:"effectiveMetricTagKeyLimit"`
+	EffectiveMetricTagValueLimit int `json:"effectiveMetricTagValueLimit"`
+}
+
 // ListProjects returns all projects without tokens
 func (p projectController) ListProjects(c *gin.Context) {
 	projects := cache.ProjectCache.GetAll()
@@ -100,4 +120,73 @@ func (p projectController) GetProject(c *gin.Context) {
 	c.JSON(http.StatusOK, project.ToWithToken())
 }
 
+// GetSettings returns the project's settings (metric tag cardinality limits and data retentions)
+func (p projectController) GetSettings(c *gin.Context) {
+	projectId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	if cache.ProjectCache.GetById(projectId) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	settings, err := repositories.ProjectSettingsRepository.FindByProjectId(c, projectId)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, toProjectSettingsResponse(settings))
+}
+
+// UpdateSettings saves the project's settings, new limits apply to metrics ingested from then on and new
+// retentions on the next run of the retention enforcer
+func (p projectController) UpdateSettings(c *gin.Context) {
+	projectId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var request UpdateProjectSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if cache.ProjectCache.GetById(projectId) == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	settings := &models.ProjectSettings{
+		ProjectId:              projectId,
+		MetricTagKeyLimit:      request.MetricTagKeyLimit,
+		MetricTagValueLimit:    request.MetricTagValueLimit,
+		EndpointRetentionDays:  request.EndpointRetentionDays,
+		TaskRetentionDays:      request.TaskRetentionDays,
+		SegmentRetentionDays:   request.SegmentRetentionDays,
+		ExceptionRetentionDays: request.ExceptionRetentionDays,
+		MessageRetentionDays:   request.MessageRetentionDays,
+		MetricRetentionDays:    request.MetricRetentionDays,
+	}
+	if err := repositories.ProjectSettingsRepository.Save(c, settings); err != nil {
+		panic(err)
+	}
+
+	cache.MetricTagCache.Invalidate(projectId)
+
+	c.JSON(http.StatusOK, toProjectSettingsResponse(settings))
+}
+
+func toProjectSettingsResponse(settings *models.ProjectSettings) ProjectSettingsResponse {
+	return ProjectSettingsResponse{
+		ProjectSettings:              *settings,
+		EffectiveMetricTagKeyLimit:   settings.TagKeyLimit(),
+		EffectiveMetricTagValueLimit: settings.TagValueLimit(),
+	}
+}
+
 var ProjectController = projectController{}
@@ -3,6 +3,8 @@ package controllers
 import (
 	"backend/app/controllers/clientcontrollers"
 	"backend/app/middleware"
+	"backend/app/models"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,22 +26,73 @@ type Pagination struct {
 	TotalPages int64 `json:"totalPages"`
 }
 
+// CursorPaginatedResponse is a page of a listing paginated with a cursor instead of page numbers
+type CursorPaginatedResponse[T any] struct {
+	Data       []T              `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+type CursorPagination struct {
+	PageSize int `json:"pageSize"`
+	// NextCursor is passed as the cursor of the next request, empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+	// Total is only counted when asked for with includeTotal
+	Total *int64 `json:"total,omitempty"`
+}
+
+func newCursorPagination(pageSize int, next *models.PageCursor) CursorPagination {
+	pagination := CursorPagination{PageSize: pageSize, HasMore: next != nil}
+	if next != nil {
+		pagination.NextCursor = next.Encode()
+	}
+	return pagination
+}
+
+// decodePageCursor decodes the cursor of a request, the empty cursor asking for the first page
+func decodePageCursor(c *gin.Context, token string) (*models.PageCursor, bool) {
+	if token == "" {
+		return nil, true
+	}
+	cursor, err := models.DecodePageCursor(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return cursor, true
+}
+
 func RegisterControllers(router *gin.RouterGroup) {
 	router.POST("/report", middleware.UseClientAuth, middleware.UseGzip, clientcontrollers.ClientController.Report)
+	router.POST("/heartbeat/:taskName", middleware.UseClientAuth, clientcontrollers.CheckInController.Heartbeat)
+	router.POST("/check-in", middleware.UseClientAuth, clientcontrollers.CheckInController.CheckIn)
 
 	// Project management
 	router.GET("/projects", middleware.UseAppAuth, ProjectController.ListProjects)
 	router.POST("/projects", middleware.UseAppAuth, ProjectController.CreateProject)
 	router.GET("/projects/:id", middleware.UseAppAuth, ProjectController.GetProject)
+	router.GET("/projects/:id/settings", middleware.UseAppAuth, ProjectController.GetSettings)
+	router.POST("/projects/:id/settings", middleware.UseAppAuth, ProjectController.UpdateSettings)
 
 	router.POST("/stats", middleware.UseAppAuth, MetricRecordController.FindHomepageStats)
 	router.GET("/dashboard", middleware.UseAppAuth, DashboardController.GetDashboard)
 	router.GET("/dashboard/overview", middleware.UseAppAuth, DashboardController.GetDashboardOverview)
 
+	// Custom dashboards
+	router.POST("/custom-dashboards", middleware.UseAppAuth, CustomDashboardController.FindAll)
+	router.POST("/custom-dashboards/create", middleware.UseAppAuth, CustomDashboardController.Create)
+	router.POST("/custom-dashboards/:dashboardId", middleware.UseAppAuth, CustomDashboardController.FindById)
+	router.POST("/custom-dashboards/:dashboardId/update", middleware.UseAppAuth, CustomDashboardController.Update)
+	router.POST("/custom-dashboards/:dashboardId/delete", middleware.UseAppAuth, CustomDashboardController.Delete)
+	router.POST("/custom-dashboards/:dashboardId/render", middleware.UseAppAuth, CustomDashboardController.Render)
+
 	// Metrics endpoints (split by category)
 	router.GET("/metrics/application", middleware.UseAppAuth, MetricsController.GetApplicationMetrics)
 	router.GET("/metrics/stats", middleware.UseAppAuth, MetricsController.GetStatsMetrics)
 	router.GET("/metrics/server", middleware.UseAppAuth, MetricsController.GetServerMetrics)
+	router.GET("/metrics/catalog", middleware.UseAppAuth, MetricsController.GetMetricCatalog)
+	router.POST("/metrics/query", middleware.UseAppAuth, MetricsController.QueryMetric)
+	router.GET("/metrics/tags", middleware.UseAppAuth, MetricsController.GetMetricTags)
 
 	// Endpoints
 	router.POST("/endpoints", middleware.UseAppAuth, EndpointController.FindAllEndpoints)
@@ -52,11 +105,84 @@ func RegisterControllers(router *gin.RouterGroup) {
 	router.POST("/tasks/grouped", middleware.UseAppAuth, TaskController.FindGroupedByTaskName)
 	router.POST("/tasks/task", middleware.UseAppAuth, TaskController.FindByTaskName)
 	router.POST("/tasks/:taskId", middleware.UseAppAuth, TaskDetailController.GetTaskDetail)
+
+	// Task monitors
+	router.POST("/task-monitors", middleware.UseAppAuth, TaskMonitorController.FindAll)
+	router.POST("/task-monitors/create", middleware.UseAppAuth, TaskMonitorController.Create)
+	router.POST("/task-monitors/events", middleware.UseAppAuth, TaskMonitorController.FindEvents)
+	router.POST("/task-monitors/:monitorId", middleware.UseAppAuth, TaskMonitorController.FindById)
+	router.POST("/task-monitors/:monitorId/update", middleware.UseAppAuth, TaskMonitorController.Update)
+	router.POST("/task-monitors/:monitorId/delete", middleware.UseAppAuth, TaskMonitorController.Delete)
+	router.POST("/task-monitors/:monitorId/events", middleware.UseAppAuth, TaskMonitorController.FindEvents)
+
+	// Segments
+	router.POST("/segments/grouped", middleware.UseAppAuth, SegmentController.FindGroupedByName)
+	router.POST("/segments/segment", middleware.UseAppAuth, SegmentController.FindGroupedByTransaction)
+
+	// Distributed traces
+	router.POST("/traces/:traceId", middleware.UseAppAuth, TraceController.GetTrace)
+
 	router.POST("/exception-stack-traces", middleware.UseAppAuth, ExceptionStackTraceController.FindGrouppedExceptionStackTraces)
 	router.POST("/exception-stack-traces/archive", middleware.UseAppAuth, ExceptionStackTraceController.ArchiveExceptions)
 	router.POST("/exception-stack-traces/unarchive", middleware.UseAppAuth, ExceptionStackTraceController.UnarchiveExceptions)
 	router.POST("/exception-stack-traces/by-id/:exceptionId", middleware.UseAppAuth, ExceptionStackTraceController.FindById)
 	router.POST("/exception-stack-traces/:hash", middleware.UseAppAuth, ExceptionStackTraceController.FindByHash)
+	router.POST("/exception-stack-traces/:hash/facets", middleware.UseAppAuth, ExceptionStackTraceController.FindFacets)
+
+	// Issue workflow
+	router.POST("/issues/update", middleware.UseAppAuth, IssueController.UpdateMany)
+	router.POST("/issues/:hash", middleware.UseAppAuth, IssueController.FindByHash)
+	router.POST("/issues/:hash/update", middleware.UseAppAuth, IssueController.Update)
+	router.POST("/issues/:hash/activity", middleware.UseAppAuth, IssueController.FindActivity)
+	router.POST("/issues/:hash/comments", middleware.UseAppAuth, IssueController.FindComments)
+	router.POST("/issues/:hash/comments/create", middleware.UseAppAuth, IssueController.CreateComment)
+	router.POST("/issues/:hash/comments/:commentId/update", middleware.UseAppAuth, IssueController.UpdateComment)
+	router.POST("/issues/:hash/comments/:commentId/delete", middleware.UseAppAuth, IssueController.DeleteComment)
+
+	// Performance issues (N+1 and repeated operations)
+	router.POST("/performance-issues", middleware.UseAppAuth, PerformanceIssueController.FindGroupedPerformanceIssues)
+	router.POST("/performance-issues/:hash", middleware.UseAppAuth, PerformanceIssueController.FindByHash)
+
+	// Alert rules
+	router.POST("/alert-rules", middleware.UseAppAuth, AlertRuleController.FindAll)
+	router.POST("/alert-rules/create", middleware.UseAppAuth, AlertRuleController.Create)
+	router.POST("/alert-rules/history", middleware.UseAppAuth, AlertRuleController.FindHistory)
+	router.POST("/alert-rules/:ruleId", middleware.UseAppAuth, AlertRuleController.FindById)
+	router.POST("/alert-rules/:ruleId/update", middleware.UseAppAuth, AlertRuleController.Update)
+	router.POST("/alert-rules/:ruleId/delete", middleware.UseAppAuth, AlertRuleController.Delete)
+	router.POST("/alert-rules/:ruleId/history", middleware.UseAppAuth, AlertRuleController.FindHistory)
+
+	// SLOs
+	router.POST("/slos", middleware.UseAppAuth, SloController.FindAll)
+	router.POST("/slos/create", middleware.UseAppAuth, SloController.Create)
+	router.POST("/slos/:sloId", middleware.UseAppAuth, SloController.FindById)
+	router.POST("/slos/:sloId/update", middleware.UseAppAuth, SloController.Update)
+	router.POST("/slos/:sloId/delete", middleware.UseAppAuth, SloController.Delete)
+
+	// Uptime checks
+	router.POST("/uptime-checks", middleware.UseAppAuth, UptimeCheckController.FindAll)
+	router.POST("/uptime-checks/create", middleware.UseAppAuth, UptimeCheckController.Create)
+	router.POST("/uptime-checks/:checkId", middleware.UseAppAuth, UptimeCheckController.FindById)
+	router.POST("/uptime-checks/:checkId/update", middleware.UseAppAuth, UptimeCheckController.Update)
+	router.POST("/uptime-checks/:checkId/delete", middleware.UseAppAuth, UptimeCheckController.Delete)
+	router.POST("/uptime-checks/:checkId/run", middleware.UseAppAuth, UptimeCheckController.Run)
+	router.POST("/uptime-checks/:checkId/results", middleware.UseAppAuth, UptimeCheckController.FindResults)
+
+	// Anomaly detection
+	router.POST("/anomalies", middleware.UseAppAuth, AnomalyController.FindAnomalies)
+	router.POST("/anomalies/baseline", middleware.UseAppAuth, AnomalyController.GetBaseline)
+
+	// Notification channels
+	router.POST("/notification-channels", middleware.UseAppAuth, NotificationChannelController.FindAll)
+	router.POST("/notification-channels/create", middleware.UseAppAuth, NotificationChannelController.Create)
+	router.POST("/notification-channels/:channelId", middleware.UseAppAuth, NotificationChannelController.FindById)
+	router.POST("/notification-channels/:channelId/update", middleware.UseAppAuth, NotificationChannelController.Update)
+	router.POST("/notification-channels/:channelId/delete", middleware.UseAppAuth, NotificationChannelController.Delete)
+	router.POST("/notification-channels/:channelId/test", middleware.UseAppAuth, NotificationChannelController.SendTest)
+	router.POST("/notification-deliveries", middleware.UseAppAuth, NotificationChannelController.FindDeliveries)
+
+	// Storage
+	router.GET("/storage", middleware.UseAppAuth, StorageController.GetStorage)
 
 	// Auth
 	router.POST("/login", AuthController.Login)
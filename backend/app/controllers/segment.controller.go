@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type segmentController struct{}
+
+type SegmentSearchRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	FromDate  time.Time `json:"fromDate"`
+	ToDate    time.Time `json:"toDate"`
+	// TransactionType limits the search to "endpoint" or "task" transactions, empty means both
+	TransactionType string `json:"transactionType" binding:"omitempty,oneof=endpoint task"`
+	// TransactionName limits the search to segments of a single endpoint/task, empty means project-wide
+	TransactionName string           `json:"transactionName"`
+	OrderBy         string           `json:"orderBy"`
+	SortDirection   string           `json:"sortDirection"`
+	Pagination      PaginationParams `json:"pagination"`
+}
+
+// FindGroupedByName returns count, total time, percentiles and share of transaction time for each segment name
+func (s segmentController) FindGroupedByName(c *gin.Context) {
+	var request SegmentSearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, total, err := repositories.SegmentRepository.FindGroupedByName(c, request.ProjectId, request.FromDate, request.ToDate, request.TransactionType, request.TransactionName, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.SegmentStats]{
+		Data: stats,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+// FindGroupedByTransaction returns the endpoints/tasks running a segment, with the segment's statistics in each
+func (s segmentController) FindGroupedByTransaction(c *gin.Context) {
+	rawName := c.Query("name")
+	if rawName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	// URL decode the segment name
+	name, err := url.PathUnescape(rawName)
+	if err != nil {
+		name = rawName // fallback to raw value if decoding fails
+	}
+
+	var request SegmentSearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, total, err := repositories.SegmentRepository.FindGroupedByTransaction(c, request.ProjectId, name, request.FromDate, request.ToDate, request.TransactionType, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.SegmentTransactionStats]{
+		Data: stats,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+var SegmentController = segmentController{}
@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxSloEndpointPatterns caps the endpoint patterns of an SLO, each one is a LIKE on every request
+const maxSloEndpointPatterns = 20
+
+type sloController struct{}
+
+type SloListRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type SaveSloRequest struct {
+	ProjectId        uuid.UUID `json:"projectId"`
+	Name             string    `json:"name" binding:"required"`
+	Description      string    `json:"description"`
+	EndpointPatterns []string  `json:"endpointPatterns"`
+	// MaxGoodStatusCode defaults to 499, only server errors are bad
+	MaxGoodStatusCode *int    `json:"maxGoodStatusCode" binding:"omitempty,min=100,max=599"`
+	MaxDurationMs     int     `json:"maxDurationMs" binding:"min=0,max=3600000"`
+	Target            float64 `json:"target" binding:"required,gt=0,lt=100"`
+	// WindowDays defaults to 30
+	WindowDays int `json:"windowDays" binding:"min=0,max=90"`
+	// BurnRateAlerts creates a fast (1h) and a slow (6h) burn rate alert rule along with the SLO, only on create
+	BurnRateAlerts bool `json:"burnRateAlerts"`
+}
+
+// SloResponse is an SLO with its status over its rolling window
+type SloResponse struct {
+	models.Slo
+	Status models.SloStatus `json:"status"`
+}
+
+func (s sloController) FindAll(c *gin.Context) {
+	var request SloListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slos, err := repositories.SloRepository.FindAll(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	response := make([]SloResponse, len(slos))
+	for i := range slos {
+		status, err := sloStatus(c, &slos[i])
+		if err != nil {
+			panic(err)
+		}
+		response[i] = SloResponse{Slo: slos[i], Status: status}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s sloController) Create(c *gin.Context) {
+	var request SaveSloRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slo := &models.Slo{
+		Id:        uuid.New(),
+		ProjectId: request.ProjectId,
+	}
+	if err := applySloRequest(slo, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.SloRepository.Save(c, slo); err != nil {
+		panic(err)
+	}
+
+	if request.BurnRateAlerts {
+		for _, rule := range sloBurnRateAlertRules(slo) {
+			if err := repositories.AlertRuleRepository.Save(c, &rule); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	status, err := sloStatus(c, slo)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, SloResponse{Slo: *slo, Status: status})
+}
+
+func (s sloController) FindById(c *gin.Context) {
+	var request SloListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slo, ok := findSlo(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	status, err := sloStatus(c, slo)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, SloResponse{Slo: *slo, Status: status})
+}
+
+// Update replaces the SLO's definition, the thresholds of its burn rate alert rules are left as they are
+func (s sloController) Update(c *gin.Context) {
+	var request SaveSloRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slo, ok := findSlo(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := applySloRequest(slo, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.SloRepository.Save(c, slo); err != nil {
+		panic(err)
+	}
+
+	status, err := sloStatus(c, slo)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, SloResponse{Slo: *slo, Status: status})
+}
+
+// Delete removes the SLO along with the alert rules on its burn rate
+func (s sloController) Delete(c *gin.Context) {
+	var request SloListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slo, ok := findSlo(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	rules, err := repositories.AlertRuleRepository.FindAll(c, slo.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+	for _, rule := range rules {
+		if rule.Type == models.AlertRuleTypeSloBurnRate && rule.SloId == slo.Id {
+			if err := repositories.AlertRuleRepository.Delete(c, rule.ProjectId, rule.Id); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if err := repositories.SloRepository.Delete(c, slo.ProjectId, slo.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// findSlo loads the SLO of the :sloId param, writing the error response and returning false when it is invalid
+// or doesn't exist
+func findSlo(c *gin.Context, projectId uuid.UUID) (*models.Slo, bool) {
+	sloId, err := uuid.Parse(c.Param("sloId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SLO ID"})
+		return nil, false
+	}
+
+	slo, err := repositories.SloRepository.FindById(c, projectId, sloId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSloNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "SLO not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return slo, true
+}
+
+// sloStatus counts the SLO's requests over its window and every burn rate window, up to now
+func sloStatus(c *gin.Context, slo *models.Slo) (models.SloStatus, error) {
+	windows := []time.Duration{slo.Window()}
+	for _, minutes := range models.SloBurnRateWindows {
+		windows = append(windows, time.Duration(minutes)*time.Minute)
+	}
+
+	counts, err := repositories.SloRepository.CountEvents(c, slo, time.Now(), windows)
+	if err != nil {
+		return models.SloStatus{}, err
+	}
+	return slo.Status(counts[0], counts[1:]), nil
+}
+
+// sloBurnRateAlertRules returns the default multi-window burn rate rules of an SLO: a fast one firing when 2% of
+// the budget is spent within an hour and a slow one when 5% is spent within 6 hours
+func sloBurnRateAlertRules(slo *models.Slo) []models.AlertRule {
+	rule := func(name string, budgetShare float64, windowMinutes int) models.AlertRule {
+		return models.AlertRule{
+			Id:            uuid.New(),
+			ProjectId:     slo.ProjectId,
+			Name:          slo.Name + " " + name,
+			Type:          models.AlertRuleTypeSloBurnRate,
+			SloId:         slo.Id,
+			Comparison:    models.AlertComparisonGreaterThanOrEqual,
+			Threshold:     math.Round(slo.BurnRateThreshold(budgetShare, windowMinutes)*100) / 100,
+			WindowMinutes: windowMinutes,
+			Enabled:       true,
+		}
+	}
+	return []models.AlertRule{
+		rule("fast burn", models.SloFastBurnBudget, 60),
+		rule("slow burn", models.SloSlowBurnBudget, 360),
+	}
+}
+
+// applySloRequest validates the request and copies it onto the SLO
+func applySloRequest(slo *models.Slo, request *SaveSloRequest) error {
+	nameLen := utf8.RuneCountInString(request.Name)
+	if nameLen < 1 || nameLen > 100 {
+		return errors.New("SLO name must be between 1 and 100 characters")
+	}
+	if len(request.EndpointPatterns) > maxSloEndpointPatterns {
+		return errors.New("an SLO can have at most 20 endpoint patterns")
+	}
+	patterns := make([]string, 0, len(request.EndpointPatterns))
+	for _, pattern := range request.EndpointPatterns {
+		if pattern == "" {
+			return errors.New("endpoint patterns must not be empty")
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	slo.Name = request.Name
+	slo.Description = request.Description
+	slo.EndpointPatterns = patterns
+	slo.MaxGoodStatusCode = 499
+	if request.MaxGoodStatusCode != nil {
+		slo.MaxGoodStatusCode = *request.MaxGoodStatusCode
+	}
+	slo.MaxDurationMs = request.MaxDurationMs
+	slo.Target = request.Target
+	slo.WindowDays = request.WindowDays
+	if slo.WindowDays == 0 {
+		slo.WindowDays = 30
+	}
+	return nil
+}
+
+var SloController = sloController{}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/repositories"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+type storageController struct{}
+
+// StorageResponse is the disk usage of the database per table and per project
+type StorageResponse struct {
+	Tables   []models.TableStorage   `json:"tables"`
+	Projects []models.ProjectStorage `json:"projects"`
+}
+
+// GetStorage returns the disk usage of every table and the estimated usage of every project, largest first
+func (s storageController) GetStorage(c *gin.Context) {
+	tables, err := repositories.StorageRepository.FindTableStorage(c)
+	if err != nil {
+		panic(err)
+	}
+	projectRows, err := repositories.StorageRepository.FindProjectRows(c)
+	if err != nil {
+		panic(err)
+	}
+
+	projects := []models.ProjectStorage{}
+	for _, project := range cache.ProjectCache.GetAll() {
+		storage := models.ProjectStorage{ProjectId: project.Id, Name: project.Name, Tables: []models.ProjectTableStorage{}}
+		for _, table := range tables {
+			rows := projectRows[table.Table][project.Id]
+			if rows == 0 {
+				continue
+			}
+			tableStorage := models.ProjectTableStorage{Table: table.Table, Rows: rows}
+			if table.Rows > 0 {
+				tableStorage.EstimatedBytesOnDisk = uint64(float64(table.BytesOnDisk) * float64(rows) / float64(table.Rows))
+			}
+			storage.Rows += tableStorage.Rows
+			storage.EstimatedBytesOnDisk += tableStorage.EstimatedBytesOnDisk
+			storage.Tables = append(storage.Tables, tableStorage)
+		}
+		projects = append(projects, storage)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].EstimatedBytesOnDisk > projects[j].EstimatedBytesOnDisk
+	})
+
+	c.JSON(http.StatusOK, StorageResponse{Tables: tables, Projects: projects})
+}
+
+var StorageController = storageController{}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"backend/app/models"
 	"backend/app/repositories"
+	"errors"
 	"net/http"
 	"net/url"
 	"time"
@@ -20,6 +21,15 @@ type TaskSearchRequest struct {
 	OrderBy       string           `json:"orderBy"`
 	SortDirection string           `json:"sortDirection"`
 	Pagination    PaginationParams `json:"pagination"`
+	// ScopeFilters limit the results to the records whose scope matches every filter
+	ScopeFilters []models.ScopeFilter `json:"scopeFilters"`
+	// Filter is a filter expression such as duration > 1s AND scope.tenant = "acme"
+	Filter string `json:"filter"`
+	// Cursor switches the listing to keyset pagination, "" asking for the first page and the nextCursor of a page
+	// for the one after it. Pagination.Page is ignored.
+	Cursor *string `json:"cursor"`
+	// IncludeTotal counts the exact total of a cursor listing, which reads every matching row
+	IncludeTotal bool `json:"includeTotal"`
 }
 
 type TaskInstancesRequest struct {
@@ -29,6 +39,15 @@ type TaskInstancesRequest struct {
 	OrderBy       string           `json:"orderBy"`
 	SortDirection string           `json:"sortDirection"`
 	Pagination    PaginationParams `json:"pagination"`
+	// ScopeFilters limit the results to the records whose scope matches every filter
+	ScopeFilters []models.ScopeFilter `json:"scopeFilters"`
+	// Filter is a filter expression such as duration > 1s AND scope.tenant = "acme"
+	Filter string `json:"filter"`
+	// Cursor switches the listing to keyset pagination, "" asking for the first page and the nextCursor of a page
+	// for the one after it. Pagination.Page is ignored.
+	Cursor *string `json:"cursor"`
+	// IncludeTotal counts the exact total of a cursor listing, which reads every matching row
+	IncludeTotal bool `json:"includeTotal"`
 }
 
 type TaskInstancesResponse struct {
@@ -37,14 +56,37 @@ type TaskInstancesResponse struct {
 	Pagination Pagination              `json:"pagination"`
 }
 
+type TaskInstancesCursorResponse struct {
+	Data       []models.Task           `json:"data"`
+	Stats      *models.TaskDetailStats `json:"stats"`
+	Pagination CursorPagination        `json:"pagination"`
+}
+
 func (e taskController) FindAllTasks(c *gin.Context) {
-	var request TaskSearchRequest
+	request := TaskSearchRequest{Pagination: PaginationParams{Page: 1}}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.TaskFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	tasks, total, err := repositories.TaskRepository.FindAll(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy)
+	if request.Cursor != nil {
+		tasks, pagination, ok := findTasksPage(c, request, "", expression)
+		if ok {
+			c.JSON(http.StatusOK, CursorPaginatedResponse[models.Task]{Data: tasks, Pagination: pagination})
+		}
+		return
+	}
+
+	tasks, total, err := repositories.TaskRepository.FindAll(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.ScopeFilters, expression)
 	if err != nil {
 		panic(err)
 	}
@@ -66,8 +108,17 @@ func (e taskController) FindGroupedByTaskName(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.TaskFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	stats, total, err := repositories.TaskRepository.FindGroupedByTaskName(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection)
+	stats, total, err := repositories.TaskRepository.FindGroupedByTaskName(c, request.ProjectId, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection, request.ScopeFilters, expression)
 	if err != nil {
 		panic(err)
 	}
@@ -96,19 +147,41 @@ func (e taskController) FindByTaskName(c *gin.Context) {
 		taskName = rawTaskName // fallback to raw value if decoding fails
 	}
 
-	var request TaskInstancesRequest
+	request := TaskInstancesRequest{Pagination: PaginationParams{Page: 1}}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := models.ValidateScopeFilters(request.ScopeFilters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expression, err := models.ParseFilterExpression(request.Filter, models.TaskFilterFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Cursor != nil {
+		tasks, pagination, ok := findTasksPage(c, TaskSearchRequest(request), taskName, expression)
+		if !ok {
+			return
+		}
+		stats, err := repositories.TaskRepository.GetTaskStats(c, request.ProjectId, taskName, request.FromDate, request.ToDate, request.ScopeFilters, expression)
+		if err != nil {
+			stats = nil
+		}
+		c.JSON(http.StatusOK, TaskInstancesCursorResponse{Data: tasks, Stats: stats, Pagination: pagination})
+		return
+	}
 
-	tasks, total, err := repositories.TaskRepository.FindByTaskName(c, request.ProjectId, taskName, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection)
+	tasks, total, err := repositories.TaskRepository.FindByTaskName(c, request.ProjectId, taskName, request.FromDate, request.ToDate, request.Pagination.Page, request.Pagination.PageSize, request.OrderBy, request.SortDirection, request.ScopeFilters, expression)
 	if err != nil {
 		panic(err)
 	}
 
 	// Get aggregate stats for this task
-	stats, err := repositories.TaskRepository.GetTaskStats(c, request.ProjectId, taskName, request.FromDate, request.ToDate)
+	stats, err := repositories.TaskRepository.GetTaskStats(c, request.ProjectId, taskName, request.FromDate, request.ToDate, request.ScopeFilters, expression)
 	if err != nil {
 		// Don't fail the request if stats fail, just return nil stats
 		stats = nil
@@ -126,4 +199,32 @@ func (e taskController) FindByTaskName(c *gin.Context) {
 	})
 }
 
+// findTasksPage lists the task runs of a project, or of one task when taskName isn't empty, from the cursor of the
+// request. Nothing is counted unless the total is asked for.
+func findTasksPage(c *gin.Context, request TaskSearchRequest, taskName string, expression *models.FilterNode) ([]models.Task, CursorPagination, bool) {
+	cursor, ok := decodePageCursor(c, *request.Cursor)
+	if !ok {
+		return nil, CursorPagination{}, false
+	}
+
+	tasks, next, err := repositories.TaskRepository.FindPage(c, request.ProjectId, taskName, request.FromDate, request.ToDate, request.Pagination.PageSize, request.OrderBy, request.SortDirection, cursor, request.ScopeFilters, expression)
+	if errors.Is(err, models.ErrInvalidPageCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cursor doesn't match the sort of the listing"})
+		return nil, CursorPagination{}, false
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	pagination := newCursorPagination(request.Pagination.PageSize, next)
+	if request.IncludeTotal {
+		total, err := repositories.TaskRepository.Count(c, request.ProjectId, taskName, request.FromDate, request.ToDate, request.ScopeFilters, expression)
+		if err != nil {
+			panic(err)
+		}
+		pagination.Total = &total
+	}
+	return tasks, pagination, true
+}
+
 var TaskController = taskController{}
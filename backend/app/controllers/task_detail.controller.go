@@ -30,11 +30,13 @@ type TaskMessageInfo struct {
 }
 
 type TaskDetailResponse struct {
-	Task        *models.Task       `json:"task"`
-	Segments    []models.Segment   `json:"segments"`
-	HasSegments bool               `json:"hasSegments"`
-	Exception   *TaskExceptionInfo `json:"exception,omitempty"`
-	Messages    []TaskMessageInfo  `json:"messages"`
+	Task              *models.Task              `json:"task"`
+	Segments          []models.Segment          `json:"segments"`
+	SegmentTree       []*models.SegmentNode     `json:"segmentTree"`
+	HasSegments       bool                      `json:"hasSegments"`
+	Exception         *TaskExceptionInfo        `json:"exception,omitempty"`
+	Messages          []TaskMessageInfo         `json:"messages"`
+	PerformanceIssues []models.PerformanceIssue `json:"performanceIssues"`
 }
 
 func (c taskDetailController) GetTaskDetail(ctx *gin.Context) {
@@ -57,7 +59,7 @@ func (c taskDetailController) GetTaskDetail(ctx *gin.Context) {
 		return
 	}
 
-	// Get segments (flat list ordered by start_time)
+	// Get segments (flat list ordered by start_time) and nest them by parent_id
 	segments, err := repositories.SegmentRepository.FindByTransactionId(ctx, request.ProjectId, taskId)
 	if err != nil {
 		panic(err)
@@ -96,12 +98,23 @@ func (c taskDetailController) GetTaskDetail(ctx *gin.Context) {
 		messages = []TaskMessageInfo{}
 	}
 
+	// Get performance issues (N+1 queries) detected in this transaction
+	performanceIssues, err := repositories.PerformanceIssueRepository.FindByTransactionId(ctx, request.ProjectId, taskId)
+	if err != nil {
+		panic(err)
+	}
+	if performanceIssues == nil {
+		performanceIssues = []models.PerformanceIssue{}
+	}
+
 	ctx.JSON(http.StatusOK, TaskDetailResponse{
-		Task:        task,
-		Segments:    segments,
-		HasSegments: len(segments) > 0,
-		Exception:   exceptionInfo,
-		Messages:    messages,
+		Task:              task,
+		Segments:          segments,
+		SegmentTree:       models.BuildSegmentTree(segments),
+		HasSegments:       len(segments) > 0,
+		Exception:         exceptionInfo,
+		Messages:          messages,
+		PerformanceIssues: performanceIssues,
 	})
 }
 
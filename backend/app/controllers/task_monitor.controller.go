@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"backend/app/models"
+	"backend/app/repositories"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type taskMonitorController struct{}
+
+type TaskMonitorListRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type SaveTaskMonitorRequest struct {
+	ProjectId    uuid.UUID `json:"projectId"`
+	TaskName     string    `json:"taskName" binding:"required"`
+	ScheduleType string    `json:"scheduleType" binding:"required"`
+	// CronExpression and Timezone (defaults to UTC) are used by cron schedules
+	CronExpression string `json:"cronExpression"`
+	Timezone       string `json:"timezone"`
+	// IntervalSeconds is used by interval schedules
+	IntervalSeconds int `json:"intervalSeconds" binding:"omitempty,min=60,max=2592000"`
+	GraceSeconds    int `json:"graceSeconds" binding:"min=0,max=86400"`
+	MaxDurationMs   int `json:"maxDurationMs" binding:"min=0"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+type TaskMonitorEventsRequest struct {
+	ProjectId  uuid.UUID        `json:"projectId"`
+	Pagination PaginationParams `json:"pagination"`
+}
+
+// TaskMonitorResponse is a monitor with the outcome of its last evaluation and when its next run is due
+type TaskMonitorResponse struct {
+	models.TaskMonitor
+	State     *models.TaskMonitorState `json:"state"`
+	NextRunAt *time.Time               `json:"nextRunAt"`
+}
+
+func (t taskMonitorController) FindAll(c *gin.Context) {
+	var request TaskMonitorListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	monitors, err := repositories.TaskMonitorRepository.FindAll(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+	states, err := repositories.TaskMonitorRepository.FindStates(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	response := make([]TaskMonitorResponse, len(monitors))
+	for i := range monitors {
+		response[i] = taskMonitorResponse(&monitors[i], states)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (t taskMonitorController) Create(c *gin.Context) {
+	var request SaveTaskMonitorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	monitor := &models.TaskMonitor{
+		Id:        uuid.New(),
+		ProjectId: request.ProjectId,
+	}
+	if err := applyTaskMonitorRequest(monitor, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.TaskMonitorRepository.Save(c, monitor); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, taskMonitorResponse(monitor, nil))
+}
+
+func (t taskMonitorController) FindById(c *gin.Context) {
+	var request TaskMonitorListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	monitor, ok := findTaskMonitor(c, request.ProjectId)
+	if !ok {
+		return
+	}
+	states, err := repositories.TaskMonitorRepository.FindStates(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, taskMonitorResponse(monitor, states))
+}
+
+func (t taskMonitorController) Update(c *gin.Context) {
+	var request SaveTaskMonitorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	monitor, ok := findTaskMonitor(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := applyTaskMonitorRequest(monitor, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.TaskMonitorRepository.Save(c, monitor); err != nil {
+		panic(err)
+	}
+	states, err := repositories.TaskMonitorRepository.FindStates(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, taskMonitorResponse(monitor, states))
+}
+
+func (t taskMonitorController) Delete(c *gin.Context) {
+	var request TaskMonitorListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	monitor, ok := findTaskMonitor(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := repositories.TaskMonitorRepository.Delete(c, monitor.ProjectId, monitor.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// FindEvents returns the missed, late, recovered and duration outlier events of the project's monitors, or of the
+// monitor in the path, most recent first
+func (t taskMonitorController) FindEvents(c *gin.Context) {
+	var request TaskMonitorEventsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var monitorId *uuid.UUID
+	if c.Param("monitorId") != "" {
+		monitor, ok := findTaskMonitor(c, request.ProjectId)
+		if !ok {
+			return
+		}
+		monitorId = &monitor.Id
+	}
+
+	events, total, err := repositories.TaskMonitorEventRepository.FindByProject(c, request.ProjectId, monitorId, request.Pagination.Page, request.Pagination.PageSize)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.TaskMonitorEvent]{
+		Data: events,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+// findTaskMonitor loads the monitor of the :monitorId param, writing the error response and returning false when
+// it is invalid or doesn't exist
+func findTaskMonitor(c *gin.Context, projectId uuid.UUID) (*models.TaskMonitor, bool) {
+	monitorId, err := uuid.Parse(c.Param("monitorId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task monitor ID"})
+		return nil, false
+	}
+
+	monitor, err := repositories.TaskMonitorRepository.FindById(c, projectId, monitorId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTaskMonitorNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task monitor not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return monitor, true
+}
+
+func taskMonitorResponse(monitor *models.TaskMonitor, states map[uuid.UUID]models.TaskMonitorState) TaskMonitorResponse {
+	response := TaskMonitorResponse{TaskMonitor: *monitor}
+	var lastRunAt *time.Time
+	if state, ok := states[monitor.Id]; ok {
+		response.State = &state
+		lastRunAt = state.LastRunAt
+	}
+	if next := monitor.NextRun(time.Now(), lastRunAt); !next.IsZero() {
+		response.NextRunAt = &next
+	}
+	return response
+}
+
+// applyTaskMonitorRequest validates the request and copies it onto the monitor
+func applyTaskMonitorRequest(monitor *models.TaskMonitor, request *SaveTaskMonitorRequest) error {
+	taskName := strings.TrimSpace(request.TaskName)
+	nameLen := utf8.RuneCountInString(taskName)
+	if nameLen < 1 || nameLen > 200 {
+		return errors.New("taskName must be between 1 and 200 characters")
+	}
+
+	timezone := request.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return errors.New("invalid timezone: " + timezone)
+	}
+
+	switch request.ScheduleType {
+	case models.TaskScheduleCron:
+		if _, err := models.ParseCronSchedule(request.CronExpression, location); err != nil {
+			return errors.New("invalid cron expression: " + err.Error())
+		}
+		monitor.CronExpression = strings.TrimSpace(request.CronExpression)
+		monitor.IntervalSeconds = 0
+	case models.TaskScheduleInterval:
+		if request.IntervalSeconds == 0 {
+			return errors.New("intervalSeconds is required for interval schedules")
+		}
+		monitor.IntervalSeconds = request.IntervalSeconds
+		monitor.CronExpression = ""
+	default:
+		return errors.New("scheduleType must be one of: cron, interval")
+	}
+
+	monitor.TaskName = taskName
+	monitor.ScheduleType = request.ScheduleType
+	monitor.Timezone = timezone
+	monitor.GraceSeconds = request.GraceSeconds
+	monitor.MaxDurationMs = request.MaxDurationMs
+	monitor.Enabled = request.Enabled == nil || *request.Enabled
+	return nil
+}
+
+var TaskMonitorController = taskMonitorController{}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/repositories"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var traceIdParamRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+type traceController struct{}
+
+type TraceRequest struct {
+	FromDate time.Time `json:"fromDate"`
+	ToDate   time.Time `json:"toDate"`
+}
+
+// GetTrace assembles every transaction and segment of a trace, across all projects, into one waterfall
+func (t traceController) GetTrace(c *gin.Context) {
+	traceId := strings.ToLower(c.Param("traceId"))
+	if !traceIdParamRe.MatchString(traceId) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid traceId"})
+		return
+	}
+
+	var request TraceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Default to the last 7 days when no window is provided
+	if request.ToDate.IsZero() {
+		request.ToDate = time.Now()
+	}
+	if request.FromDate.IsZero() {
+		request.FromDate = request.ToDate.Add(-7 * 24 * time.Hour)
+	}
+
+	endpoints, err := repositories.EndpointRepository.FindByTraceId(c, traceId, request.FromDate, request.ToDate)
+	if err != nil {
+		panic(err)
+	}
+	tasks, err := repositories.TaskRepository.FindByTraceId(c, traceId, request.FromDate, request.ToDate)
+	if err != nil {
+		panic(err)
+	}
+	segments, err := repositories.SegmentRepository.FindByTraceId(c, traceId, request.FromDate, request.ToDate)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(endpoints) == 0 && len(tasks) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Trace not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildTrace(traceId, endpoints, tasks, segments))
+}
+
+// buildTrace links spans through their parent span ids and flattens them depth-first,
+// children ordered by start time. Spans whose parent is not part of the trace become roots.
+func buildTrace(traceId string, endpoints []models.Endpoint, tasks []models.Task, segments []models.Segment) models.Trace {
+	spans := make([]models.TraceSpan, 0, len(endpoints)+len(tasks)+len(segments))
+
+	for _, e := range endpoints {
+		spans = append(spans, models.TraceSpan{
+			SpanId:        models.SpanIdFromUUID(e.Id),
+			ParentSpanId:  e.ParentSpanId,
+			Kind:          models.TraceSpanKindEndpoint,
+			Name:          e.Endpoint,
+			ProjectId:     e.ProjectId,
+			TransactionId: e.Id,
+			StartTime:     e.RecordedAt,
+			Duration:      e.Duration,
+			StatusCode:    e.StatusCode,
+			ServerName:    e.ServerName,
+		})
+	}
+	for _, t := range tasks {
+		spans = append(spans, models.TraceSpan{
+			SpanId:        models.SpanIdFromUUID(t.Id),
+			ParentSpanId:  t.ParentSpanId,
+			Kind:          models.TraceSpanKindTask,
+			Name:          t.TaskName,
+			ProjectId:     t.ProjectId,
+			TransactionId: t.Id,
+			StartTime:     t.RecordedAt,
+			Duration:      t.Duration,
+			ServerName:    t.ServerName,
+		})
+	}
+	for _, s := range segments {
+		parentSpanId := s.ParentSpanId
+		if parentSpanId == "" {
+			parentSpanId = models.SpanIdFromUUID(s.TransactionId)
+		}
+		spans = append(spans, models.TraceSpan{
+			SpanId:        models.SpanIdFromUUID(s.Id),
+			ParentSpanId:  parentSpanId,
+			Kind:          models.TraceSpanKindSegment,
+			Name:          s.Name,
+			ProjectId:     s.ProjectId,
+			TransactionId: s.TransactionId,
+			StartTime:     s.StartTime,
+			Duration:      s.Duration,
+		})
+	}
+
+	known := make(map[string]bool, len(spans))
+	for _, s := range spans {
+		known[s.SpanId] = true
+	}
+
+	children := make(map[string][]int)
+	var roots []int
+	for i, s := range spans {
+		if s.ParentSpanId == "" || !known[s.ParentSpanId] || s.ParentSpanId == s.SpanId {
+			roots = append(roots, i)
+		} else {
+			children[s.ParentSpanId] = append(children[s.ParentSpanId], i)
+		}
+	}
+
+	byStart := func(idx []int) {
+		sort.SliceStable(idx, func(a, b int) bool {
+			return spans[idx[a]].StartTime.Before(spans[idx[b]].StartTime)
+		})
+	}
+	byStart(roots)
+
+	trace := models.Trace{
+		TraceId:    traceId,
+		ProjectIds: []uuid.UUID{},
+		Spans:      make([]models.TraceSpan, 0, len(spans)),
+	}
+
+	var end time.Time
+	for i, s := range spans {
+		if i == 0 || s.StartTime.Before(trace.StartTime) {
+			trace.StartTime = s.StartTime
+		}
+		if spanEnd := s.StartTime.Add(s.Duration); spanEnd.After(end) {
+			end = spanEnd
+		}
+	}
+	trace.Duration = end.Sub(trace.StartTime)
+
+	seenProjects := make(map[uuid.UUID]bool)
+	visited := make(map[int]bool, len(spans))
+
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+
+		span := spans[i]
+		span.Depth = depth
+		span.Offset = span.StartTime.Sub(trace.StartTime)
+		if project := cache.ProjectCache.GetById(span.ProjectId); project != nil {
+			span.ProjectName = project.Name
+		}
+		if !seenProjects[span.ProjectId] {
+			seenProjects[span.ProjectId] = true
+			trace.ProjectIds = append(trace.ProjectIds, span.ProjectId)
+		}
+		trace.Spans = append(trace.Spans, span)
+
+		kids := children[span.SpanId]
+		byStart(kids)
+		for _, k := range kids {
+			walk(k, depth+1)
+		}
+	}
+
+	for _, r := range roots {
+		walk(r, 0)
+	}
+
+	return trace
+}
+
+var TraceController = traceController{}
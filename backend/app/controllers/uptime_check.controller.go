@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"backend/app/jobs"
+	"backend/app/models"
+	"backend/app/repositories"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type uptimeCheckController struct{}
+
+type UptimeCheckListRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+}
+
+type SaveUptimeCheckRequest struct {
+	ProjectId uuid.UUID `json:"projectId"`
+	Name      string    `json:"name" binding:"required"`
+	Url       string    `json:"url" binding:"required"`
+	// Method defaults to GET
+	Method string `json:"method"`
+	// IntervalSeconds defaults to 60
+	IntervalSeconds int    `json:"intervalSeconds" binding:"omitempty,min=30,max=86400"`
+	ExpectedStatus  int    `json:"expectedStatus" binding:"omitempty,min=100,max=599"`
+	BodyMatch       string `json:"bodyMatch" binding:"max=1000"`
+	// TimeoutMs defaults to 10000
+	TimeoutMs int `json:"timeoutMs" binding:"omitempty,min=1000,max=30000"`
+	// CreateIssues and Enabled default to true
+	CreateIssues *bool `json:"createIssues"`
+	Enabled      *bool `json:"enabled"`
+}
+
+type UptimeResultsRequest struct {
+	ProjectId  uuid.UUID        `json:"projectId"`
+	FromDate   *time.Time       `json:"fromDate"`
+	ToDate     *time.Time       `json:"toDate"`
+	Pagination PaginationParams `json:"pagination"`
+}
+
+// UptimeCheckResponse is a check with its last result and its stats over the last 24 hours
+type UptimeCheckResponse struct {
+	models.UptimeCheck
+	LastResult *models.UptimeResult     `json:"lastResult"`
+	Stats      *models.UptimeCheckStats `json:"stats"`
+}
+
+func (u uptimeCheckController) FindAll(c *gin.Context) {
+	var request UptimeCheckListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checks, err := repositories.UptimeCheckRepository.FindAll(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+	latest, err := repositories.UptimeResultRepository.FindLatest(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	response := make([]UptimeCheckResponse, len(checks))
+	for i := range checks {
+		response[i] = uptimeCheckResponse(c, &checks[i], latest)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (u uptimeCheckController) Create(c *gin.Context) {
+	var request SaveUptimeCheckRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check := &models.UptimeCheck{
+		Id:        uuid.New(),
+		ProjectId: request.ProjectId,
+	}
+	if err := applyUptimeCheckRequest(check, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.UptimeCheckRepository.Save(c, check); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusCreated, UptimeCheckResponse{UptimeCheck: *check})
+}
+
+func (u uptimeCheckController) FindById(c *gin.Context) {
+	var request UptimeCheckListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, ok := findUptimeCheck(c, request.ProjectId)
+	if !ok {
+		return
+	}
+	latest, err := repositories.UptimeResultRepository.FindLatest(c, request.ProjectId)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, uptimeCheckResponse(c, check, latest))
+}
+
+func (u uptimeCheckController) Update(c *gin.Context) {
+	var request SaveUptimeCheckRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, ok := findUptimeCheck(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := applyUptimeCheckRequest(check, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := repositories.UptimeCheckRepository.Save(c, check); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, UptimeCheckResponse{UptimeCheck: *check})
+}
+
+func (u uptimeCheckController) Delete(c *gin.Context) {
+	var request UptimeCheckListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, ok := findUptimeCheck(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	if err := repositories.UptimeCheckRepository.Delete(c, check.ProjectId, check.Id); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// Run runs the check right away and records the result like a scheduled run
+func (u uptimeCheckController) Run(c *gin.Context) {
+	var request UptimeCheckListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, ok := findUptimeCheck(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	result := jobs.RunUptimeCheck(c, check)
+	if err := jobs.RecordUptimeResult(c, check, &result); err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FindResults returns the results of a check, most recent first, over the last 24 hours by default
+func (u uptimeCheckController) FindResults(c *gin.Context) {
+	var request UptimeResultsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, ok := findUptimeCheck(c, request.ProjectId)
+	if !ok {
+		return
+	}
+
+	end := time.Now()
+	if request.ToDate != nil {
+		end = *request.ToDate
+	}
+	start := end.Add(-24 * time.Hour)
+	if request.FromDate != nil {
+		start = *request.FromDate
+	}
+
+	results, total, err := repositories.UptimeResultRepository.FindByCheck(c, check.ProjectId, check.Id, start, end, request.Pagination.Page, request.Pagination.PageSize)
+	if err != nil {
+		panic(err)
+	}
+
+	c.JSON(http.StatusOK, PaginatedResponse[models.UptimeResult]{
+		Data: results,
+		Pagination: Pagination{
+			Page:       request.Pagination.Page,
+			PageSize:   request.Pagination.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(request.Pagination.PageSize) - 1) / int64(request.Pagination.PageSize),
+		},
+	})
+}
+
+// findUptimeCheck loads the check of the :checkId param, writing the error response and returning false when it
+// is invalid or doesn't exist
+func findUptimeCheck(c *gin.Context, projectId uuid.UUID) (*models.UptimeCheck, bool) {
+	checkId, err := uuid.Parse(c.Param("checkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid uptime check ID"})
+		return nil, false
+	}
+
+	check, err := repositories.UptimeCheckRepository.FindById(c, projectId, checkId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUptimeCheckNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Uptime check not found"})
+			return nil, false
+		}
+		panic(err)
+	}
+	return check, true
+}
+
+func uptimeCheckResponse(c *gin.Context, check *models.UptimeCheck, latest map[uuid.UUID]models.UptimeResult) UptimeCheckResponse {
+	response := UptimeCheckResponse{UptimeCheck: *check}
+	if result, ok := latest[check.Id]; ok {
+		response.LastResult = &result
+	}
+
+	now := time.Now()
+	stats, err := repositories.UptimeResultRepository.GetStats(c, check.ProjectId, check.Id, now.Add(-24*time.Hour), now)
+	if err != nil {
+		panic(err)
+	}
+	response.Stats = stats
+	return response
+}
+
+// applyUptimeCheckRequest validates the request and copies it onto the check
+func applyUptimeCheckRequest(check *models.UptimeCheck, request *SaveUptimeCheckRequest) error {
+	nameLen := utf8.RuneCountInString(request.Name)
+	if nameLen < 1 || nameLen > 100 {
+		return errors.New("uptime check name must be between 1 and 100 characters")
+	}
+	if u, err := url.Parse(request.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !models.UptimeCheckMethods[method] {
+		return errors.New("method must be one of: GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+	}
+
+	interval := request.IntervalSeconds
+	if interval == 0 {
+		interval = 60
+	}
+	timeout := request.TimeoutMs
+	if timeout == 0 {
+		timeout = 10000
+	}
+	if timeout > interval*1000 {
+		return errors.New("timeoutMs must not be longer than the interval")
+	}
+
+	check.Name = request.Name
+	check.Url = request.Url
+	check.Method = method
+	check.IntervalSeconds = interval
+	check.ExpectedStatus = request.ExpectedStatus
+	check.BodyMatch = request.BodyMatch
+	check.TimeoutMs = timeout
+	check.CreateIssues = request.CreateIssues == nil || *request.CreateIssues
+	check.Enabled = request.Enabled == nil || *request.Enabled
+	return nil
+}
+
+var UptimeCheckController = uptimeCheckController{}
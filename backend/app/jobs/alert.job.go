@@ -0,0 +1,273 @@
+package jobs
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultAlertEvaluationInterval is how often the alert rules are evaluated, overridable with
+// ALERT_EVALUATION_INTERVAL_SECONDS
+const defaultAlertEvaluationInterval = time.Minute
+
+func alertEvaluationInterval() time.Duration {
+	if value, err := strconv.Atoi(os.Getenv("ALERT_EVALUATION_INTERVAL_SECONDS")); err == nil && value > 0 {
+		return time.Duration(value) * time.Second
+	}
+	return defaultAlertEvaluationInterval
+}
+
+// StartAlertEvaluator evaluates every enabled alert rule on a fixed interval until ctx is cancelled
+func StartAlertEvaluator(ctx context.Context) {
+	interval := alertEvaluationInterval()
+	log.Printf("Evaluating alert rules every %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := EvaluateAlertRules(ctx, now); err != nil {
+					log.Printf("Error evaluating alert rules: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// EvaluateAlertRules runs one evaluation round: every enabled rule is evaluated, its new state stored and
+// every state transition recorded in the alert history
+func EvaluateAlertRules(ctx context.Context, now time.Time) error {
+	rules, err := repositories.AlertRuleRepository.FindAllEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	states, err := repositories.AlertRuleRepository.FindStates(ctx, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	nextStates := make([]models.AlertRuleState, 0, len(rules))
+	var events []models.AlertEvent
+	rulesById := make(map[uuid.UUID]*models.AlertRule, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		rulesById[rule.Id] = rule
+		value, err := EvaluateAlertRule(ctx, rule, now)
+		if err != nil {
+			log.Printf("Error evaluating alert rule %s: %v", rule.Id, err)
+			continue
+		}
+
+		current := states[rule.Id]
+		next := rule.NextState(current, value, now)
+		nextStates = append(nextStates, next)
+
+		fromState := current.State
+		if fromState == "" {
+			fromState = models.AlertStateOk
+		}
+		if fromState != next.State {
+			events = append(events, models.AlertEvent{
+				Id:        uuid.New(),
+				RuleId:    rule.Id,
+				ProjectId: rule.ProjectId,
+				RuleName:  rule.Name,
+				FromState: fromState,
+				ToState:   next.State,
+				Value:     value,
+				Threshold: rule.Threshold,
+				CreatedAt: now,
+			})
+		}
+	}
+
+	if err := repositories.AlertRuleRepository.SaveStates(ctx, nextStates); err != nil {
+		return err
+	}
+	if err := repositories.AlertEventRepository.InsertAsync(ctx, events); err != nil {
+		return err
+	}
+
+	for i := range events {
+		event, ok := alertNotificationEvent(&events[i], rulesById[events[i].RuleId])
+		if !ok {
+			continue
+		}
+		if err := notifications.Notify(ctx, event); err != nil {
+			log.Printf("Error queueing alert notification for rule %s: %v", events[i].RuleId, err)
+		}
+	}
+	return nil
+}
+
+// alertNotificationEvent builds the notification for a rule that started firing or resolved,
+// moving in and out of PENDING isn't notified
+func alertNotificationEvent(event *models.AlertEvent, rule *models.AlertRule) (models.NotificationEvent, bool) {
+	var eventType, title string
+	switch {
+	case event.ToState == models.AlertStateFiring:
+		eventType = models.NotificationEventAlertFiring
+		title = "Alert firing: " + event.RuleName
+	case event.FromState == models.AlertStateFiring && event.ToState == models.AlertStateOk:
+		eventType = models.NotificationEventAlertResolved
+		title = "Alert resolved: " + event.RuleName
+	default:
+		return models.NotificationEvent{}, false
+	}
+
+	return models.NotificationEvent{
+		Type:      eventType,
+		ProjectId: event.ProjectId,
+		Title:     title,
+		Message:   fmt.Sprintf("%s is %s (threshold %s %s over %d minutes)", event.RuleName, formatAlertValue(event.Value), rule.Comparison, formatAlertValue(rule.Threshold), rule.WindowMinutes),
+		Fields: map[string]string{
+			"rule":      event.RuleName,
+			"ruleId":    event.RuleId.String(),
+			"ruleType":  rule.Type,
+			"value":     formatAlertValue(event.Value),
+			"threshold": formatAlertValue(rule.Threshold),
+			"state":     event.ToState,
+		},
+		OccurredAt: event.CreatedAt,
+	}, true
+}
+
+// formatAlertValue rounds to 2 decimals and drops trailing zeros
+func formatAlertValue(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// EvaluateAlertRule returns the value of the rule's condition over the window ending at now. Windows without
+// data evaluate to 0.
+func EvaluateAlertRule(ctx context.Context, rule *models.AlertRule, now time.Time) (float64, error) {
+	start := now.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+
+	var value float64
+	switch rule.Type {
+	case models.AlertRuleTypeMetric:
+		aggregation := rule.Aggregation
+		if aggregation == "" {
+			metricType, err := repositories.MetricRecordRepository.GetMetricType(ctx, rule.ProjectId, rule.MetricName)
+			if err != nil {
+				return 0, err
+			}
+			aggregation = models.DefaultMetricAggregation(metricType)
+		}
+		v, err := repositories.MetricRecordRepository.GetAggregateBetween(ctx, rule.ProjectId, rule.MetricName, aggregation, start, now, rule.MetricFilter)
+		if err != nil {
+			return 0, err
+		}
+		value = v
+	case models.AlertRuleTypeEndpointP95, models.AlertRuleTypeEndpointErrorRate:
+		stats, err := repositories.EndpointRepository.GetEndpointStats(ctx, rule.ProjectId, rule.Endpoint, start, now, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		if rule.Type == models.AlertRuleTypeEndpointP95 {
+			value = stats.P95Duration
+		} else {
+			value = stats.ErrorRate
+		}
+	case models.AlertRuleTypeExceptionCount:
+		count, err := repositories.ExceptionStackTraceRepository.CountExceptionsBetween(ctx, rule.ProjectId, start, now)
+		if err != nil {
+			return 0, err
+		}
+		value = float64(count)
+	case models.AlertRuleTypeNewIssueCount:
+		count, err := repositories.ExceptionStackTraceRepository.CountNewIssuesBetween(ctx, rule.ProjectId, start, now)
+		if err != nil {
+			return 0, err
+		}
+		value = float64(count)
+	case models.AlertRuleTypeAnomaly:
+		v, err := evaluateAnomalyScore(ctx, rule, start, now)
+		if err != nil {
+			return 0, err
+		}
+		value = v
+	case models.AlertRuleTypeSloBurnRate:
+		slo, err := repositories.SloRepository.FindById(ctx, rule.ProjectId, rule.SloId)
+		if err != nil {
+			return 0, err
+		}
+		window := time.Duration(rule.WindowMinutes) * time.Minute
+		shortWindow := window / 12
+		if shortWindow < time.Minute {
+			shortWindow = time.Minute
+		}
+		counts, err := repositories.SloRepository.CountEvents(ctx, slo, now, []time.Duration{window, shortWindow})
+		if err != nil {
+			return 0, err
+		}
+		value = math.Min(slo.BurnRate(counts[0]), slo.BurnRate(counts[1]))
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		value = 0
+	}
+	return value, nil
+}
+
+// evaluateAnomalyScore scores the rule's metric over the window against the baseline of the hour of week of now.
+// Without an endpoint or task the most deviating one in the comparison's direction is used, the highest score
+// for gt/gte and the lowest for lt/lte.
+func evaluateAnomalyScore(ctx context.Context, rule *models.AlertRule, start, now time.Time) (float64, error) {
+	baselines, err := cache.AnomalyBaselineCache.Get(ctx, rule.ProjectId, rule.OperationType)
+	if err != nil {
+		return 0, err
+	}
+	stats, err := repositories.OperationStatsRepository.FindBetween(ctx, rule.ProjectId, rule.OperationType, rule.Endpoint, start, now)
+	if err != nil {
+		return 0, err
+	}
+	byOperation := make(map[string]*models.OperationStats, len(stats))
+	for i := range stats {
+		byOperation[stats[i].Operation] = &stats[i]
+	}
+
+	lowest := rule.Comparison == models.AlertComparisonLessThan || rule.Comparison == models.AlertComparisonLessThanOrEqual
+	var value float64
+	scored := false
+	for operation, baseline := range baselines {
+		if rule.Endpoint != "" && operation != rule.Endpoint {
+			continue
+		}
+		slot, ok := baseline.Slot(rule.AnomalyMetric, now)
+		if !ok {
+			continue
+		}
+		s, ok := byOperation[operation]
+		if !ok {
+			s = &models.OperationStats{Operation: operation}
+		}
+		v, ok := s.Value(rule.AnomalyMetric, now.Sub(start))
+		if !ok {
+			continue
+		}
+		score := slot.Score(v)
+		if !scored || (lowest && score < value) || (!lowest && score > value) {
+			value = score
+			scored = true
+		}
+	}
+	return value, nil
+}
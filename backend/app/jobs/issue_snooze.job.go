@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultIssueSnoozeInterval is how often the conditions of snoozed issues are evaluated, overridable with
+// ISSUE_SNOOZE_INTERVAL_SECONDS
+const defaultIssueSnoozeInterval = time.Minute
+
+func issueSnoozeInterval() time.Duration {
+	if value, err := strconv.Atoi(os.Getenv("ISSUE_SNOOZE_INTERVAL_SECONDS")); err == nil && value > 0 {
+		return time.Duration(value) * time.Second
+	}
+	return defaultIssueSnoozeInterval
+}
+
+// StartIssueSnoozeMonitor brings back snoozed issues whose condition is met on a fixed interval until ctx is
+// cancelled
+func StartIssueSnoozeMonitor(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(issueSnoozeInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := EvaluateSnoozedIssues(ctx, now); err != nil {
+					log.Printf("Error evaluating snoozed issues: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// EvaluateSnoozedIssues unsnoozes every snoozed issue whose condition is met at now, counting its occurrences in
+// exception_stack_traces since it was snoozed
+func EvaluateSnoozedIssues(ctx context.Context, now time.Time) error {
+	snoozed, err := repositories.IssueRepository.FindAllSnoozed(ctx)
+	if err != nil {
+		return err
+	}
+
+	var issues []models.Issue
+	var activities []models.IssueActivity
+	var events []models.NotificationEvent
+	for _, issue := range snoozed {
+		if issue.SnoozedAt == nil {
+			continue
+		}
+
+		var stats models.IssueSnoozeStats
+		if issue.Snooze.NeedsStats() {
+			hourStart := now.Add(-time.Hour)
+			if hourStart.Before(*issue.SnoozedAt) {
+				hourStart = *issue.SnoozedAt
+			}
+			stats, err = repositories.ExceptionStackTraceRepository.GetSnoozeStats(ctx, issue.ProjectId, issue.ExceptionHash, *issue.SnoozedAt, hourStart)
+			if err != nil {
+				log.Printf("Error counting the occurrences of snoozed issue %s: %v", issue.ExceptionHash, err)
+				continue
+			}
+		}
+
+		condition := issue.Snooze.MetCondition(stats, now)
+		if condition == "" {
+			continue
+		}
+		activities = append(activities, *issue.Unsnooze(condition, now))
+		issues = append(issues, issue)
+		events = append(events, models.NotificationEvent{
+			Type:      models.NotificationEventIssueUnsnoozed,
+			ProjectId: issue.ProjectId,
+			Title:     "Issue unsnoozed: " + issue.ExceptionHash,
+			Message:   "A snoozed issue is back, it reached its snooze condition of " + condition + ".",
+			Fields: map[string]string{
+				"exceptionHash": issue.ExceptionHash,
+				"condition":     condition,
+				"occurrences":   strconv.FormatUint(stats.Occurrences, 10),
+			},
+			Url:        models.IssueUrl(issue.ExceptionHash),
+			OccurredAt: now,
+		})
+	}
+
+	// issues changed since they were read are evaluated again on the next tick
+	conflicts, err := repositories.IssueRepository.Save(ctx, issues)
+	if err != nil {
+		return err
+	}
+	var saved []models.IssueActivity
+	for _, activity := range activities {
+		if !conflicts[models.IssueKey(activity.ProjectId, activity.ExceptionHash)] {
+			saved = append(saved, activity)
+		}
+	}
+	if err := repositories.IssueRepository.InsertActivities(ctx, saved); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if conflicts[models.IssueKey(event.ProjectId, event.Fields["exceptionHash"])] {
+			continue
+		}
+		if err := notifications.Notify(ctx, event); err != nil {
+			log.Printf("Error queueing unsnooze notification for %s: %v", event.Fields["exceptionHash"], err)
+		}
+	}
+	return nil
+}
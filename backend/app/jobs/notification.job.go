@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultNotificationDispatchInterval is how often due deliveries are sent, overridable with
+	// NOTIFICATION_DISPATCH_INTERVAL_SECONDS
+	defaultNotificationDispatchInterval = 10 * time.Second
+	// notificationDispatchBatchSize is the number of deliveries sent per round
+	notificationDispatchBatchSize = 100
+	// notificationMaxAge is how long a pending delivery is kept in the queue
+	notificationMaxAge = 24 * time.Hour
+)
+
+func notificationDispatchInterval() time.Duration {
+	if value, err := strconv.Atoi(os.Getenv("NOTIFICATION_DISPATCH_INTERVAL_SECONDS")); err == nil && value > 0 {
+		return time.Duration(value) * time.Second
+	}
+	return defaultNotificationDispatchInterval
+}
+
+// StartNotificationDispatcher sends queued notification deliveries on a fixed interval until ctx is cancelled
+func StartNotificationDispatcher(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(notificationDispatchInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := DispatchNotifications(ctx, now); err != nil {
+					log.Printf("Error dispatching notifications: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// DispatchNotifications sends every due delivery and stores the outcome, failed sends are retried with backoff
+func DispatchNotifications(ctx context.Context, now time.Time) error {
+	deliveries, err := repositories.NotificationDeliveryRepository.FindDue(ctx, now, notificationMaxAge, notificationDispatchBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	channels := make(map[uuid.UUID]*models.NotificationChannel)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		channel, ok := channels[delivery.ChannelId]
+		if !ok {
+			channel, err = repositories.NotificationChannelRepository.FindById(ctx, delivery.ProjectId, delivery.ChannelId)
+			if err != nil && !errors.Is(err, repositories.ErrNotificationChannelNotFound) {
+				return err
+			}
+			channels[delivery.ChannelId] = channel
+		}
+
+		if channel == nil || !channel.Enabled {
+			delivery.Attempts++
+			delivery.Status = models.NotificationDeliveryFailed
+			delivery.LastError = "the channel was deleted or disabled"
+			continue
+		}
+
+		responseCode, sendErr := notifications.Deliver(ctx, channel, delivery)
+		notifications.RecordAttempt(delivery, responseCode, sendErr, time.Now())
+	}
+
+	return repositories.NotificationDeliveryRepository.Save(ctx, deliveries)
+}
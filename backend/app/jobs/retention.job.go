@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/repositories"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultRetentionInterval is how often the retention of the projects is enforced, overridable with
+// RETENTION_INTERVAL_SECONDS
+const defaultRetentionInterval = time.Hour
+
+func retentionInterval() time.Duration {
+	if value, err := strconv.Atoi(os.Getenv("RETENTION_INTERVAL_SECONDS")); err == nil && value > 0 {
+		return time.Duration(value) * time.Second
+	}
+	return defaultRetentionInterval
+}
+
+// StartRetentionEnforcer removes the data past the retention of the projects on a fixed interval until ctx is
+// cancelled
+func StartRetentionEnforcer(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(retentionInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := EnforceRetention(ctx, now); err != nil {
+					log.Printf("Error enforcing data retention: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// EnforceRetention drops the daily partitions every project's retention is past, then deletes the remaining
+// expired rows of each project. Tables hold the data of all projects, so a partition can only be dropped once
+// the longest retention of the projects is past; until then expired rows are deleted with mutations.
+func EnforceRetention(ctx context.Context, now time.Time) error {
+	settings, err := repositories.ProjectSettingsRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	projects := cache.ProjectCache.GetAll()
+
+	// without the server's time zone the expired rows are only deleted with mutations
+	location, err := repositories.StorageRepository.FindServerLocation(ctx)
+	if err != nil {
+		log.Printf("Error finding the time zone of the partitions: %v", err)
+	} else {
+		for table, days := range longestRetentions(projects, settings) {
+			dropExpiredPartitions(ctx, table, now.AddDate(0, 0, -days), location)
+		}
+	}
+
+	pendingMutations := map[string]bool{}
+	for _, target := range repositories.RetentionTargets {
+		pending, checked := pendingMutations[target.Table]
+		if !checked {
+			if pending, err = repositories.StorageRepository.HasPendingMutations(ctx, target.Table); err != nil {
+				log.Printf("Error checking the mutations of %s: %v", target.Table, err)
+				continue
+			}
+			pendingMutations[target.Table] = pending
+		}
+		if pending {
+			// the previous deletes are still being applied, queueing more would only pile them up
+			continue
+		}
+
+		for _, project := range projects {
+			projectSettings := settings[project.Id]
+			days := projectSettings.RetentionDays(target.DataType)
+			if days == 0 {
+				continue
+			}
+			cutoff := now.AddDate(0, 0, -days)
+
+			expired, err := repositories.StorageRepository.HasExpiredRows(ctx, target, project.Id, cutoff)
+			if err != nil {
+				log.Printf("Error looking for expired %s of project %s: %v", target.DataType, project.Id, err)
+				continue
+			}
+			if !expired {
+				continue
+			}
+			if err := repositories.StorageRepository.DeleteExpiredRows(ctx, target, project.Id, cutoff); err != nil {
+				log.Printf("Error deleting expired %s of project %s: %v", target.DataType, project.Id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// longestRetentions returns, per table, the longest retention of the data types stored in it across all projects.
+// Tables with data kept forever by any project are left out.
+func longestRetentions(projects []*models.Project, settings map[uuid.UUID]models.ProjectSettings) map[string]int {
+	longest := map[string]int{}
+	forever := map[string]bool{}
+	for _, target := range repositories.RetentionTargets {
+		for _, project := range projects {
+			projectSettings := settings[project.Id]
+			days := projectSettings.RetentionDays(target.DataType)
+			if days == 0 {
+				forever[target.Table] = true
+			}
+			longest[target.Table] = max(longest[target.Table], days)
+		}
+	}
+	for table := range forever {
+		delete(longest, table)
+	}
+	return longest
+}
+
+// dropExpiredPartitions drops the daily partitions of a table that only hold rows recorded before cutoff. The
+// partition ids are days in the time zone of the server, location.
+func dropExpiredPartitions(ctx context.Context, table string, cutoff time.Time, location *time.Location) {
+	partitions, err := repositories.StorageRepository.FindPartitions(ctx, table)
+	if err != nil {
+		log.Printf("Error listing the partitions of %s: %v", table, err)
+		return
+	}
+
+	cutoff = cutoff.In(location)
+	cutoffDay := time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, location)
+	for _, partition := range partitions {
+		day, err := time.ParseInLocation("20060102", partition, location)
+		if err != nil || !day.Before(cutoffDay) {
+			continue
+		}
+		if err := repositories.StorageRepository.DropPartition(ctx, table, partition); err != nil {
+			log.Printf("Error dropping partition %s of %s: %v", partition, table, err)
+		}
+	}
+}
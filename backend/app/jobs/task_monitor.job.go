@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultTaskMonitorInterval is how often the task monitors are evaluated, overridable with
+	// TASK_MONITOR_INTERVAL_SECONDS
+	defaultTaskMonitorInterval = time.Minute
+	// taskLastRunLookback is how far back the last run of a task is searched when it isn't known yet
+	taskLastRunLookback = 35 * 24 * time.Hour
+	// taskDurationBaselinePeriod is the period whose runs make up the usual duration of a task
+	taskDurationBaselinePeriod = 7 * 24 * time.Hour
+	// maxTaskDurationSamples caps the past runs the usual duration is computed from
+	maxTaskDurationSamples = 5000
+	// maxTaskRunsPerRound caps the new runs checked for duration outliers per monitor and round
+	maxTaskRunsPerRound = 100
+)
+
+var taskMonitorNotificationEvents = map[string]string{
+	models.TaskMonitorEventMissed:          models.NotificationEventTaskMissed,
+	models.TaskMonitorEventLate:            models.NotificationEventTaskLate,
+	models.TaskMonitorEventRecovered:       models.NotificationEventTaskRecovered,
+	models.TaskMonitorEventDurationOutlier: models.NotificationEventTaskDurationOutlier,
+}
+
+func taskMonitorInterval() time.Duration {
+	if value, err := strconv.Atoi(os.Getenv("TASK_MONITOR_INTERVAL_SECONDS")); err == nil && value > 0 {
+		return time.Duration(value) * time.Second
+	}
+	return defaultTaskMonitorInterval
+}
+
+// StartTaskMonitor evaluates every enabled task monitor on a fixed interval until ctx is cancelled
+func StartTaskMonitor(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(taskMonitorInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := EvaluateTaskMonitors(ctx, now); err != nil {
+					log.Printf("Error evaluating task monitors: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// EvaluateTaskMonitors runs one round: every enabled monitor is checked for missed, late and recovered runs and
+// for runs since the last round that took unusually long
+func EvaluateTaskMonitors(ctx context.Context, now time.Time) error {
+	monitors, err := repositories.TaskMonitorRepository.FindAllEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	if len(monitors) == 0 {
+		return nil
+	}
+
+	states, err := repositories.TaskMonitorRepository.FindStates(ctx, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	nextStates := make([]models.TaskMonitorState, 0, len(monitors))
+	var events []models.TaskMonitorEvent
+	for i := range monitors {
+		monitor := &monitors[i]
+		current, known := states[monitor.Id]
+
+		since := now.Add(-taskLastRunLookback)
+		if current.LastRunAt != nil {
+			since = *current.LastRunAt
+		}
+		lastRunAt, err := repositories.TaskRepository.GetLastRunAt(ctx, monitor.ProjectId, monitor.TaskName, since)
+		if err != nil {
+			log.Printf("Error evaluating task monitor %s: %v", monitor.Id, err)
+			continue
+		}
+		if lastRunAt == nil {
+			lastRunAt = current.LastRunAt
+		}
+
+		next, event := monitor.Evaluate(current, lastRunAt, now)
+		nextStates = append(nextStates, next)
+		if event != nil {
+			events = append(events, *event)
+		}
+
+		// the first round only sets the starting point of the duration checks
+		if !known {
+			continue
+		}
+		outliers, err := findDurationOutliers(ctx, monitor, current.LastCheckedAt, now)
+		if err != nil {
+			log.Printf("Error checking task durations of monitor %s: %v", monitor.Id, err)
+			continue
+		}
+		events = append(events, outliers...)
+	}
+
+	if err := repositories.TaskMonitorRepository.SaveStates(ctx, nextStates); err != nil {
+		return err
+	}
+	if err := repositories.TaskMonitorEventRepository.InsertAsync(ctx, events); err != nil {
+		return err
+	}
+
+	for i := range events {
+		if err := notifications.Notify(ctx, taskMonitorNotificationEvent(&events[i])); err != nil {
+			log.Printf("Error queueing task monitor notification for %s: %v", events[i].MonitorId, err)
+		}
+	}
+	return nil
+}
+
+// findDurationOutliers returns an event for every run between start and end that took longer than the monitor's
+// outlier threshold
+func findDurationOutliers(ctx context.Context, monitor *models.TaskMonitor, start, end time.Time) ([]models.TaskMonitorEvent, error) {
+	runs, err := repositories.TaskRepository.FindRuns(ctx, monitor.ProjectId, monitor.TaskName, start, end, maxTaskRunsPerRound)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+
+	var past []float64
+	if monitor.MaxDurationMs == 0 {
+		pastRuns, err := repositories.TaskRepository.FindRuns(ctx, monitor.ProjectId, monitor.TaskName, start.Add(-taskDurationBaselinePeriod), start, maxTaskDurationSamples)
+		if err != nil {
+			return nil, err
+		}
+		past = make([]float64, len(pastRuns))
+		for i, run := range pastRuns {
+			past[i] = run.DurationMs
+		}
+	}
+	threshold, ok := monitor.DurationOutlierThreshold(past)
+	if !ok {
+		return nil, nil
+	}
+
+	var events []models.TaskMonitorEvent
+	for _, run := range runs {
+		if run.DurationMs <= threshold {
+			continue
+		}
+		runAt := run.RecordedAt
+		events = append(events, models.TaskMonitorEvent{
+			Id:         uuid.New(),
+			MonitorId:  monitor.Id,
+			ProjectId:  monitor.ProjectId,
+			TaskName:   monitor.TaskName,
+			EventType:  models.TaskMonitorEventDurationOutlier,
+			RunAt:      &runAt,
+			DurationMs: run.DurationMs,
+			CreatedAt:  end,
+		})
+	}
+	return events, nil
+}
+
+func taskMonitorNotificationEvent(event *models.TaskMonitorEvent) models.NotificationEvent {
+	fields := map[string]string{"task": event.TaskName}
+	if event.ExpectedAt != nil {
+		fields["expectedAt"] = event.ExpectedAt.UTC().Format(time.RFC3339)
+	}
+	if event.RunAt != nil {
+		fields["runAt"] = event.RunAt.UTC().Format(time.RFC3339)
+	}
+
+	var title, message string
+	switch event.EventType {
+	case models.TaskMonitorEventMissed:
+		title = "Task missed: " + event.TaskName
+		message = fmt.Sprintf("%s didn't run within the grace period of its scheduled run.", event.TaskName)
+	case models.TaskMonitorEventLate:
+		title = "Task ran late: " + event.TaskName
+		message = fmt.Sprintf("%s ran after its grace period.", event.TaskName)
+	case models.TaskMonitorEventRecovered:
+		title = "Task recovered: " + event.TaskName
+		message = fmt.Sprintf("%s is running again after missing a scheduled run.", event.TaskName)
+	case models.TaskMonitorEventDurationOutlier:
+		title = "Slow task run: " + event.TaskName
+		message = fmt.Sprintf("A run of %s took %s ms, much longer than usual.", event.TaskName, formatAlertValue(event.DurationMs))
+		fields["durationMs"] = formatAlertValue(event.DurationMs)
+	}
+
+	return models.NotificationEvent{
+		Type:       taskMonitorNotificationEvents[event.EventType],
+		ProjectId:  event.ProjectId,
+		Title:      title,
+		Message:    message,
+		Fields:     fields,
+		Url:        models.TaskUrl(event.TaskName),
+		OccurredAt: event.CreatedAt,
+	}
+}
@@ -0,0 +1,192 @@
+package jobs
+
+import (
+	"backend/app/models"
+	"backend/app/notifications"
+	"backend/app/repositories"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// uptimeSchedulerTick is how often the scheduler looks for due checks, the precision of check intervals
+	uptimeSchedulerTick = 5 * time.Second
+	// maxConcurrentUptimeChecks caps the checks running at the same time
+	maxConcurrentUptimeChecks = 20
+	// uptimeMaxBodyBytes is how much of a response body is searched for the body match
+	uptimeMaxBodyBytes = 1 << 20
+)
+
+// uptimeHttpClient opens a new connection for every run so the latency includes the connect and TLS handshake
+var uptimeHttpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: true,
+	},
+}
+
+type uptimeScheduler struct {
+	lastRun map[uuid.UUID]time.Time
+	running map[uuid.UUID]bool
+	slots   chan struct{}
+	mu      sync.Mutex
+}
+
+// StartUptimeScheduler runs every enabled uptime check on its interval until ctx is cancelled
+func StartUptimeScheduler(ctx context.Context) {
+	scheduler := &uptimeScheduler{
+		lastRun: make(map[uuid.UUID]time.Time),
+		running: make(map[uuid.UUID]bool),
+		slots:   make(chan struct{}, maxConcurrentUptimeChecks),
+	}
+
+	go func() {
+		ticker := time.NewTicker(uptimeSchedulerTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := scheduler.runDue(ctx, now); err != nil {
+					log.Printf("Error scheduling uptime checks: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// runDue starts every check whose interval elapsed since its last run and that isn't still running
+func (s *uptimeScheduler) runDue(ctx context.Context, now time.Time) error {
+	checks, err := repositories.UptimeCheckRepository.FindAllEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enabled := make(map[uuid.UUID]bool, len(checks))
+	for i := range checks {
+		check := checks[i]
+		enabled[check.Id] = true
+		if s.running[check.Id] || now.Sub(s.lastRun[check.Id]) < check.Interval() {
+			continue
+		}
+		s.running[check.Id] = true
+		s.lastRun[check.Id] = now
+
+		go func() {
+			s.slots <- struct{}{}
+			defer func() { <-s.slots }()
+
+			result := RunUptimeCheck(ctx, &check)
+			if err := RecordUptimeResult(ctx, &check, &result); err != nil {
+				log.Printf("Error recording uptime check %s: %v", check.Id, err)
+			}
+
+			s.mu.Lock()
+			delete(s.running, check.Id)
+			s.mu.Unlock()
+		}()
+	}
+
+	// forget deleted and disabled checks so they run right away once enabled again
+	for id := range s.lastRun {
+		if !enabled[id] && !s.running[id] {
+			delete(s.lastRun, id)
+		}
+	}
+	return nil
+}
+
+// RunUptimeCheck sends the check's request and evaluates the response, it never fails, errors are part of the result
+func RunUptimeCheck(ctx context.Context, check *models.UptimeCheck) models.UptimeResult {
+	result := models.UptimeResult{
+		Id:        uuid.New(),
+		CheckId:   check.Id,
+		ProjectId: check.ProjectId,
+		CheckedAt: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, check.Method, check.Url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", "Traceway-Uptime/1.0")
+
+	resp, err := uptimeHttpClient.Do(req)
+	if err != nil {
+		result.DurationMs = float64(time.Since(result.CheckedAt).Microseconds()) / 1000
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiresAt := resp.TLS.PeerCertificates[0].NotAfter
+		result.TlsExpiresAt = &expiresAt
+	}
+
+	var body []byte
+	if check.BodyMatch != "" {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, uptimeMaxBodyBytes))
+	} else {
+		_, err = io.Copy(io.Discard, io.LimitReader(resp.Body, uptimeMaxBodyBytes))
+	}
+	result.DurationMs = float64(time.Since(result.CheckedAt).Microseconds()) / 1000
+	if err != nil {
+		result.Error = "reading the response: " + err.Error()
+		return result
+	}
+
+	switch {
+	case !check.StatusMatches(resp.StatusCode):
+		expected := "a 2xx status"
+		if check.ExpectedStatus != 0 {
+			expected = fmt.Sprintf("status %d", check.ExpectedStatus)
+		}
+		result.Error = fmt.Sprintf("expected %s, got %d", expected, resp.StatusCode)
+	case check.BodyMatch != "" && !strings.Contains(string(body), check.BodyMatch):
+		result.Error = fmt.Sprintf("response body doesn't contain %q", check.BodyMatch)
+	default:
+		result.Success = true
+	}
+	return result
+}
+
+// RecordUptimeResult stores the result, and an occurrence of the check's issue when it failed and the check creates
+// issues, notifying the channels when the issue is new or regressed
+func RecordUptimeResult(ctx context.Context, check *models.UptimeCheck, result *models.UptimeResult) error {
+	if err := repositories.UptimeResultRepository.InsertAsync(ctx, []models.UptimeResult{*result}); err != nil {
+		return err
+	}
+	if result.Success || !check.CreateIssues {
+		return nil
+	}
+
+	// detected like the reported exceptions, so a new or resolved failure notifies the project's channels
+	stackTraces := []models.ExceptionStackTrace{check.FailureStackTrace(result)}
+	issueEvents, err := notifications.DetectIssueEvents(ctx, check.ProjectId, stackTraces)
+	if err != nil {
+		return err
+	}
+	if err := repositories.ExceptionStackTraceRepository.InsertAsync(ctx, stackTraces); err != nil {
+		return err
+	}
+	notifications.NotifyIssueEvents(ctx, issueEvents)
+	return nil
+}
@@ -0,0 +1,4 @@
+ALTER TABLE segments
+    ADD COLUMN IF NOT EXISTS `trace_id` String DEFAULT '',
+    ADD COLUMN IF NOT EXISTS `parent_span_id` String DEFAULT '',
+    ADD INDEX IF NOT EXISTS idx_trace_id trace_id TYPE bloom_filter(0.001) GRANULARITY 1
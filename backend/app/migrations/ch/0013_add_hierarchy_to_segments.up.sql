@@ -0,0 +1,3 @@
+ALTER TABLE segments
+    ADD COLUMN IF NOT EXISTS `parent_id` Nullable(UUID),
+    ADD COLUMN IF NOT EXISTS `attributes` String DEFAULT '{}'
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS performance_issues
+(
+    `id` UUID,
+    `project_id` UUID,
+    `issue_type` LowCardinality(String),
+    `issue_hash` String,
+    `transaction_id` UUID,
+    `transaction_type` LowCardinality(String) DEFAULT 'endpoint',
+    `transaction_name` LowCardinality(String),
+    `segment_name` String,
+    `repeat_count` UInt32,
+    `total_duration` Int64,
+    `recorded_at` DateTime,
+    `app_version` LowCardinality(String) DEFAULT '',
+    `server_name` LowCardinality(String) DEFAULT '',
+    INDEX idx_issue_hash issue_hash TYPE bloom_filter(0.01) GRANULARITY 4,
+    INDEX idx_transaction_id transaction_id TYPE bloom_filter(0.01) GRANULARITY 4,
+    INDEX idx_id id TYPE bloom_filter(0.001) GRANULARITY 1
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMMDD(recorded_at)
+ORDER BY (project_id, recorded_at, issue_hash)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,4 @@
+ALTER TABLE metric_records
+    ADD COLUMN IF NOT EXISTS `metric_type` LowCardinality(String) DEFAULT 'gauge',
+    ADD COLUMN IF NOT EXISTS `bucket_bounds` Array(Float64),
+    ADD COLUMN IF NOT EXISTS `bucket_counts` Array(UInt64)
@@ -0,0 +1,4 @@
+ALTER TABLE metric_records
+    ADD COLUMN IF NOT EXISTS `tags` Map(LowCardinality(String), String),
+    ADD INDEX IF NOT EXISTS idx_tag_keys mapKeys(tags) TYPE bloom_filter(0.01) GRANULARITY 4,
+    ADD INDEX IF NOT EXISTS idx_tag_values mapValues(tags) TYPE bloom_filter(0.01) GRANULARITY 4
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS project_settings
+(
+    `project_id` UUID,
+    `metric_tag_key_limit` UInt16 DEFAULT 0,
+    `metric_tag_value_limit` UInt32 DEFAULT 0,
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY project_id
+SETTINGS index_granularity = 8192
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS custom_dashboards
+(
+    `id` UUID,
+    `project_id` UUID,
+    `name` String,
+    `description` String DEFAULT '',
+    `default_time_range` LowCardinality(String) DEFAULT '24h',
+    `widgets` String DEFAULT '[]',
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
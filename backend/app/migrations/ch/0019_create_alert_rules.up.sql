@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS alert_rules
+(
+    `id` UUID,
+    `project_id` UUID,
+    `name` String,
+    `rule_type` LowCardinality(String),
+    `metric_name` String DEFAULT '',
+    `aggregation` LowCardinality(String) DEFAULT '',
+    `metric_filter` String DEFAULT '{}',
+    `endpoint` String DEFAULT '',
+    `comparison` LowCardinality(String),
+    `threshold` Float64,
+    `recovery_threshold` Nullable(Float64),
+    `window_minutes` UInt32,
+    `for_minutes` UInt32 DEFAULT 0,
+    `enabled` Bool DEFAULT true,
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS alert_rule_states
+(
+    `rule_id` UUID,
+    `project_id` UUID,
+    `state` LowCardinality(String),
+    `value` Float64,
+    `pending_since` Nullable(DateTime64(3)),
+    `state_changed_at` DateTime64(3),
+    `last_evaluated_at` DateTime64(3)
+)
+ENGINE = ReplacingMergeTree(last_evaluated_at)
+ORDER BY (project_id, rule_id)
+SETTINGS index_granularity = 8192
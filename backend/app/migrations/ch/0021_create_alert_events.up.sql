@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS alert_events
+(
+    `id` UUID,
+    `rule_id` UUID,
+    `project_id` UUID,
+    `rule_name` String,
+    `from_state` LowCardinality(String),
+    `to_state` LowCardinality(String),
+    `value` Float64,
+    `threshold` Float64,
+    `created_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(created_at)
+ORDER BY (project_id, rule_id, created_at)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS notification_channels
+(
+    `id` UUID,
+    `project_id` UUID,
+    `name` String,
+    `channel_type` LowCardinality(String),
+    `config` String DEFAULT '{}',
+    `event_types` Array(LowCardinality(String)),
+    `enabled` Bool DEFAULT true,
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS notification_deliveries
+(
+    `id` UUID,
+    `project_id` UUID,
+    `channel_id` UUID,
+    `event_type` LowCardinality(String),
+    `subject` String,
+    `body` String,
+    `event` String DEFAULT '{}',
+    `status` LowCardinality(String),
+    `attempts` UInt16 DEFAULT 0,
+    `last_error` String DEFAULT '',
+    `response_code` UInt16 DEFAULT 0,
+    `next_attempt_at` DateTime64(3),
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3),
+    INDEX idx_status status TYPE set(3) GRANULARITY 1
+)
+ENGINE = ReplacingMergeTree(updated_at)
+PARTITION BY toYYYYMM(created_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
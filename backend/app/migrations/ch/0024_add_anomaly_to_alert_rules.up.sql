@@ -0,0 +1,3 @@
+ALTER TABLE alert_rules
+    ADD COLUMN IF NOT EXISTS `operation_type` LowCardinality(String) DEFAULT '',
+    ADD COLUMN IF NOT EXISTS `anomaly_metric` LowCardinality(String) DEFAULT ''
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS slos
+(
+    `id` UUID,
+    `project_id` UUID,
+    `name` String,
+    `description` String DEFAULT '',
+    `endpoint_patterns` Array(String),
+    `max_good_status_code` UInt16 DEFAULT 499,
+    `max_duration_ms` UInt32 DEFAULT 0,
+    `target` Float64,
+    `window_days` UInt16 DEFAULT 30,
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,2 @@
+ALTER TABLE alert_rules
+    ADD COLUMN IF NOT EXISTS `slo_id` UUID
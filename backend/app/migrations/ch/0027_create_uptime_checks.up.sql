@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS uptime_checks
+(
+    `id` UUID,
+    `project_id` UUID,
+    `name` String,
+    `url` String,
+    `method` LowCardinality(String) DEFAULT 'GET',
+    `interval_seconds` UInt32 DEFAULT 60,
+    `expected_status` UInt16 DEFAULT 0,
+    `body_match` String DEFAULT '',
+    `timeout_ms` UInt32 DEFAULT 10000,
+    `create_issues` Bool DEFAULT true,
+    `enabled` Bool DEFAULT true,
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS uptime_results
+(
+    `id` UUID,
+    `check_id` UUID,
+    `project_id` UUID,
+    `checked_at` DateTime64(3),
+    `success` Bool,
+    `status_code` UInt16 DEFAULT 0,
+    `duration_ms` Float64,
+    `error` String DEFAULT '',
+    `tls_expires_at` Nullable(DateTime)
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMMDD(checked_at)
+ORDER BY (project_id, check_id, checked_at)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS task_monitors
+(
+    `id` UUID,
+    `project_id` UUID,
+    `task_name` String,
+    `schedule_type` LowCardinality(String),
+    `cron_expression` String DEFAULT '',
+    `timezone` String DEFAULT 'UTC',
+    `interval_seconds` UInt32 DEFAULT 0,
+    `grace_seconds` UInt32 DEFAULT 0,
+    `max_duration_ms` UInt32 DEFAULT 0,
+    `enabled` Bool DEFAULT true,
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS task_monitor_states
+(
+    `monitor_id` UUID,
+    `project_id` UUID,
+    `status` LowCardinality(String),
+    `last_run_at` Nullable(DateTime64(3)),
+    `missed_expected_at` Nullable(DateTime64(3)),
+    `last_checked_at` DateTime64(3)
+)
+ENGINE = ReplacingMergeTree(last_checked_at)
+ORDER BY (project_id, monitor_id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS task_monitor_events
+(
+    `id` UUID,
+    `monitor_id` UUID,
+    `project_id` UUID,
+    `task_name` String,
+    `event_type` LowCardinality(String),
+    `expected_at` Nullable(DateTime64(3)),
+    `run_at` Nullable(DateTime64(3)),
+    `duration_ms` Float64 DEFAULT 0,
+    `created_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(created_at)
+ORDER BY (project_id, monitor_id, created_at)
+SETTINGS index_granularity = 8192
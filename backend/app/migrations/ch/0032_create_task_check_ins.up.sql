@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS task_check_ins
+(
+    `id` UUID,
+    `project_id` UUID,
+    `task_name` LowCardinality(String),
+    `check_in_id` UUID,
+    `status` LowCardinality(String),
+    `duration` Int64 DEFAULT 0,
+    `recorded_at` DateTime64(3)
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMMDD(recorded_at)
+ORDER BY (project_id, task_name, recorded_at)
+SETTINGS index_granularity = 8192
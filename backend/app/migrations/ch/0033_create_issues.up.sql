@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS issues
+(
+    `project_id` UUID,
+    `exception_hash` String,
+    `status` LowCardinality(String) DEFAULT 'unresolved',
+    `assignee` String DEFAULT '',
+    `priority` LowCardinality(String) DEFAULT 'medium',
+    `resolved_at` Nullable(DateTime64(3)),
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, exception_hash)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS issue_comments
+(
+    `id` UUID,
+    `project_id` UUID,
+    `exception_hash` String,
+    `author` String DEFAULT '',
+    `body` String,
+    `created_at` DateTime64(3) DEFAULT now64(3),
+    `updated_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (project_id, exception_hash, id)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS issue_activities
+(
+    `id` UUID,
+    `project_id` UUID,
+    `exception_hash` String,
+    `actor` String DEFAULT '',
+    `action` LowCardinality(String),
+    `old_value` String DEFAULT '',
+    `new_value` String DEFAULT '',
+    `created_at` DateTime64(3) DEFAULT now64(3)
+)
+ENGINE = MergeTree
+ORDER BY (project_id, exception_hash, created_at)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,4 @@
+INSERT INTO issues (project_id, exception_hash, status, resolved_at, created_at, updated_at)
+SELECT project_id, exception_hash, 'resolved', max(archived_at), max(archived_at), max(archived_at)
+FROM archived_exceptions FINAL
+GROUP BY project_id, exception_hash
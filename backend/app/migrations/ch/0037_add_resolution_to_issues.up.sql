@@ -0,0 +1,3 @@
+ALTER TABLE issues
+    ADD COLUMN IF NOT EXISTS `resolution` LowCardinality(String) DEFAULT '',
+    ADD COLUMN IF NOT EXISTS `resolved_in_version` String DEFAULT ''
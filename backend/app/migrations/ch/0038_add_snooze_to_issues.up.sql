@@ -0,0 +1,7 @@
+ALTER TABLE issues
+    ADD COLUMN IF NOT EXISTS `snoozed_at` Nullable(DateTime64(3)),
+    ADD COLUMN IF NOT EXISTS `snooze_until` Nullable(DateTime64(3)),
+    ADD COLUMN IF NOT EXISTS `snooze_occurrences` UInt32 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `snooze_occurrences_per_hour` UInt32 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `snooze_users` UInt32 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `snooze_servers` UInt32 DEFAULT 0
@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+    ADD INDEX IF NOT EXISTS idx_scope scope TYPE ngrambf_v1(4, 4096, 3, 0) GRANULARITY 4
@@ -0,0 +1,7 @@
+ALTER TABLE project_settings
+    ADD COLUMN IF NOT EXISTS `endpoint_retention_days` UInt16 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `task_retention_days` UInt16 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `segment_retention_days` UInt16 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `exception_retention_days` UInt16 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `message_retention_days` UInt16 DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS `metric_retention_days` UInt16 DEFAULT 0
@@ -0,0 +1,15 @@
+CREATE MATERIALIZED VIEW IF NOT EXISTS endpoint_rollups_1h_mv TO endpoint_rollups_1h AS
+SELECT
+    project_id,
+    toStartOfHour(recorded_at) AS bucket,
+    endpoint,
+    server_name,
+    count() AS requests,
+    countIf(status_code >= 400) AS errors,
+    countIf(duration <= 500000000 AND status_code < 400) AS satisfied,
+    countIf(duration > 500000000 AND duration <= 2000000000 AND status_code < 400) AS tolerating,
+    sum(duration) AS duration_sum,
+    quantilesState(0.5, 0.95, 0.99)(duration) AS duration_quantiles,
+    max(recorded_at) AS max_recorded_at
+FROM endpoints
+GROUP BY project_id, bucket, endpoint, server_name
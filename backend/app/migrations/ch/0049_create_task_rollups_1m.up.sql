@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS task_rollups_1m
+(
+    `project_id` UUID,
+    `bucket` DateTime,
+    `task_name` LowCardinality(String),
+    `server_name` LowCardinality(String),
+    `runs` SimpleAggregateFunction(sum, UInt64),
+    `duration_sum` SimpleAggregateFunction(sum, Int64),
+    `duration_quantiles` AggregateFunction(quantiles(0.5, 0.95, 0.99), Int64),
+    `max_recorded_at` SimpleAggregateFunction(max, DateTime)
+)
+ENGINE = AggregatingMergeTree
+PARTITION BY toYYYYMMDD(bucket)
+ORDER BY (project_id, bucket, task_name, server_name)
+SETTINGS index_granularity = 8192
@@ -0,0 +1,12 @@
+CREATE MATERIALIZED VIEW IF NOT EXISTS task_rollups_1h_mv TO task_rollups_1h AS
+SELECT
+    project_id,
+    toStartOfHour(recorded_at) AS bucket,
+    task_name,
+    server_name,
+    count() AS runs,
+    sum(duration) AS duration_sum,
+    quantilesState(0.5, 0.95, 0.99)(duration) AS duration_quantiles,
+    max(recorded_at) AS max_recorded_at
+FROM tasks
+GROUP BY project_id, bucket, task_name, server_name
@@ -0,0 +1,2 @@
+ALTER TABLE exception_stack_traces
+    ADD INDEX IF NOT EXISTS idx_stack_trace_lower lowerUTF8(stack_trace) TYPE tokenbf_v1(10240, 3, 0) GRANULARITY 4
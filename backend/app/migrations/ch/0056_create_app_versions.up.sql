@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS app_versions
+(
+    `project_id` UUID,
+    `app_version` String,
+    `first_seen` SimpleAggregateFunction(min, DateTime)
+)
+ENGINE = AggregatingMergeTree
+ORDER BY (project_id, app_version)
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS rollup_cutoffs
+(
+    `rollup` String,
+    `cutoff` DateTime
+)
+ENGINE = MergeTree
+ORDER BY rollup
@@ -0,0 +1,2 @@
+INSERT INTO rollup_cutoffs (rollup, cutoff)
+SELECT 'app_versions', toStartOfHour(now()) + INTERVAL 2 HOUR
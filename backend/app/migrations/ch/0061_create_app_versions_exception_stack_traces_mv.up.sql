@@ -0,0 +1,8 @@
+CREATE MATERIALIZED VIEW IF NOT EXISTS app_versions_exception_stack_traces_mv TO app_versions AS
+SELECT
+    project_id,
+    app_version,
+    min(recorded_at) AS first_seen
+FROM exception_stack_traces
+WHERE app_version != '' AND recorded_at >= (SELECT cutoff FROM rollup_cutoffs WHERE rollup = 'app_versions')
+GROUP BY project_id, app_version
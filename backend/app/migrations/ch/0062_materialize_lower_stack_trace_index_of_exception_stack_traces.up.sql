@@ -0,0 +1 @@
+ALTER TABLE exception_stack_traces MATERIALIZE INDEX idx_stack_trace_lower
@@ -0,0 +1,3 @@
+INSERT INTO rollup_cutoffs (rollup, cutoff)
+SELECT rollup, toStartOfHour(now()) + INTERVAL 2 HOUR
+FROM (SELECT arrayJoin(['endpoint_rollups_1m', 'endpoint_rollups_1h', 'task_rollups_1m', 'task_rollups_1h']) AS rollup)
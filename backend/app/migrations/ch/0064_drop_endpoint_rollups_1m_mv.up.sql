@@ -0,0 +1 @@
+DROP VIEW IF EXISTS endpoint_rollups_1m_mv
@@ -0,0 +1 @@
+TRUNCATE TABLE IF EXISTS endpoint_rollups_1h
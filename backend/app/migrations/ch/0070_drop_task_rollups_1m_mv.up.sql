@@ -0,0 +1 @@
+DROP VIEW IF EXISTS task_rollups_1m_mv
@@ -0,0 +1,13 @@
+CREATE MATERIALIZED VIEW IF NOT EXISTS task_rollups_1m_mv TO task_rollups_1m AS
+SELECT
+    project_id,
+    toStartOfMinute(recorded_at) AS bucket,
+    task_name,
+    server_name,
+    count() AS runs,
+    sum(duration) AS duration_sum,
+    quantilesState(0.5, 0.95, 0.99)(duration) AS duration_quantiles,
+    max(recorded_at) AS max_recorded_at
+FROM tasks
+WHERE recorded_at >= (SELECT cutoff FROM rollup_cutoffs WHERE rollup = 'task_rollups_1m')
+GROUP BY project_id, bucket, task_name, server_name
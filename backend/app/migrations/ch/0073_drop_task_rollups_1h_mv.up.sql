@@ -0,0 +1 @@
+DROP VIEW IF EXISTS task_rollups_1h_mv
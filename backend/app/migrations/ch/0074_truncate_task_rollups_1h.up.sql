@@ -0,0 +1 @@
+TRUNCATE TABLE IF EXISTS task_rollups_1h
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AlertRuleTypeMetric compares an aggregation of any metric (see the /metrics/query API) against the threshold
+	AlertRuleTypeMetric = "metric"
+	// AlertRuleTypeEndpointP95 compares the p95 response time in ms, of one endpoint or all of them
+	AlertRuleTypeEndpointP95 = "endpoint_p95"
+	// AlertRuleTypeEndpointErrorRate compares the percentage of requests with a status code >= 400
+	AlertRuleTypeEndpointErrorRate = "endpoint_error_rate"
+	// AlertRuleTypeExceptionCount compares the number of exceptions recorded in the window
+	AlertRuleTypeExceptionCount = "exception_count"
+	// AlertRuleTypeNewIssueCount compares the number of issues first seen in the window
+	AlertRuleTypeNewIssueCount = "new_issue_count"
+	// AlertRuleTypeAnomaly compares the anomaly score (see the /anomalies API) of an endpoint or task over the
+	// window against its baseline, of the most deviating one when no endpoint or task is set
+	AlertRuleTypeAnomaly = "anomaly"
+	// AlertRuleTypeSloBurnRate compares the burn rate of an SLO's error budget. Both the window and a short window
+	// of a twelfth of it have to burn that fast (the lower of the two is the value), so the rule resolves quickly
+	// once the burn stops.
+	AlertRuleTypeSloBurnRate = "slo_burn_rate"
+)
+
+const (
+	AlertComparisonGreaterThan        = "gt"
+	AlertComparisonGreaterThanOrEqual = "gte"
+	AlertComparisonLessThan           = "lt"
+	AlertComparisonLessThanOrEqual    = "lte"
+)
+
+const (
+	AlertStateOk      = "OK"
+	AlertStatePending = "PENDING"
+	AlertStateFiring  = "FIRING"
+)
+
+// IsValidAlertRuleType reports whether the rule type can be evaluated
+func IsValidAlertRuleType(ruleType string) bool {
+	switch ruleType {
+	case AlertRuleTypeMetric, AlertRuleTypeEndpointP95, AlertRuleTypeEndpointErrorRate, AlertRuleTypeExceptionCount, AlertRuleTypeNewIssueCount,
+		AlertRuleTypeAnomaly, AlertRuleTypeSloBurnRate:
+		return true
+	}
+	return false
+}
+
+// IsValidAlertComparison reports whether the comparison is supported
+func IsValidAlertComparison(comparison string) bool {
+	switch comparison {
+	case AlertComparisonGreaterThan, AlertComparisonGreaterThanOrEqual, AlertComparisonLessThan, AlertComparisonLessThanOrEqual:
+		return true
+	}
+	return false
+}
+
+// AlertRule is a condition evaluated periodically over a sliding window of the project's data
+type AlertRule struct {
+	Id        uuid.UUID `json:"id" ch:"id"`
+	ProjectId uuid.UUID `json:"projectId" ch:"project_id"`
+	Name      string    `json:"name" ch:"name"`
+	Type      string    `json:"type" ch:"rule_type"`
+	// MetricName, Aggregation and MetricFilter are set for metric rules
+	MetricName   string             `json:"metricName,omitempty" ch:"metric_name"`
+	Aggregation  string             `json:"aggregation,omitempty" ch:"aggregation"`
+	MetricFilter MetricSeriesFilter `json:"metricFilter" ch:"metric_filter"`
+	// Endpoint narrows endpoint and anomaly rules to one endpoint (or task), all of them when empty
+	Endpoint string `json:"endpoint,omitempty" ch:"endpoint"`
+	// OperationType and AnomalyMetric are set for anomaly rules
+	OperationType string `json:"operationType,omitempty" ch:"operation_type"`
+	AnomalyMetric string `json:"anomalyMetric,omitempty" ch:"anomaly_metric"`
+	// SloId is set for SLO burn rate rules
+	SloId      uuid.UUID `json:"sloId,omitempty" ch:"slo_id"`
+	Comparison string    `json:"comparison" ch:"comparison"`
+	Threshold  float64   `json:"threshold" ch:"threshold"`
+	// RecoveryThreshold is the value a firing rule has to get back past before it resolves (hysteresis),
+	// the threshold itself when not set
+	RecoveryThreshold *float64 `json:"recoveryThreshold" ch:"recovery_threshold"`
+	WindowMinutes     int      `json:"windowMinutes" ch:"window_minutes"`
+	// ForMinutes is how long the condition has to hold while PENDING before the rule fires
+	ForMinutes int       `json:"forMinutes" ch:"for_minutes"`
+	Enabled    bool      `json:"enabled" ch:"enabled"`
+	CreatedAt  time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// AlertRuleState is the outcome of the last evaluation of a rule
+type AlertRuleState struct {
+	RuleId          uuid.UUID  `json:"ruleId" ch:"rule_id"`
+	ProjectId       uuid.UUID  `json:"projectId" ch:"project_id"`
+	State           string     `json:"state" ch:"state"`
+	Value           float64    `json:"value" ch:"value"`
+	PendingSince    *time.Time `json:"pendingSince" ch:"pending_since"`
+	StateChangedAt  time.Time  `json:"stateChangedAt" ch:"state_changed_at"`
+	LastEvaluatedAt time.Time  `json:"lastEvaluatedAt" ch:"last_evaluated_at"`
+}
+
+// AlertEvent records a state transition of a rule
+type AlertEvent struct {
+	Id        uuid.UUID `json:"id" ch:"id"`
+	RuleId    uuid.UUID `json:"ruleId" ch:"rule_id"`
+	ProjectId uuid.UUID `json:"projectId" ch:"project_id"`
+	RuleName  string    `json:"ruleName" ch:"rule_name"`
+	FromState string    `json:"fromState" ch:"from_state"`
+	ToState   string    `json:"toState" ch:"to_state"`
+	Value     float64   `json:"value" ch:"value"`
+	Threshold float64   `json:"threshold" ch:"threshold"`
+	CreatedAt time.Time `json:"createdAt" ch:"created_at"`
+}
+
+// Breaches reports whether the value crosses the threshold in the rule's comparison direction
+func (r *AlertRule) Breaches(value float64) bool {
+	return compareAlertValue(r.Comparison, value, r.Threshold)
+}
+
+// Recovered reports whether the value is back past the recovery threshold, so a firing rule can resolve
+func (r *AlertRule) Recovered(value float64) bool {
+	recovery := r.Threshold
+	if r.RecoveryThreshold != nil {
+		recovery = *r.RecoveryThreshold
+	}
+	return !compareAlertValue(r.Comparison, value, recovery)
+}
+
+func compareAlertValue(comparison string, value, threshold float64) bool {
+	switch comparison {
+	case AlertComparisonGreaterThan:
+		return value > threshold
+	case AlertComparisonGreaterThanOrEqual:
+		return value >= threshold
+	case AlertComparisonLessThan:
+		return value < threshold
+	case AlertComparisonLessThanOrEqual:
+		return value <= threshold
+	}
+	return false
+}
+
+// NextState returns the state after an evaluation that produced value. OK moves to PENDING when the condition
+// holds (straight to FIRING when ForMinutes is 0), PENDING fires once the condition held for ForMinutes and falls
+// back to OK as soon as it doesn't, FIRING only resolves once the value is past the recovery threshold.
+func (r *AlertRule) NextState(current AlertRuleState, value float64, now time.Time) AlertRuleState {
+	next := current
+	next.RuleId = r.Id
+	next.ProjectId = r.ProjectId
+	next.Value = value
+	next.LastEvaluatedAt = now
+	if next.State == "" {
+		next.State = AlertStateOk
+		next.StateChangedAt = now
+	}
+
+	transition := func(state string) {
+		next.State = state
+		next.StateChangedAt = now
+	}
+
+	switch next.State {
+	case AlertStateOk:
+		if r.Breaches(value) {
+			if r.ForMinutes == 0 {
+				transition(AlertStateFiring)
+			} else {
+				pendingSince := now
+				next.PendingSince = &pendingSince
+				transition(AlertStatePending)
+			}
+		}
+	case AlertStatePending:
+		if !r.Breaches(value) {
+			next.PendingSince = nil
+			transition(AlertStateOk)
+		} else if next.PendingSince == nil || now.Sub(*next.PendingSince) >= time.Duration(r.ForMinutes)*time.Minute {
+			next.PendingSince = nil
+			transition(AlertStateFiring)
+		}
+	case AlertStateFiring:
+		if r.Recovered(value) {
+			transition(AlertStateOk)
+		}
+	}
+
+	return next
+}
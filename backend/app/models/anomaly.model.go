@@ -0,0 +1,259 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	OperationTypeEndpoint = "endpoint"
+	OperationTypeTask     = "task"
+)
+
+const (
+	// AnomalyMetricP95 is the p95 duration in ms
+	AnomalyMetricP95 = "p95"
+	// AnomalyMetricThroughput is the number of requests (or task runs) per minute
+	AnomalyMetricThroughput = "throughput"
+	// AnomalyMetricErrorRate is the percentage of requests with a status code >= 400, endpoints only
+	AnomalyMetricErrorRate = "error_rate"
+)
+
+const (
+	// HoursPerWeek is the number of seasonal slots of a baseline, one per hour of the week
+	HoursPerWeek = 7 * 24
+	// MinAnomalyBaselineSamples is the number of past hours a slot needs before anything is compared against it
+	MinAnomalyBaselineSamples = 3
+	// MinAnomalySampleRequests is the number of requests an hour needs for its p95 and error rate to be meaningful
+	MinAnomalySampleRequests = 10
+	// DefaultAnomalyThreshold is the robust z-score above which an hour is flagged
+	DefaultAnomalyThreshold = 3.5
+)
+
+// madScale turns the median absolute deviation into an estimate of the standard deviation of normal data
+const madScale = 1.4826
+
+// minAnomalyDeviation keeps near-constant series from flagging tiny changes, per metric
+var minAnomalyDeviation = map[string]float64{
+	AnomalyMetricP95:        5,   // ms
+	AnomalyMetricThroughput: 0.5, // per minute
+	AnomalyMetricErrorRate:  1,   // percentage points
+}
+
+// AnomalyMetrics returns the metrics anomalies are detected on for the operation type
+func AnomalyMetrics(operationType string) []string {
+	if operationType == OperationTypeTask {
+		return []string{AnomalyMetricP95, AnomalyMetricThroughput}
+	}
+	return []string{AnomalyMetricP95, AnomalyMetricThroughput, AnomalyMetricErrorRate}
+}
+
+// IsValidOperationType reports whether the operation type is an endpoint or a task
+func IsValidOperationType(operationType string) bool {
+	return operationType == OperationTypeEndpoint || operationType == OperationTypeTask
+}
+
+// IsValidAnomalyMetric reports whether anomalies are detected on the metric for the operation type
+func IsValidAnomalyMetric(operationType, metric string) bool {
+	for _, m := range AnomalyMetrics(operationType) {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// HourOfWeek returns the seasonal slot of t, 0 being Sunday 00:00-01:00 UTC
+func HourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// OperationStats are the requests of one endpoint (or runs of one task) in a time bucket
+type OperationStats struct {
+	Operation string    `json:"operation"`
+	Time      time.Time `json:"time"`
+	Count     uint64    `json:"count"`
+	P95       float64   `json:"p95"` // in ms
+	Errors    uint64    `json:"errors"`
+}
+
+// Value returns the metric over a bucket of the given length, false when the bucket has too few requests for it
+func (s *OperationStats) Value(metric string, bucket time.Duration) (float64, bool) {
+	switch metric {
+	case AnomalyMetricThroughput:
+		return float64(s.Count) / bucket.Minutes(), true
+	case AnomalyMetricP95:
+		return s.P95, s.Count >= MinAnomalySampleRequests
+	case AnomalyMetricErrorRate:
+		return float64(s.Errors) * 100 / float64(s.Count), s.Count >= MinAnomalySampleRequests
+	}
+	return 0, false
+}
+
+// AnomalyBaselineSlot is the expected value of a metric in one hour of the week
+type AnomalyBaselineSlot struct {
+	HourOfWeek int     `json:"hourOfWeek"`
+	Samples    int     `json:"samples"`
+	Median     float64 `json:"median"`
+	// Deviation is the scaled median absolute deviation, floored so that flat series don't flag every wiggle
+	Deviation float64 `json:"deviation"`
+}
+
+// Score returns how many deviations the value is above (positive) or below (negative) the median
+func (s *AnomalyBaselineSlot) Score(value float64) float64 {
+	return (value - s.Median) / s.Deviation
+}
+
+// OperationBaseline is the seasonal model of an endpoint or task, the slots of each metric are indexed by HourOfWeek
+type OperationBaseline struct {
+	OperationType string                           `json:"operationType"`
+	Operation     string                           `json:"operation"`
+	Metrics       map[string][]AnomalyBaselineSlot `json:"metrics"`
+}
+
+// Slot returns the baseline of the metric at t, false when there aren't enough samples for that hour of the week
+func (b *OperationBaseline) Slot(metric string, t time.Time) (*AnomalyBaselineSlot, bool) {
+	slots := b.Metrics[metric]
+	if len(slots) != HoursPerWeek {
+		return nil, false
+	}
+	slot := &slots[HourOfWeek(t)]
+	return slot, slot.Samples >= MinAnomalyBaselineSamples
+}
+
+// Anomaly is an hour where a metric of an endpoint or task deviated from its baseline
+type Anomaly struct {
+	OperationType string    `json:"operationType"`
+	Operation     string    `json:"operation"`
+	Metric        string    `json:"metric"`
+	Time          time.Time `json:"time"`
+	Value         float64   `json:"value"`
+	Expected      float64   `json:"expected"`
+	Deviation     float64   `json:"deviation"`
+	Score         float64   `json:"score"`
+}
+
+// BuildOperationBaselines builds the baseline of every operation from its hourly stats between start and end.
+// Hours without requests after an operation was first seen count as zero throughput.
+func BuildOperationBaselines(operationType string, hourly []OperationStats, start, end time.Time) map[string]*OperationBaseline {
+	byOperation := make(map[string]map[time.Time]*OperationStats)
+	firstSeen := make(map[string]time.Time)
+	for i := range hourly {
+		s := &hourly[i]
+		if byOperation[s.Operation] == nil {
+			byOperation[s.Operation] = make(map[time.Time]*OperationStats)
+		}
+		byOperation[s.Operation][s.Time.UTC()] = s
+		if first, ok := firstSeen[s.Operation]; !ok || s.Time.Before(first) {
+			firstSeen[s.Operation] = s.Time
+		}
+	}
+
+	metrics := AnomalyMetrics(operationType)
+	baselines := make(map[string]*OperationBaseline, len(byOperation))
+	for operation, stats := range byOperation {
+		samples := make(map[string][][]float64, len(metrics))
+		for _, metric := range metrics {
+			samples[metric] = make([][]float64, HoursPerWeek)
+		}
+
+		for hour := firstSeen[operation].UTC().Truncate(time.Hour); hour.Before(end); hour = hour.Add(time.Hour) {
+			if hour.Before(start) {
+				continue
+			}
+			slot := HourOfWeek(hour)
+			s, ok := stats[hour]
+			if !ok {
+				samples[AnomalyMetricThroughput][slot] = append(samples[AnomalyMetricThroughput][slot], 0)
+				continue
+			}
+			for _, metric := range metrics {
+				if value, ok := s.Value(metric, time.Hour); ok {
+					samples[metric][slot] = append(samples[metric][slot], value)
+				}
+			}
+		}
+
+		baseline := &OperationBaseline{
+			OperationType: operationType,
+			Operation:     operation,
+			Metrics:       make(map[string][]AnomalyBaselineSlot, len(metrics)),
+		}
+		for _, metric := range metrics {
+			slots := make([]AnomalyBaselineSlot, HoursPerWeek)
+			for slot, values := range samples[metric] {
+				slots[slot] = newAnomalyBaselineSlot(slot, values, minAnomalyDeviation[metric])
+			}
+			baseline.Metrics[metric] = slots
+		}
+		baselines[operation] = baseline
+	}
+	return baselines
+}
+
+func newAnomalyBaselineSlot(hourOfWeek int, values []float64, minDeviation float64) AnomalyBaselineSlot {
+	slot := AnomalyBaselineSlot{HourOfWeek: hourOfWeek, Samples: len(values)}
+	if len(values) == 0 {
+		return slot
+	}
+
+	slot.Median = median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - slot.Median)
+	}
+	slot.Deviation = math.Max(madScale*median(deviations), math.Max(0.1*math.Abs(slot.Median), minDeviation))
+	return slot
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// DetectAnomalies compares every hour between start and end of the operation against its baseline and returns the
+// hours whose score is at least threshold in either direction. Hours without requests count as zero throughput.
+func DetectAnomalies(baseline *OperationBaseline, hourly []OperationStats, start, end time.Time, threshold float64) []Anomaly {
+	stats := make(map[time.Time]*OperationStats, len(hourly))
+	for i := range hourly {
+		stats[hourly[i].Time.UTC()] = &hourly[i]
+	}
+
+	anomalies := []Anomaly{}
+	for hour := start.UTC().Truncate(time.Hour); hour.Before(end); hour = hour.Add(time.Hour) {
+		s, ok := stats[hour]
+		if !ok {
+			s = &OperationStats{Operation: baseline.Operation, Time: hour}
+		}
+		for _, metric := range AnomalyMetrics(baseline.OperationType) {
+			value, ok := s.Value(metric, time.Hour)
+			if !ok {
+				continue
+			}
+			slot, ok := baseline.Slot(metric, hour)
+			if !ok {
+				continue
+			}
+			if score := slot.Score(value); math.Abs(score) >= threshold {
+				anomalies = append(anomalies, Anomaly{
+					OperationType: baseline.OperationType,
+					Operation:     baseline.Operation,
+					Metric:        metric,
+					Time:          hour,
+					Value:         value,
+					Expected:      slot.Median,
+					Deviation:     slot.Deviation,
+					Score:         score,
+				})
+			}
+		}
+	}
+	return anomalies
+}
@@ -2,6 +2,8 @@ package clientmodels
 
 import (
 	"backend/app/models"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -46,15 +48,73 @@ type ClientMetricRecord struct {
 	Name       string    `json:"name"`
 	Value      float64   `json:"value"`
 	RecordedAt time.Time `json:"recordedAt"`
+	// Type is "gauge", "counter" or "histogram", older clients don't send it
+	Type string `json:"type"`
+	// Buckets are the histogram bucket upper bounds (ascending) and Counts the observations per bucket,
+	// with one extra trailing count for observations above the last bound
+	Buckets []float64 `json:"buckets"`
+	Counts  []uint64  `json:"counts"`
+	// Tags are optional labels such as queue=emails or region=eu
+	Tags map[string]string `json:"tags"`
+}
+
+// MetricType returns the reported type, falling back to the default type for the metric name
+func (c *ClientMetricRecord) MetricType() string {
+	if models.IsValidMetricType(c.Type) {
+		return c.Type
+	}
+	return models.DefaultMetricType(c.Name)
+}
+
+// IsValid reports whether the record can be stored, histograms need matching ascending buckets and counts
+func (c *ClientMetricRecord) IsValid() bool {
+	if c.MetricType() != models.MetricTypeHistogram {
+		return true
+	}
+	if len(c.Buckets) == 0 || len(c.Counts) != len(c.Buckets)+1 {
+		return false
+	}
+	for i := 1; i < len(c.Buckets); i++ {
+		if c.Buckets[i] <= c.Buckets[i-1] {
+			return false
+		}
+	}
+	return true
 }
 
 func (c *ClientMetricRecord) ToMetricRecord(serverName string) models.MetricRecord {
-	return models.MetricRecord{
+	mr := models.MetricRecord{
 		Name:       c.Name,
 		Value:      c.Value,
 		RecordedAt: c.RecordedAt,
 		ServerName: serverName,
+		Type:       c.MetricType(),
 	}
+	if mr.Type == models.MetricTypeHistogram {
+		mr.BucketBounds = c.Buckets
+		mr.BucketCounts = c.Counts
+	}
+	mr.Tags = c.ValidTags()
+	return mr
+}
+
+// ValidTags returns the tags with invalid keys and empty values dropped and long values truncated.
+// The project's key and value limits are enforced separately at ingest.
+func (c *ClientMetricRecord) ValidTags() map[string]string {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(c.Tags))
+	for key, value := range c.Tags {
+		if !models.IsValidMetricTagKey(key) || value == "" {
+			continue
+		}
+		if len(value) > models.MaxMetricTagValueLength {
+			value = strings.ToValidUTF8(value[:models.MaxMetricTagValueLength], "")
+		}
+		tags[key] = value
+	}
+	return tags
 }
 
 type ClientTransaction struct {
@@ -68,6 +128,10 @@ type ClientTransaction struct {
 	Scope      map[string]string `json:"scope"`
 	Segments   []*ClientSegment  `json:"segments"`
 	IsTask     bool              `json:"isTask"`
+	// TraceParent is the W3C traceparent value the SDK received with the incoming request (if any)
+	TraceParent  string `json:"traceparent"`
+	TraceId      string `json:"traceId"`
+	ParentSpanId string `json:"parentSpanId"`
 }
 
 // ParsedId returns the transaction ID as uuid.UUID
@@ -78,7 +142,53 @@ func (c *ClientTransaction) ParsedId() uuid.UUID {
 	return uuid.New()
 }
 
+var (
+	traceIdRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	spanIdRe  = regexp.MustCompile(`^[0-9a-f]{16}$`)
+)
+
+// ParseTraceParent parses a W3C traceparent value (version-traceid-parentid-flags)
+// and returns false if it is malformed or uses the all-zero trace/parent ids
+func ParseTraceParent(traceParent string) (traceId string, parentSpanId string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(strings.ToLower(traceParent)), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	// version 00 must have exactly 4 parts, future versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	if !traceIdRe.MatchString(parts[1]) || parts[1] == strings.Repeat("0", 32) {
+		return "", "", false
+	}
+	if !spanIdRe.MatchString(parts[2]) || parts[2] == strings.Repeat("0", 16) {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// TraceContext returns the trace id and parent span id for the transaction.
+// A valid traceparent takes precedence over the explicit fields, and transactions
+// without any incoming context start a new trace keyed by their own id.
+func (c *ClientTransaction) TraceContext() (traceId string, parentSpanId string) {
+	if traceId, parentSpanId, ok := ParseTraceParent(c.TraceParent); ok {
+		return traceId, parentSpanId
+	}
+
+	traceId = strings.ToLower(c.TraceId)
+	if !traceIdRe.MatchString(traceId) {
+		return models.TraceIdFromUUID(c.ParsedId()), ""
+	}
+
+	parentSpanId = strings.ToLower(c.ParentSpanId)
+	if !spanIdRe.MatchString(parentSpanId) {
+		parentSpanId = ""
+	}
+	return traceId, parentSpanId
+}
+
 func (c *ClientTransaction) ToEndpoint(appVersion, serverName string) models.Endpoint {
+	traceId, parentSpanId := c.TraceContext()
 	return models.Endpoint{
 		Id:         c.ParsedId(),
 		Endpoint:   c.Endpoint,
@@ -90,10 +200,14 @@ func (c *ClientTransaction) ToEndpoint(appVersion, serverName string) models.End
 		Scope:      c.Scope,
 		AppVersion: appVersion,
 		ServerName: serverName,
+
+		TraceId:      traceId,
+		ParentSpanId: parentSpanId,
 	}
 }
 
 func (c *ClientTransaction) ToTask(appVersion, serverName string) models.Task {
+	traceId, parentSpanId := c.TraceContext()
 	return models.Task{
 		Id:         c.ParsedId(),
 		TaskName:   c.Endpoint, // Endpoint field is used as task name
@@ -103,14 +217,19 @@ func (c *ClientTransaction) ToTask(appVersion, serverName string) models.Task {
 		Scope:      c.Scope,
 		AppVersion: appVersion,
 		ServerName: serverName,
+
+		TraceId:      traceId,
+		ParentSpanId: parentSpanId,
 	}
 }
 
 type ClientSegment struct {
-	Id        string        `json:"id"`
-	Name      string        `json:"name"`
-	StartTime time.Time     `json:"startTime"`
-	Duration  time.Duration `json:"duration"`
+	Id         string            `json:"id"`
+	ParentId   *string           `json:"parentId"`
+	Name       string            `json:"name"`
+	StartTime  time.Time         `json:"startTime"`
+	Duration   time.Duration     `json:"duration"`
+	Attributes map[string]string `json:"attributes"`
 }
 
 // ParsedId returns the segment ID as uuid.UUID
@@ -121,14 +240,37 @@ func (c *ClientSegment) ParsedId() uuid.UUID {
 	return uuid.New()
 }
 
-func (c *ClientSegment) ToSegment(transactionId uuid.UUID) models.Segment {
+// ParsedParentId returns the parent segment ID, or nil for segments directly under the transaction
+func (c *ClientSegment) ParsedParentId() *uuid.UUID {
+	if c.ParentId == nil {
+		return nil
+	}
+	if parsed, err := uuid.Parse(*c.ParentId); err == nil {
+		return &parsed
+	}
+	return nil
+}
+
+// ToSegment converts the client segment, linking it to its transaction's trace.
+// The segment's parent span is its parent segment, or the transaction itself for top level segments.
+func (c *ClientSegment) ToSegment(transactionId uuid.UUID, traceId string) models.Segment {
+	parentId := c.ParsedParentId()
+	parentSpanId := models.SpanIdFromUUID(transactionId)
+	if parentId != nil {
+		parentSpanId = models.SpanIdFromUUID(*parentId)
+	}
+
 	return models.Segment{
 		Id:            c.ParsedId(),
 		TransactionId: transactionId,
+		ParentId:      parentId,
 		Name:          c.Name,
 		StartTime:     c.StartTime,
 		Duration:      c.Duration,
 		RecordedAt:    time.Now(),
+		TraceId:       traceId,
+		ParentSpanId:  parentSpanId,
+		Attributes:    c.Attributes,
 	}
 }
 
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds the steps Next and Prev take, enough to cross several years of a sparse schedule
+const cronSearchLimit = 100000
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 7 is accepted as Sunday too
+}
+
+// CronSchedule is a parsed standard 5 field cron expression (minute, hour, day of month, month, day of week)
+// supporting *, lists, ranges, steps and the @hourly style macros. Like cron, when both the day of month and
+// the day of week are restricted a day matching either one matches, and like vixie cron a field starting with *
+// (e.g. */2) isn't restricted.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+	location                      *time.Location
+}
+
+// ParseCronSchedule parses the expression, evaluated in the given time zone
+func ParseCronSchedule(expression string, location *time.Location) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, errors.New("cron expression must have 5 fields: minute hour day-of-month month day-of-week")
+	}
+
+	bits := make([]uint64, 5)
+	for i, field := range fields {
+		max := cronFields[i].max
+		if i == 4 {
+			max = 7
+		}
+		b, err := parseCronField(field, cronFields[i].min, max)
+		if err != nil {
+			return nil, fmt.Errorf("field %d (%q): %w", i+1, field, err)
+		}
+		bits[i] = b
+	}
+	// 7 is Sunday as well
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+	return &CronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: isCronFieldRestricted(fields[2]),
+		dowRestricted: isCronFieldRestricted(fields[4]),
+		location:      location,
+	}, nil
+}
+
+// isCronFieldRestricted reports whether the day field restricts the days, fields starting with * or ? don't
+func isCronFieldRestricted(field string) bool {
+	return !strings.HasPrefix(field, "*") && !strings.HasPrefix(field, "?")
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s < 1 {
+				return 0, errors.New("invalid step")
+			}
+			step = s
+			part = part[:idx]
+		}
+
+		start, end := min, max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, errors.New("invalid range")
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, errors.New("invalid value")
+			}
+			start = value
+			end = value
+			// "5/15" means every 15 starting at 5
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("values must be between %d and %d", min, max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first scheduled time after t, the zero time when there is none (e.g. "0 0 30 2 *")
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < cronSearchLimit; i++ {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.location).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Prev returns the last scheduled time at or before t, the zero time when there is none
+func (s *CronSchedule) Prev(t time.Time) time.Time {
+	t = t.In(s.location).Truncate(time.Minute)
+	for i := 0; i < cronSearchLimit; i++ {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.location).Add(-time.Minute)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location).Add(-time.Minute)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.location).Add(-time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
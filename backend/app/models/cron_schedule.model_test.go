@@ -0,0 +1,131 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func cronTime(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string // empty when there is no next time
+	}{
+		{"every minute", "* * * * *", "2024-01-01 10:07", "2024-01-01 10:08"},
+		{"step", "*/15 * * * *", "2024-01-01 10:07", "2024-01-01 10:15"},
+		{"step from the exact time", "*/15 * * * *", "2024-01-01 10:15", "2024-01-01 10:30"},
+		{"step with a start", "5/15 * * * *", "2024-01-01 10:50", "2024-01-01 11:05"},
+		{"list", "0 6,18 * * *", "2024-01-01 07:00", "2024-01-01 18:00"},
+		{"range", "30 8 * * 1-5", "2024-01-05 09:00", "2024-01-08 08:30"},
+		{"range with a step", "0 0 1-10/3 * *", "2024-01-02 00:00", "2024-01-04 00:00"},
+		{"range with a step past its end", "0 0 1-10/3 * *", "2024-01-10 00:00", "2024-02-01 00:00"},
+		{"7 is Sunday", "0 9 * * 7", "2024-01-01 00:00", "2024-01-07 09:00"},
+		{"0 is Sunday", "0 9 * * 0", "2024-01-01 00:00", "2024-01-07 09:00"},
+		{"February 29", "0 0 29 2 *", "2023-03-01 00:00", "2024-02-29 00:00"},
+		{"end of the year", "0 0 1 1 *", "2024-06-15 12:00", "2025-01-01 00:00"},
+		{"day of month or day of week", "0 0 13 * 5", "2024-01-01 00:00", "2024-01-05 00:00"},
+		{"stepped day of month isn't restricted", "0 0 */2 * 1", "2024-01-01 00:00", "2024-01-15 00:00"},
+		{"stepped day of week isn't restricted", "0 0 13 * */2", "2024-01-01 00:00", "2024-01-13 00:00"},
+		{"macro", "@hourly", "2024-01-01 10:30", "2024-01-01 11:00"},
+		{"never", "0 0 30 2 *", "2024-01-01 00:00", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expression, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseCronSchedule(%q) returned %v", tt.expression, err)
+			}
+			var want time.Time
+			if tt.want != "" {
+				want = cronTime(tt.want)
+			}
+			if got := schedule.Next(cronTime(tt.from)); !got.Equal(want) {
+				t.Errorf("Next(%s) = %v, want %v", tt.from, got, want)
+			}
+		})
+	}
+}
+
+func TestCronSchedulePrev(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string
+	}{
+		{"at the exact time", "*/15 * * * *", "2024-01-01 10:15", "2024-01-01 10:15"},
+		{"step", "*/15 * * * *", "2024-01-01 10:14", "2024-01-01 10:00"},
+		{"range", "30 8 * * 1-5", "2024-01-08 08:00", "2024-01-05 08:30"},
+		{"7 is Sunday", "0 9 * * 7", "2024-01-07 08:00", "2023-12-31 09:00"},
+		{"February 29", "0 0 29 2 *", "2024-02-28 00:00", "2020-02-29 00:00"},
+		{"stepped day of month isn't restricted", "0 0 */2 * 1", "2024-01-14 00:00", "2024-01-01 00:00"},
+		{"never", "0 0 31 4 *", "2024-01-01 00:00", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expression, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseCronSchedule(%q) returned %v", tt.expression, err)
+			}
+			var want time.Time
+			if tt.want != "" {
+				want = cronTime(tt.want)
+			}
+			if got := schedule.Prev(cronTime(tt.from)); !got.Equal(want) {
+				t.Errorf("Prev(%s) = %v, want %v", tt.from, got, want)
+			}
+		})
+	}
+}
+
+func TestCronScheduleLocation(t *testing.T) {
+	location := time.FixedZone("UTC-5", -5*60*60)
+	schedule, err := ParseCronSchedule("0 9 * * *", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cronTime("2024-01-02 14:00")
+	if got := schedule.Next(cronTime("2024-01-01 15:00")); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestParseCronScheduleInvalid(t *testing.T) {
+	expressions := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@never",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"5-1 * * * *",
+		"1- * * * *",
+		"1-x * * * *",
+		"1,,2 * * * *",
+	}
+
+	for _, expression := range expressions {
+		if _, err := ParseCronSchedule(expression, time.UTC); err == nil {
+			t.Errorf("ParseCronSchedule(%q) returned no error", expression)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WidgetTypeMetric charts any metric through the generic metric query
+	WidgetTypeMetric = "metric"
+	// WidgetTypeEndpointLatency charts avg and p95 response time, of one endpoint or all of them
+	WidgetTypeEndpointLatency = "endpoint_latency"
+	// WidgetTypeErrorRate charts the percentage of requests with a status code >= 400
+	WidgetTypeErrorRate = "error_rate"
+	// WidgetTypeTopIssues lists the most frequent unarchived issues
+	WidgetTypeTopIssues = "top_issues"
+	// WidgetTypeUptimeAvailability charts the percentage of successful runs of an uptime check
+	WidgetTypeUptimeAvailability = "uptime_availability"
+	// WidgetTypeUptimeResponseTime charts the avg and p95 response time of an uptime check
+	WidgetTypeUptimeResponseTime = "uptime_response_time"
+)
+
+// DashboardTimeRanges are the default time ranges a custom dashboard can open with
+var DashboardTimeRanges = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// WidgetLayout positions a widget on the dashboard grid
+type WidgetLayout struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// WidgetMetricQuery is the metric query of a metric widget, see the /metrics/query API
+type WidgetMetricQuery struct {
+	Name            string `json:"name"`
+	Aggregation     string `json:"aggregation"`
+	IntervalMinutes int    `json:"intervalMinutes"`
+	MetricSeriesFilter
+}
+
+type DashboardWidget struct {
+	Id     string       `json:"id"`
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Layout WidgetLayout `json:"layout"`
+	// Query is set for metric widgets
+	Query *WidgetMetricQuery `json:"query,omitempty"`
+	// Endpoint narrows endpoint_latency and error_rate widgets to one endpoint, all endpoints when empty
+	Endpoint string `json:"endpoint,omitempty"`
+	// Limit is the number of issues of a top_issues widget
+	Limit int `json:"limit,omitempty"`
+	// CheckId is the uptime check of uptime widgets
+	CheckId *uuid.UUID `json:"checkId,omitempty"`
+}
+
+// CustomDashboard is a named, user-defined set of widgets of a project
+type CustomDashboard struct {
+	Id               uuid.UUID         `json:"id" ch:"id"`
+	ProjectId        uuid.UUID         `json:"projectId" ch:"project_id"`
+	Name             string            `json:"name" ch:"name"`
+	Description      string            `json:"description" ch:"description"`
+	DefaultTimeRange string            `json:"defaultTimeRange" ch:"default_time_range"`
+	Widgets          []DashboardWidget `json:"widgets" ch:"widgets"`
+	CreatedAt        time.Time         `json:"createdAt" ch:"created_at"`
+	UpdatedAt        time.Time         `json:"updatedAt" ch:"updated_at"`
+}
@@ -19,6 +19,9 @@ type Endpoint struct {
 	Scope      map[string]string `json:"scope" ch:"scope"`
 	AppVersion string            `json:"appVersion" ch:"app_version"`
 	ServerName string            `json:"serverName" ch:"server_name"`
+	// TraceId and ParentSpanId link this request to the distributed trace it belongs to
+	TraceId      string `json:"traceId" ch:"trace_id"`
+	ParentSpanId string `json:"parentSpanId" ch:"parent_span_id"`
 }
 
 type EndpointStats struct {
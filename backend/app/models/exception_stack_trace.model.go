@@ -20,6 +20,17 @@ type ExceptionStackTrace struct {
 	IsMessage       bool              `json:"isMessage" ch:"is_message"`
 }
 
+// IssueHistory is what was known about an exception hash before a new occurrence was stored
+type IssueHistory struct {
+	Seen  bool
+	Issue *Issue // nil when the hash has no issue yet
+}
+
+// IssueUrl returns the link to an issue in the UI
+func IssueUrl(exceptionHash string) string {
+	return getBackendUrl() + "/issues/" + exceptionHash
+}
+
 type ExceptionTrendPoint struct {
 	Timestamp time.Time `json:"timestamp"`
 	Count     uint64    `json:"count"`
@@ -32,4 +43,48 @@ type ExceptionGroup struct {
 	FirstSeen     time.Time             `json:"firstSeen" ch:"first_seen"`
 	Count         uint64                `json:"count" ch:"count"`
 	HourlyTrend   []ExceptionTrendPoint `json:"hourlyTrend,omitempty"`
+	// Status, Assignee and Priority come from the group's issue
+	Status   string `json:"status" ch:"status"`
+	Assignee string `json:"assignee" ch:"assignee"`
+	Priority string `json:"priority" ch:"priority"`
+	// Highlights are the excerpts of the stack trace matching the search, when searching
+	Highlights []SearchSnippet `json:"highlights,omitempty"`
+}
+
+const (
+	// FacetSourceField facets break occurrences down by a column: server_name, app_version or transaction_type
+	FacetSourceField = "field"
+	// FacetSourceScope facets break occurrences down by a key of their scope
+	FacetSourceScope = "scope"
+)
+
+// FacetValue is a value of a facet with the occurrences that have it
+type FacetValue struct {
+	Value string `json:"value"`
+	Count uint64 `json:"count"`
+	// Percentage is the share of all occurrences in the range, 0 to 100
+	Percentage float64 `json:"percentage"`
+}
+
+// Facet is the breakdown of the occurrences of an exception group by a column or scope key, top values first
+type Facet struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+	// Count is the number of occurrences that have the key, every occurrence for fields
+	Count  uint64       `json:"count"`
+	Values []FacetValue `json:"values"`
+}
+
+// ExceptionFacets are the facets of an exception group over a time range
+type ExceptionFacets struct {
+	Total  uint64  `json:"total"`
+	Facets []Facet `json:"facets"`
+}
+
+// Percentage returns count as a percentage of total, 0 without occurrences
+func (f *ExceptionFacets) Percentage(count uint64) float64 {
+	if f.Total == 0 {
+		return 0
+	}
+	return float64(count) / float64(f.Total) * 100
 }
@@ -0,0 +1,494 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxFilterExpressionLength and maxFilterDepth bound the work of parsing a filter expression
+const (
+	MaxFilterExpressionLength = 2000
+	maxFilterDepth            = 32
+)
+
+type FilterFieldType string
+
+const (
+	FilterFieldString   FilterFieldType = "string"
+	FilterFieldNumber   FilterFieldType = "number"
+	FilterFieldDuration FilterFieldType = "duration"
+	FilterFieldBool     FilterFieldType = "bool"
+)
+
+// FilterField is a column that can be filtered on, scope.<key> fields are available on every list
+type FilterField struct {
+	Column string
+	Type   FilterFieldType
+}
+
+var EndpointFilterFields = map[string]FilterField{
+	"endpoint":    {Column: "endpoint", Type: FilterFieldString},
+	"duration":    {Column: "duration", Type: FilterFieldDuration},
+	"status_code": {Column: "status_code", Type: FilterFieldNumber},
+	"body_size":   {Column: "body_size", Type: FilterFieldNumber},
+	"client_ip":   {Column: "client_ip", Type: FilterFieldString},
+	"app_version": {Column: "app_version", Type: FilterFieldString},
+	"server_name": {Column: "server_name", Type: FilterFieldString},
+	"trace_id":    {Column: "trace_id", Type: FilterFieldString},
+}
+
+var TaskFilterFields = map[string]FilterField{
+	"task_name":   {Column: "task_name", Type: FilterFieldString},
+	"duration":    {Column: "duration", Type: FilterFieldDuration},
+	"client_ip":   {Column: "client_ip", Type: FilterFieldString},
+	"app_version": {Column: "app_version", Type: FilterFieldString},
+	"server_name": {Column: "server_name", Type: FilterFieldString},
+	"trace_id":    {Column: "trace_id", Type: FilterFieldString},
+}
+
+var ExceptionFilterFields = map[string]FilterField{
+	"exception_hash":   {Column: "exception_hash", Type: FilterFieldString},
+	"stack_trace":      {Column: "stack_trace", Type: FilterFieldString},
+	"transaction_type": {Column: "transaction_type", Type: FilterFieldString},
+	"app_version":      {Column: "app_version", Type: FilterFieldString},
+	"server_name":      {Column: "server_name", Type: FilterFieldString},
+	"is_message":       {Column: "is_message", Type: FilterFieldBool},
+}
+
+const (
+	FilterNodeAnd       = "and"
+	FilterNodeOr        = "or"
+	FilterNodeNot       = "not"
+	FilterNodeCondition = "condition"
+)
+
+const (
+	FilterOpEquals      = "="
+	FilterOpNotEquals   = "!="
+	FilterOpGreater     = ">"
+	FilterOpGreaterOrEq = ">="
+	FilterOpLess        = "<"
+	FilterOpLessOrEq    = "<="
+	FilterOpIn          = "IN"
+	FilterOpNotIn       = "NOT IN"
+	FilterOpContains    = "CONTAINS"
+	FilterOpExists      = "EXISTS"
+)
+
+// FilterNode is a parsed filter expression, either a condition or a combination of child nodes
+type FilterNode struct {
+	Kind      string
+	Children  []FilterNode
+	Condition *FilterCondition
+}
+
+// FilterCondition compares a whitelisted column, or the ScopeKey of the scope column, with values already
+// converted to the type of the field: string or int64 (durations in nanoseconds, booleans as 0 and 1)
+type FilterCondition struct {
+	Column   string
+	ScopeKey string
+	Operator string
+	Values   []interface{}
+}
+
+// FilterSyntaxError points at the character of the expression that couldn't be parsed
+type FilterSyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("filter syntax error at position %d: %s", e.Position, e.Message)
+}
+
+// ParseFilterExpression parses expressions such as
+//
+//	status_code >= 500 AND duration > 1s AND server_name IN (a, b) AND scope.tenant = "acme"
+//
+// with AND, OR, NOT and parentheses, the =, !=, >, >=, <, <=, IN, NOT IN and CONTAINS operators, and EXISTS on
+// scope fields. Only the given fields and scope.<key> can be used, an empty expression returns nil.
+func ParseFilterExpression(input string, fields map[string]FilterField) (*FilterNode, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+	if len(input) > MaxFilterExpressionLength {
+		return nil, &FilterSyntaxError{Position: MaxFilterExpressionLength + 1, Message: fmt.Sprintf("the expression is longer than %d characters", MaxFilterExpressionLength)}
+	}
+
+	tokens, err := lexFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens, fields: fields, end: utf8.RuneCountInString(input) + 1}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, p.errorAt(t, "expected AND, OR or the end of the expression, got "+t.describe())
+	}
+	return &node, nil
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenWord filterTokenKind = iota
+	filterTokenString
+	filterTokenOperator
+	filterTokenOpenParen
+	filterTokenCloseParen
+	filterTokenComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+func (t filterToken) describe() string {
+	if t.kind == filterTokenString {
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+func (t filterToken) isKeyword(keyword string) bool {
+	return t.kind == filterTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func isFilterWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(`()",'=!<>`, r)
+}
+
+func lexFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: filterTokenOpenParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: filterTokenCloseParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{kind: filterTokenComma, text: ",", pos: pos})
+			i++
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, &FilterSyntaxError{Position: pos, Message: "unterminated string"}
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenString, text: value.String(), pos: pos})
+			i = j + 1
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || r == '<' && runes[i+1] == '>') {
+				op += string(runes[i+1])
+			}
+			i += len(op)
+			switch op {
+			case "==":
+				op = FilterOpEquals
+			case "<>":
+				op = FilterOpNotEquals
+			case "!":
+				return nil, &FilterSyntaxError{Position: pos, Message: "unexpected '!', did you mean '!='?"}
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenOperator, text: op, pos: pos})
+		default:
+			j := i
+			for j < len(runes) && isFilterWordRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, text: string(runes[i:j]), pos: pos})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	next   int
+	fields map[string]FilterField
+	end    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.next >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.next], true
+}
+
+func (p *filterParser) errorAt(t filterToken, message string) error {
+	return &FilterSyntaxError{Position: t.pos, Message: message}
+}
+
+func (p *filterParser) errorAtEnd(message string) error {
+	return &FilterSyntaxError{Position: p.end, Message: message}
+}
+
+// expect returns the next token, failing with what was expected at the end of the expression
+func (p *filterParser) expect(expected string) (filterToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return filterToken{}, p.errorAtEnd("expected " + expected + ", got the end of the expression")
+	}
+	p.next++
+	return t, nil
+}
+
+func (p *filterParser) parseOr(depth int) (FilterNode, error) {
+	return p.parseBinary(depth, "OR", FilterNodeOr, p.parseAnd)
+}
+
+func (p *filterParser) parseAnd(depth int) (FilterNode, error) {
+	return p.parseBinary(depth, "AND", FilterNodeAnd, p.parseUnary)
+}
+
+func (p *filterParser) parseBinary(depth int, keyword, kind string, operand func(int) (FilterNode, error)) (FilterNode, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return FilterNode{}, err
+	}
+	children := []FilterNode{first}
+	for {
+		t, ok := p.peek()
+		if !ok || !t.isKeyword(keyword) {
+			break
+		}
+		p.next++
+		child, err := operand(depth)
+		if err != nil {
+			return FilterNode{}, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return FilterNode{Kind: kind, Children: children}, nil
+}
+
+func (p *filterParser) parseUnary(depth int) (FilterNode, error) {
+	if depth > maxFilterDepth {
+		t, _ := p.peek()
+		return FilterNode{}, p.errorAt(t, fmt.Sprintf("the expression is nested more than %d levels deep", maxFilterDepth))
+	}
+
+	t, err := p.expect("a condition")
+	if err != nil {
+		return FilterNode{}, err
+	}
+	switch {
+	case t.isKeyword("NOT"):
+		child, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return FilterNode{}, err
+		}
+		return FilterNode{Kind: FilterNodeNot, Children: []FilterNode{child}}, nil
+	case t.kind == filterTokenOpenParen:
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return FilterNode{}, err
+		}
+		closing, err := p.expect("')'")
+		if err != nil {
+			return FilterNode{}, err
+		}
+		if closing.kind != filterTokenCloseParen {
+			return FilterNode{}, p.errorAt(closing, "expected ')', got "+closing.describe())
+		}
+		return node, nil
+	case t.kind == filterTokenWord:
+		return p.parseCondition(t)
+	}
+	return FilterNode{}, p.errorAt(t, "expected a field name, got "+t.describe())
+}
+
+func (p *filterParser) parseCondition(fieldToken filterToken) (FilterNode, error) {
+	condition := FilterCondition{}
+	fieldType := FilterFieldString
+	if key, ok := strings.CutPrefix(fieldToken.text, "scope."); ok {
+		if key == "" {
+			return FilterNode{}, p.errorAt(fieldToken, "scope fields need a key, such as scope.tenant")
+		}
+		condition.Column = "scope"
+		condition.ScopeKey = key
+	} else if field, ok := p.fields[fieldToken.text]; ok {
+		condition.Column = field.Column
+		fieldType = field.Type
+	} else {
+		return FilterNode{}, p.errorAt(fieldToken, "unknown field '"+fieldToken.text+"', expected one of: "+p.fieldNames())
+	}
+
+	opToken, err := p.expect("an operator")
+	if err != nil {
+		return FilterNode{}, err
+	}
+	switch {
+	case opToken.kind == filterTokenOperator:
+		condition.Operator = opToken.text
+	case opToken.isKeyword("IN"):
+		condition.Operator = FilterOpIn
+	case opToken.isKeyword("CONTAINS"):
+		condition.Operator = FilterOpContains
+	case opToken.isKeyword("EXISTS"):
+		condition.Operator = FilterOpExists
+	case opToken.isKeyword("NOT"):
+		inToken, err := p.expect("IN or EXISTS")
+		if err != nil {
+			return FilterNode{}, err
+		}
+		if inToken.isKeyword("IN") {
+			condition.Operator = FilterOpNotIn
+		} else if inToken.isKeyword("EXISTS") && condition.ScopeKey != "" {
+			return FilterNode{Kind: FilterNodeNot, Children: []FilterNode{{Kind: FilterNodeCondition, Condition: &FilterCondition{
+				Column: condition.Column, ScopeKey: condition.ScopeKey, Operator: FilterOpExists}}}}, nil
+		} else {
+			return FilterNode{}, p.errorAt(inToken, "expected IN or EXISTS after NOT, got "+inToken.describe())
+		}
+	default:
+		return FilterNode{}, p.errorAt(opToken, "expected an operator such as =, !=, >, IN or CONTAINS, got "+opToken.describe())
+	}
+
+	if err := checkFilterOperator(condition, fieldType); err != nil {
+		return FilterNode{}, p.errorAt(opToken, err.Error())
+	}
+
+	switch condition.Operator {
+	case FilterOpExists:
+	case FilterOpIn, FilterOpNotIn:
+		if condition.Values, err = p.parseValueList(fieldType); err != nil {
+			return FilterNode{}, err
+		}
+	default:
+		valueToken, err := p.expect("a value")
+		if err != nil {
+			return FilterNode{}, err
+		}
+		value, err := p.parseValue(valueToken, fieldType)
+		if err != nil {
+			return FilterNode{}, err
+		}
+		condition.Values = []interface{}{value}
+	}
+	return FilterNode{Kind: FilterNodeCondition, Condition: &condition}, nil
+}
+
+func checkFilterOperator(condition FilterCondition, fieldType FilterFieldType) error {
+	switch condition.Operator {
+	case FilterOpExists:
+		if condition.ScopeKey == "" {
+			return fmt.Errorf("EXISTS can only be used on scope fields")
+		}
+	case FilterOpContains:
+		if fieldType != FilterFieldString {
+			return fmt.Errorf("CONTAINS can only be used on text fields")
+		}
+	case FilterOpGreater, FilterOpGreaterOrEq, FilterOpLess, FilterOpLessOrEq:
+		if fieldType != FilterFieldNumber && fieldType != FilterFieldDuration {
+			return fmt.Errorf("%s can only be used on numeric and duration fields", condition.Operator)
+		}
+	case FilterOpIn, FilterOpNotIn:
+		if fieldType == FilterFieldBool {
+			return fmt.Errorf("%s can't be used on boolean fields", condition.Operator)
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) parseValueList(fieldType FilterFieldType) ([]interface{}, error) {
+	open, err := p.expect("'('")
+	if err != nil {
+		return nil, err
+	}
+	if open.kind != filterTokenOpenParen {
+		return nil, p.errorAt(open, "expected '(' after IN, got "+open.describe())
+	}
+
+	var values []interface{}
+	for {
+		valueToken, err := p.expect("a value")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(valueToken, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		separator, err := p.expect("',' or ')'")
+		if err != nil {
+			return nil, err
+		}
+		if separator.kind == filterTokenCloseParen {
+			return values, nil
+		}
+		if separator.kind != filterTokenComma {
+			return nil, p.errorAt(separator, "expected ',' or ')', got "+separator.describe())
+		}
+	}
+}
+
+// parseValue converts a value token to the type of the field, durations such as 250ms or 1.5s become nanoseconds
+func (p *filterParser) parseValue(t filterToken, fieldType FilterFieldType) (interface{}, error) {
+	if t.kind != filterTokenWord && t.kind != filterTokenString {
+		return nil, p.errorAt(t, "expected a value, got "+t.describe())
+	}
+
+	switch fieldType {
+	case FilterFieldNumber:
+		value, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, p.errorAt(t, "expected a whole number, got "+t.describe())
+		}
+		return value, nil
+	case FilterFieldDuration:
+		value, err := time.ParseDuration(t.text)
+		if err != nil {
+			return nil, p.errorAt(t, "expected a duration such as 250ms or 1.5s, got "+t.describe())
+		}
+		return int64(value), nil
+	case FilterFieldBool:
+		value, err := strconv.ParseBool(t.text)
+		if err != nil {
+			return nil, p.errorAt(t, "expected true or false, got "+t.describe())
+		}
+		if value {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return t.text, nil
+}
+
+func (p *filterParser) fieldNames() string {
+	names := make([]string, 0, len(p.fields)+1)
+	for name := range p.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(append(names, "scope.<key>"), ", ")
+}
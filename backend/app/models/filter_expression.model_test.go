@@ -0,0 +1,159 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// filterNodeString renders a node as a compact s-expression to compare parsed trees
+func filterNodeString(node FilterNode) string {
+	if node.Kind == FilterNodeCondition {
+		c := node.Condition
+		column := c.Column
+		if c.ScopeKey != "" {
+			column += "." + c.ScopeKey
+		}
+		values := make([]string, len(c.Values))
+		for i, value := range c.Values {
+			values[i] = fmt.Sprintf("%#v", value)
+		}
+		if len(values) == 0 {
+			return "(" + column + " " + c.Operator + ")"
+		}
+		return "(" + column + " " + c.Operator + " " + strings.Join(values, " ") + ")"
+	}
+	children := make([]string, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = filterNodeString(child)
+	}
+	return "(" + node.Kind + " " + strings.Join(children, " ") + ")"
+}
+
+func TestParseFilterExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fields map[string]FilterField
+		want   string
+	}{
+		{"condition", "status_code >= 500", EndpointFilterFields, `(status_code >= 500)`},
+		{"AND binds tighter than OR", "status_code = 1 OR status_code = 2 AND endpoint = a", EndpointFilterFields,
+			`(or (status_code = 1) (and (status_code = 2) (endpoint = "a")))`},
+		{"NOT binds tighter than AND", "NOT status_code = 1 AND endpoint = a", EndpointFilterFields,
+			`(and (not (status_code = 1)) (endpoint = "a"))`},
+		{"parentheses", "(status_code = 1 OR status_code = 2) AND endpoint = a", EndpointFilterFields,
+			`(and (or (status_code = 1) (status_code = 2)) (endpoint = "a"))`},
+		{"chained operands are flattened", "status_code = 1 AND status_code = 2 AND status_code = 3", EndpointFilterFields,
+			`(and (status_code = 1) (status_code = 2) (status_code = 3))`},
+		{"keywords ignore case", "status_code = 1 and not endpoint = a or endpoint in (b)", EndpointFilterFields,
+			`(or (and (status_code = 1) (not (endpoint = "a"))) (endpoint IN "b"))`},
+		{"double quotes", `endpoint = "GET /users 1"`, EndpointFilterFields, `(endpoint = "GET /users 1")`},
+		{"single quotes", `endpoint = 'say "hi"'`, EndpointFilterFields, `(endpoint = "say \"hi\"")`},
+		{"escaped quote", `endpoint = "say \"hi\""`, EndpointFilterFields, `(endpoint = "say \"hi\"")`},
+		{"escaped backslash", `endpoint = "a\\b"`, EndpointFilterFields, `(endpoint = "a\\b")`},
+		{"quoted keyword is a value", `endpoint = "AND"`, EndpointFilterFields, `(endpoint = "AND")`},
+		{"== and <>", "status_code == 1 AND status_code <> 2", EndpointFilterFields, `(and (status_code = 1) (status_code != 2))`},
+		{"duration", "duration > 1.5s", EndpointFilterFields, `(duration > 1500000000)`},
+		{"bool", "is_message = true", ExceptionFilterFields, `(is_message = 1)`},
+		{"IN", `server_name IN (a, "b c")`, EndpointFilterFields, `(server_name IN "a" "b c")`},
+		{"NOT IN", "status_code NOT IN (500, 502)", EndpointFilterFields, `(status_code NOT IN 500 502)`},
+		{"CONTAINS", "stack_trace CONTAINS timeout", ExceptionFilterFields, `(stack_trace CONTAINS "timeout")`},
+		{"scope", `scope.tenant = "acme"`, TaskFilterFields, `(scope.tenant = "acme")`},
+		{"scope EXISTS", "scope.tenant EXISTS", TaskFilterFields, `(scope.tenant EXISTS)`},
+		{"scope NOT EXISTS", "scope.tenant NOT EXISTS", TaskFilterFields, `(not (scope.tenant EXISTS))`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseFilterExpression(tt.input, tt.fields)
+			if err != nil {
+				t.Fatalf("ParseFilterExpression(%q) returned %v", tt.input, err)
+			}
+			if got := filterNodeString(*node); got != tt.want {
+				t.Errorf("ParseFilterExpression(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterExpressionEmpty(t *testing.T) {
+	for _, input := range []string{"", "   ", "\t\n"} {
+		node, err := ParseFilterExpression(input, EndpointFilterFields)
+		if node != nil || err != nil {
+			t.Errorf("ParseFilterExpression(%q) = %v, %v, want nil, nil", input, node, err)
+		}
+	}
+}
+
+func TestParseFilterExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		position int
+		message  string
+	}{
+		{"unknown field", "foo = 1", 1, "unknown field 'foo'"},
+		{"unknown operator", "status_code ~ 1", 13, "expected an operator"},
+		{"lone !", "status_code ! 1", 13, "unexpected '!'"},
+		{"operator on the wrong type", "endpoint > a", 10, "> can only be used on numeric and duration fields"},
+		{"CONTAINS on a number", "status_code CONTAINS 5", 13, "CONTAINS can only be used on text fields"},
+		{"EXISTS on a column", "endpoint EXISTS", 10, "EXISTS can only be used on scope fields"},
+		{"NOT EXISTS on a column", "endpoint NOT EXISTS", 14, "expected IN or EXISTS after NOT"},
+		{"invalid number", "status_code > abc", 15, "expected a whole number"},
+		{"invalid duration", "duration > 5 minutes", 12, "expected a duration"},
+		{"missing value", "status_code =", 14, "expected a value, got the end of the expression"},
+		{"missing operator", "status_code", 12, "expected an operator, got the end of the expression"},
+		{"missing condition", "status_code = 1 AND", 20, "expected a condition"},
+		{"missing closing parenthesis", "(status_code = 1", 17, "expected ')'"},
+		{"extra closing parenthesis", "status_code = 1)", 16, "expected AND, OR or the end of the expression"},
+		{"missing AND", "status_code = 1 status_code = 2", 17, "expected AND, OR or the end of the expression"},
+		{"unterminated string", `endpoint = "abc`, 12, "unterminated string"},
+		{"IN without a list", "server_name IN a", 16, "expected '(' after IN"},
+		{"IN list without a comma", "server_name IN (a b)", 19, "expected ',' or ')'"},
+		{"unterminated IN list", "server_name IN (a,", 19, "expected a value"},
+		{"scope without a key", "scope. = 1", 1, "scope fields need a key"},
+		{"value in place of a field", `"a" = 1`, 1, "expected a field name"},
+		{"too deep", strings.Repeat("(", 40) + "status_code = 1" + strings.Repeat(")", 40), 34, "nested more than"},
+		{"too long", "endpoint = " + strings.Repeat("a", MaxFilterExpressionLength), MaxFilterExpressionLength + 1, "longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseFilterExpression(tt.input, EndpointFilterFields)
+			var syntaxErr *FilterSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ParseFilterExpression(%q) = %v, %v, want a FilterSyntaxError", tt.input, node, err)
+			}
+			if syntaxErr.Position != tt.position || !strings.Contains(syntaxErr.Message, tt.message) {
+				t.Errorf("ParseFilterExpression(%q) error = %v, want position %d and %q", tt.input, err, tt.position, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseFilterExpressionValues(t *testing.T) {
+	node, err := ParseFilterExpression("status_code IN (1, 2) AND duration <= 250ms", EndpointFilterFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{int64(1), int64(2)}, {int64(250000000)}}
+	for i, child := range node.Children {
+		if !reflect.DeepEqual(child.Condition.Values, want[i]) {
+			t.Errorf("values of condition %d = %#v, want %#v", i, child.Condition.Values, want[i])
+		}
+	}
+}
+
+func TestParseFilterExpressionMalformed(t *testing.T) {
+	inputs := []string{"(", ")", "()", ",", "=", "!", "'", `"\`, "NOT", "NOT NOT", "scope.a NOT", "server_name IN (",
+		"server_name IN ()", "server_name IN (a,)", "status_code = 1 OR", "status_code = = 1", "AND AND", "a,b", "é = ü"}
+
+	for _, input := range inputs {
+		if _, err := ParseFilterExpression(input, EndpointFilterFields); err == nil {
+			t.Errorf("ParseFilterExpression(%q) returned no error", input)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	IssueStatusUnresolved = "unresolved"
+	// IssueStatusResolved issues are hidden until they occur again, which makes them unresolved (a regression)
+	IssueStatusResolved = "resolved"
+	// IssueStatusIgnored issues stay hidden whether they occur again or not
+	IssueStatusIgnored = "ignored"
+	// IssueStatusSnoozed issues are hidden until the condition of their snooze is met
+	IssueStatusSnoozed = "snoozed"
+)
+
+// IssueStatuses are the valid issue statuses
+var IssueStatuses = map[string]bool{
+	IssueStatusUnresolved: true,
+	IssueStatusResolved:   true,
+	IssueStatusIgnored:    true,
+	IssueStatusSnoozed:    true,
+}
+
+const (
+	// IssueResolutionNow resolves the issue right away, any later occurrence reopens it
+	IssueResolutionNow = "now"
+	// IssueResolutionNextRelease resolves the issue in the release after ResolvedInVersion, only occurrences on an
+	// app version first reported after the issue was resolved reopen it
+	IssueResolutionNextRelease = "next_release"
+)
+
+// IssuePriorities are the valid issue priorities, from lowest to highest
+var IssuePriorities = []string{"low", "medium", "high", "critical"}
+
+// DefaultIssuePriority is the priority of an issue nobody triaged yet
+const DefaultIssuePriority = "medium"
+
+const (
+	IssueActivityStatusChanged   = "status_changed"
+	IssueActivityAssigned        = "assigned"
+	IssueActivityPriorityChanged = "priority_changed"
+	IssueActivityCommented       = "commented"
+	// IssueActivityResolvedInNextRelease is recorded when an issue is resolved in the next release, NewValue is
+	// the version deployed at the time
+	IssueActivityResolvedInNextRelease = "resolved_in_next_release"
+	// IssueActivityRegressed is recorded when a resolved issue occurs again and is unresolved by the ingest
+	IssueActivityRegressed = "regressed"
+	// IssueActivitySnoozed is recorded when an issue is snoozed, NewValue describes the condition
+	IssueActivitySnoozed = "snoozed"
+	// IssueActivityUnsnoozed is recorded when the condition of a snoozed issue is met, NewValue is the condition
+	IssueActivityUnsnoozed = "unsnoozed"
+)
+
+// IssueUserScopeKeys are the scope keys identifying the user an occurrence affected, the first one set is used
+var IssueUserScopeKeys = []string{"user.id", "userId", "user"}
+
+// IssueActorSystem is the actor of the activity that isn't caused by a user
+const IssueActorSystem = "system"
+
+// Issue is the workflow state of an exception hash. Hashes without a stored issue are unresolved, unassigned and
+// of the default priority.
+type Issue struct {
+	ProjectId     uuid.UUID `json:"projectId" ch:"project_id"`
+	ExceptionHash string    `json:"exceptionHash" ch:"exception_hash"`
+	Status        string    `json:"status" ch:"status"`
+	Assignee      string    `json:"assignee" ch:"assignee"`
+	Priority      string    `json:"priority" ch:"priority"`
+	// ResolvedAt is set while the issue is resolved, occurrences after it are regressions
+	ResolvedAt *time.Time `json:"resolvedAt" ch:"resolved_at"`
+	// Resolution is how a resolved issue was resolved, one of the IssueResolution constants
+	Resolution string `json:"resolution" ch:"resolution"`
+	// ResolvedInVersion is the latest app version when the issue was resolved in the next release
+	ResolvedInVersion string `json:"resolvedInVersion" ch:"resolved_in_version"`
+	// SnoozedAt is set while the issue is snoozed, the conditions of Snooze count the occurrences after it
+	SnoozedAt *time.Time  `json:"snoozedAt" ch:"snoozed_at"`
+	Snooze    IssueSnooze `json:"snooze"`
+	CreatedAt time.Time   `json:"createdAt" ch:"created_at"`
+	UpdatedAt time.Time   `json:"updatedAt" ch:"updated_at"`
+}
+
+// IssueSnooze are the conditions of a snoozed issue, it comes back as soon as any of the set ones is met
+type IssueSnooze struct {
+	// Until snoozes the issue until the date
+	Until *time.Time `json:"until" ch:"snooze_until"`
+	// Occurrences snoozes the issue until it occurs this many more times
+	Occurrences int `json:"occurrences" ch:"snooze_occurrences"`
+	// OccurrencesPerHour snoozes the issue until it occurs this many times within an hour
+	OccurrencesPerHour int `json:"occurrencesPerHour" ch:"snooze_occurrences_per_hour"`
+	// Users snoozes the issue until this many distinct users are affected, see IssueUserScopeKeys
+	Users int `json:"users" ch:"snooze_users"`
+	// Servers snoozes the issue until it occurs on this many distinct servers
+	Servers int `json:"servers" ch:"snooze_servers"`
+}
+
+// IssueSnoozeStats are the occurrences of a snoozed issue since it was snoozed
+type IssueSnoozeStats struct {
+	Occurrences uint64 `json:"occurrences"`
+	// LastHour are the occurrences of the last hour
+	LastHour uint64 `json:"lastHour"`
+	Users    uint64 `json:"users"`
+	Servers  uint64 `json:"servers"`
+}
+
+// IssueComment is a comment left on an issue
+type IssueComment struct {
+	Id            uuid.UUID `json:"id" ch:"id"`
+	ProjectId     uuid.UUID `json:"projectId" ch:"project_id"`
+	ExceptionHash string    `json:"exceptionHash" ch:"exception_hash"`
+	Author        string    `json:"author" ch:"author"`
+	Body          string    `json:"body" ch:"body"`
+	CreatedAt     time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// IssueActivity is an entry of the timeline of an issue, OldValue and NewValue hold the changed field
+type IssueActivity struct {
+	Id            uuid.UUID `json:"id" ch:"id"`
+	ProjectId     uuid.UUID `json:"projectId" ch:"project_id"`
+	ExceptionHash string    `json:"exceptionHash" ch:"exception_hash"`
+	Actor         string    `json:"actor" ch:"actor"`
+	Action        string    `json:"action" ch:"action"`
+	OldValue      string    `json:"oldValue" ch:"old_value"`
+	NewValue      string    `json:"newValue" ch:"new_value"`
+	CreatedAt     time.Time `json:"createdAt" ch:"created_at"`
+}
+
+// IssueFilter narrows the issues listed, on any of the set fields
+type IssueFilter struct {
+	Statuses []string
+	// Assignee matches the assignee exactly, the empty string matching unassigned issues
+	Assignee   *string
+	Priorities []string
+	// Scope only counts the occurrences whose scope matches, issues without a matching occurrence are left out
+	Scope []ScopeFilter
+	// Expression only counts the occurrences matching a parsed filter expression
+	Expression *FilterNode
+}
+
+// IssueKey identifies the issue of an exception hash across projects
+func IssueKey(projectId uuid.UUID, exceptionHash string) string {
+	return projectId.String() + "/" + exceptionHash
+}
+
+// NewIssue returns the state of an issue nobody changed yet
+func NewIssue(projectId uuid.UUID, exceptionHash string) Issue {
+	return Issue{
+		ProjectId:     projectId,
+		ExceptionHash: exceptionHash,
+		Status:        IssueStatusUnresolved,
+		Priority:      DefaultIssuePriority,
+	}
+}
+
+// IsValidIssuePriority reports whether priority is one of IssuePriorities
+func IsValidIssuePriority(priority string) bool {
+	for _, p := range IssuePriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus changes the status of the issue, returning the activity to record or nil when it didn't change.
+// Resolving an issue resolved in the next release resolves it right away instead.
+func (i *Issue) SetStatus(status, actor string, now time.Time) *IssueActivity {
+	if i.Status == status && (status != IssueStatusResolved || i.Resolution != IssueResolutionNextRelease) {
+		return nil
+	}
+	old := i.Status
+	i.Status = status
+	i.ResolvedAt = nil
+	i.Resolution = ""
+	i.ResolvedInVersion = ""
+	i.SnoozedAt = nil
+	i.Snooze = IssueSnooze{}
+	if status == IssueStatusResolved {
+		i.ResolvedAt = &now
+		i.Resolution = IssueResolutionNow
+	}
+	return i.activity(IssueActivityStatusChanged, actor, old, status, now)
+}
+
+// ResolveInNextRelease resolves the issue until it occurs on an app version released after now, currentVersion
+// being the latest version at the time
+func (i *Issue) ResolveInNextRelease(currentVersion, actor string, now time.Time) *IssueActivity {
+	if i.Status == IssueStatusResolved && i.Resolution == IssueResolutionNextRelease && i.ResolvedInVersion == currentVersion {
+		return nil
+	}
+	i.Status = IssueStatusResolved
+	i.ResolvedAt = &now
+	i.Resolution = IssueResolutionNextRelease
+	i.ResolvedInVersion = currentVersion
+	i.SnoozedAt = nil
+	i.Snooze = IssueSnooze{}
+	return i.activity(IssueActivityResolvedInNextRelease, actor, "", currentVersion, now)
+}
+
+// IsRegression reports whether an occurrence on appVersion at occurredAt reopens the resolved issue. For issues
+// resolved in the next release, versionFirstSeen is when appVersion was first reported, nil when this occurrence
+// is its first report. Occurrences without an app version can't tell a new release and don't reopen them.
+func (i *Issue) IsRegression(occurredAt time.Time, appVersion string, versionFirstSeen *time.Time) bool {
+	if i.Status != IssueStatusResolved || i.ResolvedAt == nil || !occurredAt.After(*i.ResolvedAt) {
+		return false
+	}
+	if i.Resolution != IssueResolutionNextRelease {
+		return true
+	}
+	if appVersion == "" || appVersion == i.ResolvedInVersion {
+		return false
+	}
+	return versionFirstSeen == nil || versionFirstSeen.After(*i.ResolvedAt)
+}
+
+// SetSnooze snoozes the issue until any of the conditions of snooze is met
+func (i *Issue) SetSnooze(snooze IssueSnooze, actor string, now time.Time) *IssueActivity {
+	old := i.Status
+	i.Status = IssueStatusSnoozed
+	i.ResolvedAt = nil
+	i.Resolution = ""
+	i.ResolvedInVersion = ""
+	i.SnoozedAt = &now
+	i.Snooze = snooze
+	return i.activity(IssueActivitySnoozed, actor, old, snooze.String(), now)
+}
+
+// Unsnooze brings back a snoozed issue whose condition was met
+func (i *Issue) Unsnooze(condition string, now time.Time) *IssueActivity {
+	i.Status = IssueStatusUnresolved
+	i.SnoozedAt = nil
+	i.Snooze = IssueSnooze{}
+	return i.activity(IssueActivityUnsnoozed, IssueActorSystem, IssueStatusSnoozed, condition, now)
+}
+
+// IsEmpty reports whether no condition is set
+func (s IssueSnooze) IsEmpty() bool {
+	return s.Until == nil && s.Occurrences == 0 && s.OccurrencesPerHour == 0 && s.Users == 0 && s.Servers == 0
+}
+
+// NeedsStats reports whether a condition depends on the occurrences since the issue was snoozed
+func (s IssueSnooze) NeedsStats() bool {
+	return s.Occurrences > 0 || s.OccurrencesPerHour > 0 || s.Users > 0 || s.Servers > 0
+}
+
+// MetCondition returns the condition that is met at now given the occurrences since the issue was snoozed, or
+// the empty string while none is
+func (s IssueSnooze) MetCondition(stats IssueSnoozeStats, now time.Time) string {
+	switch {
+	case s.Until != nil && !now.Before(*s.Until):
+		return "until " + s.Until.UTC().Format(time.RFC3339)
+	case s.Occurrences > 0 && stats.Occurrences >= uint64(s.Occurrences):
+		return fmt.Sprintf("%d occurrences", s.Occurrences)
+	case s.OccurrencesPerHour > 0 && stats.LastHour >= uint64(s.OccurrencesPerHour):
+		return fmt.Sprintf("%d occurrences per hour", s.OccurrencesPerHour)
+	case s.Users > 0 && stats.Users >= uint64(s.Users):
+		return fmt.Sprintf("%d users", s.Users)
+	case s.Servers > 0 && stats.Servers >= uint64(s.Servers):
+		return fmt.Sprintf("%d servers", s.Servers)
+	}
+	return ""
+}
+
+// String describes the conditions, e.g. "until 2026-01-02T15:04:05Z or 100 occurrences"
+func (s IssueSnooze) String() string {
+	var conditions []string
+	if s.Until != nil {
+		conditions = append(conditions, "until "+s.Until.UTC().Format(time.RFC3339))
+	}
+	if s.Occurrences > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d occurrences", s.Occurrences))
+	}
+	if s.OccurrencesPerHour > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d occurrences per hour", s.OccurrencesPerHour))
+	}
+	if s.Users > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d users", s.Users))
+	}
+	if s.Servers > 0 {
+		conditions = append(conditions, fmt.Sprintf("%d servers", s.Servers))
+	}
+	return strings.Join(conditions, " or ")
+}
+
+// SetAssignee changes the assignee of the issue, the empty string unassigning it
+func (i *Issue) SetAssignee(assignee, actor string, now time.Time) *IssueActivity {
+	if i.Assignee == assignee {
+		return nil
+	}
+	old := i.Assignee
+	i.Assignee = assignee
+	return i.activity(IssueActivityAssigned, actor, old, assignee, now)
+}
+
+// SetPriority changes the priority of the issue
+func (i *Issue) SetPriority(priority, actor string, now time.Time) *IssueActivity {
+	if i.Priority == priority {
+		return nil
+	}
+	old := i.Priority
+	i.Priority = priority
+	return i.activity(IssueActivityPriorityChanged, actor, old, priority, now)
+}
+
+// Regress unresolves a resolved issue that occurred again at occurredAt
+func (i *Issue) Regress(occurredAt time.Time) *IssueActivity {
+	old := i.Status
+	i.Status = IssueStatusUnresolved
+	i.ResolvedAt = nil
+	i.Resolution = ""
+	i.ResolvedInVersion = ""
+	return i.activity(IssueActivityRegressed, IssueActorSystem, old, IssueStatusUnresolved, occurredAt)
+}
+
+func (i *Issue) activity(action, actor, oldValue, newValue string, now time.Time) *IssueActivity {
+	return &IssueActivity{
+		Id:            uuid.New(),
+		ProjectId:     i.ProjectId,
+		ExceptionHash: i.ExceptionHash,
+		Actor:         actor,
+		Action:        action,
+		OldValue:      oldValue,
+		NewValue:      newValue,
+		CreatedAt:     now,
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,9 +10,96 @@ import (
 type MetricRecord struct {
 	ProjectId  uuid.UUID `json:"projectId" ch:"project_id"`
 	Name       string    `json:"name" ch:"name"`
-	Value      float64   `json:"value" ch:"value"`
+	Value      float64   `json:"value" ch:"value"` // gauge value, cumulative counter value or the sum of histogram observations
 	RecordedAt time.Time `json:"recordedAt" ch:"recorded_at"`
 	ServerName string    `json:"serverName" ch:"server_name"`
+	Type       string    `json:"type" ch:"metric_type"`
+	// BucketBounds are the upper bounds of a histogram's buckets, BucketCounts holds the observations in each
+	// bucket (non-cumulative) plus a final overflow (+Inf) bucket
+	BucketBounds []float64 `json:"bucketBounds,omitempty" ch:"bucket_bounds"`
+	BucketCounts []uint64  `json:"bucketCounts,omitempty" ch:"bucket_counts"`
+	// Tags are free-form labels (queue=emails, region=eu), bounded by the project's tag limits at ingest
+	Tags map[string]string `json:"tags,omitempty" ch:"tags"`
+}
+
+const (
+	// MetricTagOverflowValue replaces tag values that would push a tag above the project's value limit
+	MetricTagOverflowValue  = "__other__"
+	MaxMetricTagValueLength = 128
+)
+
+// metricTagKeyRe allows identifier-like keys such as "queue", "http.method" or "region-id"
+var metricTagKeyRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.\-]{0,63}$`)
+
+// IsValidMetricTagKey reports whether a tag key can be stored and queried
+func IsValidMetricTagKey(key string) bool {
+	return metricTagKeyRe.MatchString(key)
+}
+
+// MetricSeriesFilter narrows a metric query to servers and tag values and decides how the results are split into series
+type MetricSeriesFilter struct {
+	Servers []string          `json:"servers"`
+	Tags    map[string]string `json:"tags"`
+	// GroupBy is "server" (default), "none" or the key of a tag
+	GroupBy string `json:"groupBy"`
+}
+
+const (
+	MetricGroupByServer = "server"
+	MetricGroupByNone   = "none"
+)
+
+const (
+	MetricAggregationAvg  = "avg"
+	MetricAggregationMin  = "min"
+	MetricAggregationMax  = "max"
+	MetricAggregationSum  = "sum"
+	MetricAggregationP95  = "p95"
+	MetricAggregationRate = "rate" // per-second rate of a counter
+)
+
+// IsValidMetricAggregation reports whether the aggregation is supported by the metric query API
+func IsValidMetricAggregation(aggregation string) bool {
+	switch aggregation {
+	case MetricAggregationAvg, MetricAggregationMin, MetricAggregationMax, MetricAggregationSum, MetricAggregationP95, MetricAggregationRate:
+		return true
+	}
+	return false
+}
+
+// DefaultMetricAggregation returns the aggregation a metric type is usually viewed with
+func DefaultMetricAggregation(metricType string) string {
+	switch metricType {
+	case MetricTypeCounter:
+		return MetricAggregationRate
+	case MetricTypeHistogram:
+		return MetricAggregationP95
+	}
+	return MetricAggregationAvg
+}
+
+// MetricCatalogEntry describes a metric name reported by a project
+type MetricCatalogEntry struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	LastSeen    time.Time `json:"lastSeen"`
+	Servers     []string  `json:"servers"`
+	SampleCount uint64    `json:"sampleCount"`
+	TagKeys     []string  `json:"tagKeys"`
+}
+
+// MetricSeries is one series of a metric query, keyed by server name, tag value or "" when not grouped
+type MetricSeries struct {
+	Key   string                `json:"key"`
+	Value float64               `json:"value"` // latest value
+	Trend []DashboardTrendPoint `json:"trend"`
+}
+
+// MetricTagStats describes the values seen for one tag key of a metric
+type MetricTagStats struct {
+	Key          string   `json:"key"`
+	Cardinality  uint64   `json:"cardinality"`
+	SampleValues []string `json:"sampleValues"`
 }
 
 const (
@@ -24,3 +112,64 @@ const (
 	MetricNameGCPauseTotal = "go.gc_pause"
 	// other metric names are custom and added by the clients
 )
+
+const (
+	// MetricTypeGauge is a point in time value (memory used, goroutines), aggregated with avg/min/max
+	MetricTypeGauge = "gauge"
+	// MetricTypeCounter is a monotonically increasing value (GC cycles), queried as rate/increase
+	MetricTypeCounter = "counter"
+	// MetricTypeHistogram is a bucketed distribution of observations, queried as percentiles
+	MetricTypeHistogram = "histogram"
+)
+
+// IsValidMetricType reports whether the type is one of the supported metric types
+func IsValidMetricType(metricType string) bool {
+	return metricType == MetricTypeGauge || metricType == MetricTypeCounter || metricType == MetricTypeHistogram
+}
+
+// DefaultMetricType returns the type of the built-in metrics for clients that don't report a type
+func DefaultMetricType(name string) string {
+	switch name {
+	case MetricNameNumGC, MetricNameGCPauseTotal:
+		return MetricTypeCounter
+	}
+	return MetricTypeGauge
+}
+
+// HistogramQuantile estimates the q-quantile (0-1) from histogram buckets by linear interpolation inside the
+// bucket the quantile falls in. Observations in the overflow bucket are reported as the highest bound.
+func HistogramQuantile(q float64, bounds []float64, counts []uint64) float64 {
+	if len(bounds) == 0 || len(counts) == 0 {
+		return 0
+	}
+
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var cumulative float64
+	for i, c := range counts {
+		if i >= len(bounds) {
+			// overflow bucket
+			return bounds[len(bounds)-1]
+		}
+		next := cumulative + float64(c)
+		if next >= rank && c > 0 {
+			lower := 0.0
+			if i > 0 {
+				lower = bounds[i-1]
+			} else if bounds[0] < 0 {
+				lower = bounds[0]
+			}
+			return lower + (bounds[i]-lower)*((rank-cumulative)/float64(c))
+		}
+		cumulative = next
+	}
+
+	return bounds[len(bounds)-1]
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// NotificationChannelWebhook posts the event as JSON, signed with HMAC-SHA256 when a secret is set
+	NotificationChannelWebhook = "webhook"
+	// NotificationChannelSlack posts to a Slack (or Slack-compatible) incoming webhook
+	NotificationChannelSlack = "slack"
+	// NotificationChannelTeams posts a MessageCard to a Microsoft Teams incoming webhook
+	NotificationChannelTeams = "teams"
+	// NotificationChannelEmail sends a plain text email through the SMTP server configured with the SMTP_* env vars
+	NotificationChannelEmail = "email"
+)
+
+const (
+	NotificationEventAlertFiring   = "alert.firing"
+	NotificationEventAlertResolved = "alert.resolved"
+	// NotificationEventIssueNew is emitted the first time an exception hash is seen in a project
+	NotificationEventIssueNew = "issue.new"
+	// NotificationEventIssueRegression is emitted when a resolved issue occurs again
+	NotificationEventIssueRegression = "issue.regression"
+	// NotificationEventIssueUnsnoozed is emitted when the condition of a snoozed issue is met
+	NotificationEventIssueUnsnoozed = "issue.unsnoozed"
+	// NotificationEventTaskMissed, Late, Recovered and DurationOutlier are emitted for the events of task monitors
+	NotificationEventTaskMissed          = "task.missed"
+	NotificationEventTaskLate            = "task.late"
+	NotificationEventTaskRecovered       = "task.recovered"
+	NotificationEventTaskDurationOutlier = "task.duration_outlier"
+	NotificationEventTest                = "test"
+)
+
+const (
+	NotificationDeliveryPending   = "pending"
+	NotificationDeliveryDelivered = "delivered"
+	NotificationDeliveryFailed    = "failed"
+)
+
+// IsValidNotificationChannelType reports whether notifications can be delivered to the channel type
+func IsValidNotificationChannelType(channelType string) bool {
+	switch channelType {
+	case NotificationChannelWebhook, NotificationChannelSlack, NotificationChannelTeams, NotificationChannelEmail:
+		return true
+	}
+	return false
+}
+
+// NotificationEventTypes are the event types a channel can subscribe to
+var NotificationEventTypes = map[string]bool{
+	NotificationEventAlertFiring:         true,
+	NotificationEventAlertResolved:       true,
+	NotificationEventIssueNew:            true,
+	NotificationEventIssueRegression:     true,
+	NotificationEventIssueUnsnoozed:      true,
+	NotificationEventTaskMissed:          true,
+	NotificationEventTaskLate:            true,
+	NotificationEventTaskRecovered:       true,
+	NotificationEventTaskDurationOutlier: true,
+}
+
+// NotificationChannelConfig holds the settings of every channel type, only the ones of the channel's type are used
+type NotificationChannelConfig struct {
+	// Url is the endpoint of webhook, slack and teams channels
+	Url string `json:"url,omitempty"`
+	// Secret signs webhook payloads, it is never returned by the API
+	Secret    string            `json:"secret,omitempty"`
+	SecretSet bool              `json:"secretSet,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	// Recipients are the addresses of email channels
+	Recipients []string `json:"recipients,omitempty"`
+	// SubjectTemplate and BodyTemplate override the default message (Go text/template over the NotificationEvent)
+	SubjectTemplate string `json:"subjectTemplate,omitempty"`
+	BodyTemplate    string `json:"bodyTemplate,omitempty"`
+}
+
+// NotificationChannel is a destination for a project's alert and issue events
+type NotificationChannel struct {
+	Id        uuid.UUID                 `json:"id" ch:"id"`
+	ProjectId uuid.UUID                 `json:"projectId" ch:"project_id"`
+	Name      string                    `json:"name" ch:"name"`
+	Type      string                    `json:"type" ch:"channel_type"`
+	Config    NotificationChannelConfig `json:"config" ch:"config"`
+	// EventTypes the channel is subscribed to, every event type when empty
+	EventTypes []string  `json:"eventTypes" ch:"event_types"`
+	Enabled    bool      `json:"enabled" ch:"enabled"`
+	CreatedAt  time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// Subscribes reports whether the channel wants events of the given type
+func (c *NotificationChannel) Subscribes(eventType string) bool {
+	if eventType == NotificationEventTest {
+		return true
+	}
+	if len(c.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range c.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedHeaderValue replaces the values of webhook headers returned by the API, which usually carry credentials
+const RedactedHeaderValue = "********"
+
+// Redacted returns a copy of the channel that is safe to return from the API
+func (c NotificationChannel) Redacted() NotificationChannel {
+	c.Config.SecretSet = c.Config.Secret != ""
+	c.Config.Secret = ""
+	if len(c.Config.Headers) > 0 {
+		headers := make(map[string]string, len(c.Config.Headers))
+		for name := range c.Config.Headers {
+			headers[name] = RedactedHeaderValue
+		}
+		c.Config.Headers = headers
+	}
+	return c
+}
+
+// RestoreRedactedHeaders puts back the stored value of the headers sent back redacted, headers without a stored
+// value are dropped
+func (c *NotificationChannelConfig) RestoreRedactedHeaders(stored map[string]string) {
+	for name, value := range c.Headers {
+		if value != RedactedHeaderValue {
+			continue
+		}
+		if storedValue, ok := stored[name]; ok {
+			c.Headers[name] = storedValue
+		} else {
+			delete(c.Headers, name)
+		}
+	}
+}
+
+// NotificationEvent is something that happened in a project that channels get notified about. It is the data
+// the subject and body templates are rendered with.
+type NotificationEvent struct {
+	Type        string            `json:"type"`
+	ProjectId   uuid.UUID         `json:"projectId"`
+	ProjectName string            `json:"projectName"`
+	Title       string            `json:"title"`
+	Message     string            `json:"message"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	// Url links to the page of the alert or issue
+	Url        string    `json:"url,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// NotificationDelivery is one event queued for, or delivered to, one channel
+type NotificationDelivery struct {
+	Id            uuid.UUID `json:"id" ch:"id"`
+	ProjectId     uuid.UUID `json:"projectId" ch:"project_id"`
+	ChannelId     uuid.UUID `json:"channelId" ch:"channel_id"`
+	EventType     string    `json:"eventType" ch:"event_type"`
+	Subject       string    `json:"subject" ch:"subject"`
+	Body          string    `json:"body" ch:"body"`
+	Event         string    `json:"event" ch:"event"` // the NotificationEvent as JSON
+	Status        string    `json:"status" ch:"status"`
+	Attempts      uint16    `json:"attempts" ch:"attempts"`
+	LastError     string    `json:"lastError" ch:"last_error"`
+	ResponseCode  uint16    `json:"responseCode" ch:"response_code"`
+	NextAttemptAt time.Time `json:"nextAttemptAt" ch:"next_attempt_at"`
+	CreatedAt     time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" ch:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidPageCursor = errors.New("invalid cursor")
+
+// PageCursor is the position of the last row of a page in a listing sorted by a column then id, the next page
+// starting right after it. It is handed to clients as an opaque token.
+type PageCursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d"`
+	// Value of the sort column, recorded_at as unix seconds
+	Value int64     `json:"v"`
+	Id    uuid.UUID `json:"i"`
+}
+
+// Encode returns the opaque token of the cursor
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageCursor parses a token returned by Encode
+func DecodePageCursor(token string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageCursor
+	}
+	var cursor PageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.SortBy == "" {
+		return nil, ErrInvalidPageCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PerformanceIssueTypeNPlusOne is raised when the same (normalized) segment repeats too often inside one transaction
+	PerformanceIssueTypeNPlusOne = "n_plus_one"
+)
+
+// PerformanceIssue is a single transaction flagged by a performance detector
+type PerformanceIssue struct {
+	Id              uuid.UUID     `json:"id" ch:"id"`
+	ProjectId       uuid.UUID     `json:"projectId" ch:"project_id"`
+	IssueType       string        `json:"issueType" ch:"issue_type"`
+	IssueHash       string        `json:"issueHash" ch:"issue_hash"`
+	TransactionId   uuid.UUID     `json:"transactionId" ch:"transaction_id"`
+	TransactionType string        `json:"transactionType" ch:"transaction_type"` // "endpoint" or "task"
+	TransactionName string        `json:"transactionName" ch:"transaction_name"`
+	SegmentName     string        `json:"segmentName" ch:"segment_name"` // normalized segment name
+	RepeatCount     uint32        `json:"repeatCount" ch:"repeat_count"`
+	TotalDuration   time.Duration `json:"totalDuration" ch:"total_duration"` // time spent in the repeated segments
+	RecordedAt      time.Time     `json:"recordedAt" ch:"recorded_at"`
+	AppVersion      string        `json:"appVersion" ch:"app_version"`
+	ServerName      string        `json:"serverName" ch:"server_name"`
+}
+
+// PerformanceIssueGroup aggregates every occurrence sharing an issue hash
+type PerformanceIssueGroup struct {
+	IssueHash          string        `json:"issueHash"`
+	IssueType          string        `json:"issueType"`
+	TransactionType    string        `json:"transactionType"`
+	TransactionName    string        `json:"transactionName"`
+	SegmentName        string        `json:"segmentName"`
+	Count              uint64        `json:"count"`
+	MaxRepeatCount     uint32        `json:"maxRepeatCount"`
+	AvgRepeatCount     float64       `json:"avgRepeatCount"`
+	AvgTotalDuration   time.Duration `json:"avgTotalDuration"`
+	FirstSeen          time.Time     `json:"firstSeen"`
+	LastSeen           time.Time     `json:"lastSeen"`
+	SampleTransactions []uuid.UUID   `json:"sampleTransactions"`
+}
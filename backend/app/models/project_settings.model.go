@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMetricTagKeyLimit is the number of tag keys kept on a single metric record
+	DefaultMetricTagKeyLimit = 10
+	// DefaultMetricTagValueLimit is the number of distinct values kept per tag key of a metric
+	DefaultMetricTagValueLimit = 1000
+)
+
+// Data types a retention can be set for
+const (
+	DataTypeEndpoints  = "endpoints"
+	DataTypeTasks      = "tasks"
+	DataTypeSegments   = "segments"
+	DataTypeExceptions = "exceptions"
+	DataTypeMessages   = "messages"
+	DataTypeMetrics    = "metrics"
+)
+
+// MaxRetentionDays bounds the retention of a data type, about 10 years
+const MaxRetentionDays = 3650
+
+// ProjectSettings holds per-project limits and retentions, zero limits fall back to the defaults
+type ProjectSettings struct {
+	ProjectId           uuid.UUID `json:"projectId" ch:"project_id"`
+	MetricTagKeyLimit   uint16    `json:"metricTagKeyLimit" ch:"metric_tag_key_limit"`
+	MetricTagValueLimit uint32    `json:"metricTagValueLimit" ch:"metric_tag_value_limit"`
+	// Retentions are the number of days each data type is kept for, 0 keeping it forever
+	EndpointRetentionDays  uint16    `json:"endpointRetentionDays" ch:"endpoint_retention_days"`
+	TaskRetentionDays      uint16    `json:"taskRetentionDays" ch:"task_retention_days"`
+	SegmentRetentionDays   uint16    `json:"segmentRetentionDays" ch:"segment_retention_days"`
+	ExceptionRetentionDays uint16    `json:"exceptionRetentionDays" ch:"exception_retention_days"`
+	MessageRetentionDays   uint16    `json:"messageRetentionDays" ch:"message_retention_days"`
+	MetricRetentionDays    uint16    `json:"metricRetentionDays" ch:"metric_retention_days"`
+	UpdatedAt              time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// RetentionDays returns the number of days a data type is kept for, 0 when it is kept forever
+func (s *ProjectSettings) RetentionDays(dataType string) int {
+	switch dataType {
+	case DataTypeEndpoints:
+		return int(s.EndpointRetentionDays)
+	case DataTypeTasks:
+		return int(s.TaskRetentionDays)
+	case DataTypeSegments:
+		return int(s.SegmentRetentionDays)
+	case DataTypeExceptions:
+		return int(s.ExceptionRetentionDays)
+	case DataTypeMessages:
+		return int(s.MessageRetentionDays)
+	case DataTypeMetrics:
+		return int(s.MetricRetentionDays)
+	}
+	return 0
+}
+
+// TagKeyLimit returns the effective number of tag keys allowed per metric record
+func (s *ProjectSettings) TagKeyLimit() int {
+	if s.MetricTagKeyLimit == 0 {
+		return DefaultMetricTagKeyLimit
+	}
+	return int(s.MetricTagKeyLimit)
+}
+
+// TagValueLimit returns the effective number of distinct values allowed per tag key of a metric
+func (s *ProjectSettings) TagValueLimit() int {
+	if s.MetricTagValueLimit == 0 {
+		return DefaultMetricTagValueLimit
+	}
+	return int(s.MetricTagValueLimit)
+}
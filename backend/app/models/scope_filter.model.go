@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ScopeFilterEquals    = "equals"
+	ScopeFilterContains  = "contains"
+	ScopeFilterExists    = "exists"
+	ScopeFilterNotExists = "not_exists"
+)
+
+var ScopeFilterOperators = map[string]bool{
+	ScopeFilterEquals:    true,
+	ScopeFilterContains:  true,
+	ScopeFilterExists:    true,
+	ScopeFilterNotExists: true,
+}
+
+// MaxScopeFilters bounds the scope filters of a single search
+const MaxScopeFilters = 10
+
+// ScopeFilter narrows a search to the records whose scope (tenant ids, request tags...) matches. Contains is case
+// insensitive, exists and not_exists ignore the value.
+type ScopeFilter struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// Validate checks the operator, and that equals and contains filters have a value
+func (f ScopeFilter) Validate() error {
+	if f.Key == "" || len(f.Key) > 128 {
+		return errors.New("scope filter key is required and must be at most 128 characters")
+	}
+	if !ScopeFilterOperators[f.Operator] {
+		return errors.New("scope filter operator must be one of: equals, contains, exists, not_exists")
+	}
+	if (f.Operator == ScopeFilterEquals || f.Operator == ScopeFilterContains) && f.Value == "" {
+		return fmt.Errorf("scope filter %s requires a value", f.Operator)
+	}
+	return nil
+}
+
+// ValidateScopeFilters validates every filter of a search and their count
+func ValidateScopeFilters(filters []ScopeFilter) error {
+	if len(filters) > MaxScopeFilters {
+		return fmt.Errorf("at most %d scope filters are allowed", MaxScopeFilters)
+	}
+	for _, f := range filters {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
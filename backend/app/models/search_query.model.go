@@ -0,0 +1,178 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Snippets are cut around the matches of a search, with this many characters of context on each side
+const (
+	searchSnippetContext = 60
+	MaxSearchSnippets    = 3
+)
+
+// SearchTerm is a word or a quoted phrase of a search, negated terms exclude the records containing them
+type SearchTerm struct {
+	Text    string
+	Phrase  bool
+	Negated bool
+}
+
+// SearchQuery is a parsed full-text search such as: timeout "connection refused" -healthcheck
+type SearchQuery struct {
+	Terms []SearchTerm
+}
+
+// SearchMatch is a highlighted range of a snippet, in characters
+type SearchMatch struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchSnippet is an excerpt of a stack trace or message around the matches of a search
+type SearchSnippet struct {
+	Text    string        `json:"text"`
+	Matches []SearchMatch `json:"matches"`
+}
+
+// ParseSearchQuery splits a search into words and "quoted phrases", a leading - negates either. An unterminated
+// quote runs to the end of the search.
+func ParseSearchQuery(search string) SearchQuery {
+	var query SearchQuery
+	runes := []rune(search)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		term := SearchTerm{}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			term.Negated = true
+			i++
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			term.Text = strings.TrimSpace(string(runes[i+1 : end]))
+			term.Phrase = true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			term.Text = string(runes[i:end])
+			i = end
+		}
+
+		if term.Text != "" {
+			query.Terms = append(query.Terms, term)
+		}
+	}
+	return query
+}
+
+// IsEmpty reports whether the search has no terms
+func (q SearchQuery) IsEmpty() bool {
+	return len(q.Terms) == 0
+}
+
+// isTokenSeparator reports whether the tokenbf_v1 index splits text on the character, any ASCII character that
+// isn't a letter or a digit
+func isTokenSeparator(r rune) bool {
+	return r < utf8.RuneSelf && !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+}
+
+// IsToken reports whether the term is a single token of the tokenbf_v1 index
+func (t SearchTerm) IsToken() bool {
+	if t.Phrase {
+		return false
+	}
+	for _, r := range t.Text {
+		if isTokenSeparator(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerRunes lower cases every character on its own, so the indexes stay those of the text
+func lowerRunes(text string) []rune {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// Highlight returns up to MaxSearchSnippets excerpts of text around the case insensitive matches of the
+// search's positive terms, overlapping excerpts being merged. Words only match whole tokens like the search does,
+// phrases match anywhere.
+func (q SearchQuery) Highlight(text string) []SearchSnippet {
+	runes := []rune(text)
+	haystack := lowerRunes(text)
+
+	var matches []SearchMatch
+	for _, term := range q.Terms {
+		if term.Negated || term.Text == "" {
+			continue
+		}
+		needle := lowerRunes(term.Text)
+		// a word must start and end on token boundaries, unless it starts or ends with a separator itself
+		checkStart := !term.Phrase && !isTokenSeparator(needle[0])
+		checkEnd := !term.Phrase && !isTokenSeparator(needle[len(needle)-1])
+		for start := 0; start+len(needle) <= len(haystack); start++ {
+			end := start + len(needle)
+			if string(haystack[start:end]) != string(needle) ||
+				checkStart && start > 0 && !isTokenSeparator(haystack[start-1]) ||
+				checkEnd && end < len(haystack) && !isTokenSeparator(haystack[end]) {
+				continue
+			}
+			matches = append(matches, SearchMatch{Start: start, End: end})
+			start = end - 1
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	var snippets []SearchSnippet
+	snippetStart, snippetEnd := -1, -1
+	var snippetMatches []SearchMatch
+	flush := func() {
+		if snippetStart < 0 {
+			return
+		}
+		snippet := SearchSnippet{Text: string(runes[snippetStart:snippetEnd])}
+		for _, m := range snippetMatches {
+			snippet.Matches = append(snippet.Matches, SearchMatch{Start: m.Start - snippetStart, End: m.End - snippetStart})
+		}
+		snippets = append(snippets, snippet)
+	}
+	for _, m := range matches {
+		if snippetStart >= 0 && m.Start < snippetEnd {
+			if m.Start < snippetMatches[len(snippetMatches)-1].End {
+				continue // overlaps the previous match
+			}
+			snippetEnd = max(snippetEnd, min(len(runes), m.End+searchSnippetContext))
+			snippetMatches = append(snippetMatches, m)
+			continue
+		}
+		flush()
+		if len(snippets) == MaxSearchSnippets {
+			return snippets
+		}
+		snippetStart = max(0, m.Start-searchSnippetContext)
+		snippetEnd = min(len(runes), m.End+searchSnippetContext)
+		snippetMatches = []SearchMatch{m}
+	}
+	flush()
+	return snippets
+}
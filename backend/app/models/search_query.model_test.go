@@ -0,0 +1,108 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		search string
+		want   []SearchTerm
+	}{
+		{"", nil},
+		{"timeout", []SearchTerm{{Text: "timeout"}}},
+		{`timeout "connection refused" -healthcheck`, []SearchTerm{
+			{Text: "timeout"}, {Text: "connection refused", Phrase: true}, {Text: "healthcheck", Negated: true}}},
+		{`-"bad gateway"`, []SearchTerm{{Text: "bad gateway", Phrase: true, Negated: true}}},
+		{`"unterminated phrase`, []SearchTerm{{Text: "unterminated phrase", Phrase: true}}},
+		{`- "" x`, []SearchTerm{{Text: "-"}, {Text: "x"}}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseSearchQuery(tt.search).Terms; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSearchQuery(%q) = %#v, want %#v", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTermIsToken(t *testing.T) {
+	tests := []struct {
+		term SearchTerm
+		want bool
+	}{
+		{SearchTerm{Text: "Timeout42"}, true},
+		{SearchTerm{Text: "naïve"}, true},
+		{SearchTerm{Text: "foo.bar"}, false},
+		{SearchTerm{Text: "timeout", Phrase: true}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.term.IsToken(); got != tt.want {
+			t.Errorf("%#v.IsToken() = %v, want %v", tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQueryHighlight(t *testing.T) {
+	farApart := "timeout " + strings.Repeat("-", 200) + " timeout"
+	repeated := strings.Repeat("timeout"+strings.Repeat(" ", 150), 5)
+
+	tests := []struct {
+		name   string
+		search string
+		text   string
+		want   []SearchSnippet
+	}{
+		{"word on token boundaries", "timeout", "ConnectionTimeout: timeout after 5s",
+			[]SearchSnippet{{Text: "ConnectionTimeout: timeout after 5s", Matches: []SearchMatch{{19, 26}}}}},
+		{"word inside a token", "time", "timeout", nil},
+		{"case insensitive", "timeout", "TIMEOUT",
+			[]SearchSnippet{{Text: "TIMEOUT", Matches: []SearchMatch{{0, 7}}}}},
+		{"lower casing that changes lengths", "timeout", "İstanbul TIMEOUT",
+			[]SearchSnippet{{Text: "İstanbul TIMEOUT", Matches: []SearchMatch{{9, 16}}}}},
+		{"non-ASCII letters are part of tokens", "na", "a naïve b", nil},
+		{"non-ASCII word", "NAÏVE", "a naïve b",
+			[]SearchSnippet{{Text: "a naïve b", Matches: []SearchMatch{{2, 7}}}}},
+		{"word with a separator", "foo.bar", "x foo.bar xfoo.bar",
+			[]SearchSnippet{{Text: "x foo.bar xfoo.bar", Matches: []SearchMatch{{2, 9}}}}},
+		{"word starting with a separator", ".bar", "xfoo.bar",
+			[]SearchSnippet{{Text: "xfoo.bar", Matches: []SearchMatch{{4, 8}}}}},
+		{"phrase inside a token", `"nection"`, "ConnectionTimeout",
+			[]SearchSnippet{{Text: "ConnectionTimeout", Matches: []SearchMatch{{3, 10}}}}},
+		{"phrase", `"connection refused"`, "dial: Connection refused",
+			[]SearchSnippet{{Text: "dial: Connection refused", Matches: []SearchMatch{{6, 24}}}}},
+		{"negated terms aren't highlighted", "timeout -refused", "refused: timeout",
+			[]SearchSnippet{{Text: "refused: timeout", Matches: []SearchMatch{{9, 16}}}}},
+		{"only negated terms", "-timeout", "timeout", nil},
+		{"matches close together share a snippet", "timeout", "timeout a timeout",
+			[]SearchSnippet{{Text: "timeout a timeout", Matches: []SearchMatch{{0, 7}, {10, 17}}}}},
+		{"overlapping matches", `timeout "meo"`, "timeout",
+			[]SearchSnippet{{Text: "timeout", Matches: []SearchMatch{{0, 7}}}}},
+		{"matches far apart", "timeout", farApart, []SearchSnippet{
+			{Text: farApart[:67], Matches: []SearchMatch{{0, 7}}},
+			{Text: farApart[149:], Matches: []SearchMatch{{60, 67}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSearchQuery(tt.search).Highlight(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Highlight(%q) = %#v, want %#v", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("at most MaxSearchSnippets snippets", func(t *testing.T) {
+		snippets := ParseSearchQuery("timeout").Highlight(repeated)
+		if len(snippets) != MaxSearchSnippets {
+			t.Fatalf("Highlight returned %d snippets, want %d", len(snippets), MaxSearchSnippets)
+		}
+		for i, snippet := range snippets {
+			if len(snippet.Matches) != 1 || snippet.Text[snippet.Matches[0].Start:snippet.Matches[0].End] != "timeout" {
+				t.Errorf("snippet %d = %#v, want a single timeout match", i, snippet)
+			}
+		}
+	})
+}
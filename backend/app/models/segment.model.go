@@ -1,17 +1,172 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Well known segment attribute keys, other keys are custom and added by the clients
+const (
+	SegmentAttributeDbSystem    = "db.system"
+	SegmentAttributeDbStatement = "db.statement"
+	SegmentAttributeHttpMethod  = "http.method"
+	SegmentAttributeHttpUrl     = "http.url"
+	SegmentAttributeHttpStatus  = "http.status_code"
+	SegmentAttributeCacheKey    = "cache.key"
+	SegmentAttributeCacheHit    = "cache.hit"
+	SegmentAttributeQueueName   = "messaging.destination"
+)
+
+// SegmentAttributes holds key/value details describing the work a segment performed (query, url, cache key...)
+type SegmentAttributes map[string]string
+
+// Get returns the attribute value or an empty string if it's not set
+func (a SegmentAttributes) Get(key string) string {
+	if a == nil {
+		return ""
+	}
+	return a[key]
+}
+
 type Segment struct {
-	Id            uuid.UUID     `json:"id" ch:"id"`
-	TransactionId uuid.UUID     `json:"transactionId" ch:"transaction_id"`
-	ProjectId     uuid.UUID     `json:"projectId" ch:"project_id"`
-	Name          string        `json:"name" ch:"name"`
-	StartTime     time.Time     `json:"startTime" ch:"start_time"`
-	Duration      time.Duration `json:"duration" ch:"duration"`
-	RecordedAt    time.Time     `json:"recordedAt" ch:"recorded_at"`
+	Id            uuid.UUID         `json:"id" ch:"id"`
+	TransactionId uuid.UUID         `json:"transactionId" ch:"transaction_id"`
+	ProjectId     uuid.UUID         `json:"projectId" ch:"project_id"`
+	ParentId      *uuid.UUID        `json:"parentId" ch:"parent_id"` // nil for segments directly under the transaction
+	Name          string            `json:"name" ch:"name"`
+	StartTime     time.Time         `json:"startTime" ch:"start_time"`
+	Duration      time.Duration     `json:"duration" ch:"duration"`
+	RecordedAt    time.Time         `json:"recordedAt" ch:"recorded_at"`
+	TraceId       string            `json:"traceId" ch:"trace_id"`
+	ParentSpanId  string            `json:"parentSpanId" ch:"parent_span_id"`
+	Attributes    SegmentAttributes `json:"attributes" ch:"attributes"`
+}
+
+// SegmentNode is a segment placed in the transaction's segment tree
+type SegmentNode struct {
+	Segment
+	// SelfTime is the segment duration not covered by any of its children
+	SelfTime time.Duration  `json:"selfTime"`
+	Children []*SegmentNode `json:"children"`
+}
+
+// BuildSegmentTree nests segments under their parents (ordered by start time) and computes self-time
+// for every node. Segments whose parent is missing are treated as top level segments.
+func BuildSegmentTree(segments []Segment) []*SegmentNode {
+	nodes := make(map[uuid.UUID]*SegmentNode, len(segments))
+	ordered := make([]*SegmentNode, 0, len(segments))
+	for _, s := range segments {
+		node := &SegmentNode{Segment: s, Children: []*SegmentNode{}}
+		nodes[s.Id] = node
+		ordered = append(ordered, node)
+	}
+
+	roots := []*SegmentNode{}
+	for _, node := range ordered {
+		if node.ParentId != nil && *node.ParentId != node.Id {
+			if parent, ok := nodes[*node.ParentId]; ok && !isSegmentAncestor(nodes, node.Id, parent) {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	sortSegmentNodes(roots)
+	for _, node := range ordered {
+		sortSegmentNodes(node.Children)
+		node.SelfTime = node.Duration - coveredDuration(node)
+	}
+
+	return roots
+}
+
+// isSegmentAncestor reports whether the segment with the given id is an ancestor of node (guards against cycles)
+func isSegmentAncestor(nodes map[uuid.UUID]*SegmentNode, id uuid.UUID, node *SegmentNode) bool {
+	for depth := 0; node != nil && depth <= len(nodes); depth++ {
+		if node.Id == id {
+			return true
+		}
+		if node.ParentId == nil {
+			return false
+		}
+		node = nodes[*node.ParentId]
+	}
+	return false
+}
+
+func sortSegmentNodes(nodes []*SegmentNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].StartTime.Before(nodes[j].StartTime)
+	})
+}
+
+// coveredDuration returns the union of the children's intervals clipped to the parent's interval,
+// so concurrent children are not subtracted twice
+func coveredDuration(node *SegmentNode) time.Duration {
+	parentStart := node.StartTime
+	parentEnd := node.StartTime.Add(node.Duration)
+
+	var covered time.Duration
+	var currentStart, currentEnd time.Time
+	open := false
+
+	// children are sorted by start time
+	for _, child := range node.Children {
+		start := child.StartTime
+		end := child.StartTime.Add(child.Duration)
+		if start.Before(parentStart) {
+			start = parentStart
+		}
+		if end.After(parentEnd) {
+			end = parentEnd
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		if open && !start.After(currentEnd) {
+			if end.After(currentEnd) {
+				currentEnd = end
+			}
+			continue
+		}
+		if open {
+			covered += currentEnd.Sub(currentStart)
+		}
+		currentStart, currentEnd, open = start, end, true
+	}
+	if open {
+		covered += currentEnd.Sub(currentStart)
+	}
+
+	return covered
+}
+
+// SegmentStats aggregates every segment sharing a name over a time range
+type SegmentStats struct {
+	Name             string        `json:"name"`
+	Count            uint64        `json:"count"`
+	TransactionCount uint64        `json:"transactionCount"`
+	TotalDuration    time.Duration `json:"totalDuration"`
+	AvgDuration      time.Duration `json:"avgDuration"`
+	P50Duration      time.Duration `json:"p50Duration"`
+	P95Duration      time.Duration `json:"p95Duration"`
+	// TransactionTimeShare is the percentage of the containing transactions' time spent in this segment
+	TransactionTimeShare float64 `json:"transactionTimeShare"`
+}
+
+// SegmentTransactionStats aggregates a single segment name within one endpoint or task
+type SegmentTransactionStats struct {
+	TransactionName      string        `json:"transactionName"`
+	TransactionType      string        `json:"transactionType"` // "endpoint" or "task"
+	Count                uint64        `json:"count"`
+	TransactionCount     uint64        `json:"transactionCount"`
+	TotalDuration        time.Duration `json:"totalDuration"`
+	AvgDuration          time.Duration `json:"avgDuration"`
+	P50Duration          time.Duration `json:"p50Duration"`
+	P95Duration          time.Duration `json:"p95Duration"`
+	TransactionTimeShare float64       `json:"transactionTimeShare"`
 }
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SloBurnRateWindows are the windows, in minutes, the burn rate of an SLO is reported over
+var SloBurnRateWindows = []int{5, 30, 60, 120, 360, 1440, 4320}
+
+const (
+	// SloFastBurnBudget and SloSlowBurnBudget are the shares of the error budget that, spent within the fast (1h)
+	// and slow (6h) alert windows, make the default burn rate alerts of an SLO fire
+	SloFastBurnBudget = 0.02
+	SloSlowBurnBudget = 0.05
+)
+
+// Slo is a service level objective over the requests of a group of endpoints. A request is good when its status
+// code is at most MaxGoodStatusCode and, when MaxDurationMs is set, it took at most MaxDurationMs.
+type Slo struct {
+	Id          uuid.UUID `json:"id" ch:"id"`
+	ProjectId   uuid.UUID `json:"projectId" ch:"project_id"`
+	Name        string    `json:"name" ch:"name"`
+	Description string    `json:"description" ch:"description"`
+	// EndpointPatterns match endpoints by name, * matching any characters, every endpoint when empty
+	EndpointPatterns  []string `json:"endpointPatterns" ch:"endpoint_patterns"`
+	MaxGoodStatusCode int      `json:"maxGoodStatusCode" ch:"max_good_status_code"`
+	MaxDurationMs     int      `json:"maxDurationMs" ch:"max_duration_ms"`
+	// Target is the percentage of good requests, e.g. 99.9, always below 100 so there is an error budget
+	Target     float64   `json:"target" ch:"target"`
+	WindowDays int       `json:"windowDays" ch:"window_days"`
+	CreatedAt  time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// SloEventCounts are the requests matched by an SLO in a window
+type SloEventCounts struct {
+	Total uint64 `json:"total"`
+	Good  uint64 `json:"good"`
+}
+
+// SloBurnRate is how fast the error budget was spent in a window, 1 spending exactly the whole budget over the
+// SLO's window
+type SloBurnRate struct {
+	WindowMinutes int     `json:"windowMinutes"`
+	Total         uint64  `json:"total"`
+	Bad           uint64  `json:"bad"`
+	BurnRate      float64 `json:"burnRate"`
+}
+
+// SloStatus is the state of an SLO over its rolling window
+type SloStatus struct {
+	Total uint64 `json:"total"`
+	Good  uint64 `json:"good"`
+	// Sli is the percentage of good requests, 100 without requests
+	Sli float64 `json:"sli"`
+	// ErrorBudget is the number of bad requests the target allows for the requests of the window
+	ErrorBudget float64 `json:"errorBudget"`
+	// BudgetRemaining is the share of the error budget left, negative once the SLO is breached
+	BudgetRemaining float64       `json:"budgetRemaining"`
+	BurnRates       []SloBurnRate `json:"burnRates"`
+}
+
+// Window returns the rolling window of the SLO
+func (s *Slo) Window() time.Duration {
+	return time.Duration(s.WindowDays) * 24 * time.Hour
+}
+
+// AllowedErrorRatio is the share of requests that may be bad, e.g. 0.001 for a 99.9% target
+func (s *Slo) AllowedErrorRatio() float64 {
+	return 1 - s.Target/100
+}
+
+// BurnRate returns the ratio of bad requests in the counts relative to the allowed error ratio, 0 without requests
+func (s *Slo) BurnRate(counts SloEventCounts) float64 {
+	if counts.Total == 0 || s.AllowedErrorRatio() <= 0 {
+		return 0
+	}
+	return float64(counts.Total-counts.Good) / float64(counts.Total) / s.AllowedErrorRatio()
+}
+
+// BurnRateThreshold returns the burn rate at which budgetShare of the error budget is spent within windowMinutes
+func (s *Slo) BurnRateThreshold(budgetShare float64, windowMinutes int) float64 {
+	return budgetShare * s.Window().Minutes() / float64(windowMinutes)
+}
+
+// Status computes the status of the SLO from the counts of its window and of each of SloBurnRateWindows
+func (s *Slo) Status(window SloEventCounts, burnWindows []SloEventCounts) SloStatus {
+	status := SloStatus{
+		Total:       window.Total,
+		Good:        window.Good,
+		Sli:         100,
+		ErrorBudget: float64(window.Total) * s.AllowedErrorRatio(),
+		BurnRates:   make([]SloBurnRate, len(burnWindows)),
+	}
+
+	status.BudgetRemaining = 1
+	if window.Total > 0 {
+		status.Sli = float64(window.Good) * 100 / float64(window.Total)
+		status.BudgetRemaining = 1 - float64(window.Total-window.Good)/status.ErrorBudget
+	}
+
+	for i, counts := range burnWindows {
+		status.BurnRates[i] = SloBurnRate{
+			WindowMinutes: SloBurnRateWindows[i],
+			Total:         counts.Total,
+			Bad:           counts.Total - counts.Good,
+			BurnRate:      s.BurnRate(counts),
+		}
+	}
+	return status
+}
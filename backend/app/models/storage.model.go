@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// TableStorage is the disk usage of a table, read from the active parts of ClickHouse
+type TableStorage struct {
+	Table             string `json:"table"`
+	Rows              uint64 `json:"rows"`
+	BytesOnDisk       uint64 `json:"bytesOnDisk"`
+	UncompressedBytes uint64 `json:"uncompressedBytes"`
+	Partitions        uint64 `json:"partitions"`
+}
+
+// ProjectTableStorage is the share of a table used by a project. Tables hold the rows of every project together so
+// the bytes are estimated from the project's share of the rows.
+type ProjectTableStorage struct {
+	Table                string `json:"table"`
+	Rows                 uint64 `json:"rows"`
+	EstimatedBytesOnDisk uint64 `json:"estimatedBytesOnDisk"`
+}
+
+type ProjectStorage struct {
+	ProjectId            uuid.UUID             `json:"projectId"`
+	Name                 string                `json:"name"`
+	Rows                 uint64                `json:"rows"`
+	EstimatedBytesOnDisk uint64                `json:"estimatedBytesOnDisk"`
+	Tables               []ProjectTableStorage `json:"tables"`
+}
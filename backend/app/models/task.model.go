@@ -1,6 +1,7 @@
 package models
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,14 @@ type Task struct {
 	Scope      map[string]string `json:"scope" ch:"scope"`
 	AppVersion string            `json:"appVersion" ch:"app_version"`
 	ServerName string            `json:"serverName" ch:"server_name"`
+	// TraceId and ParentSpanId link this task to the distributed trace it belongs to
+	TraceId      string `json:"traceId" ch:"trace_id"`
+	ParentSpanId string `json:"parentSpanId" ch:"parent_span_id"`
+}
+
+// TaskUrl returns the link to a task in the UI
+func TaskUrl(taskName string) string {
+	return getBackendUrl() + "/tasks/" + url.PathEscape(taskName)
 }
 
 type TaskStats struct {
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TaskScheduleCron expects a run at every time of the cron expression
+	TaskScheduleCron = "cron"
+	// TaskScheduleInterval expects a run at most IntervalSeconds after the previous one
+	TaskScheduleInterval = "interval"
+)
+
+const (
+	// TaskMonitorStatusPending is the status of a monitor until its first run is due
+	TaskMonitorStatusPending = "pending"
+	TaskMonitorStatusOk      = "ok"
+	// TaskMonitorStatusMissed is the status of a monitor whose last expected run didn't happen within the grace period
+	TaskMonitorStatusMissed = "missed"
+)
+
+const (
+	// TaskMonitorEventMissed is recorded when an expected run didn't happen within the grace period
+	TaskMonitorEventMissed = "missed"
+	// TaskMonitorEventLate is recorded when a missed run happens after all, before the next one is due
+	TaskMonitorEventLate = "late"
+	// TaskMonitorEventRecovered is recorded when a task that missed a run runs again on a later schedule
+	TaskMonitorEventRecovered = "recovered"
+	// TaskMonitorEventDurationOutlier is recorded for a run that took much longer than usual, or than MaxDurationMs
+	TaskMonitorEventDurationOutlier = "duration_outlier"
+)
+
+const (
+	// TaskCheckInInProgress starts a run, TaskCheckInOk and TaskCheckInError finish it (or record a run on their own)
+	TaskCheckInInProgress = "in_progress"
+	TaskCheckInOk         = "ok"
+	TaskCheckInError      = "error"
+)
+
+// MinTaskDurationSamples is the number of past runs needed before runs are compared against the usual duration
+const MinTaskDurationSamples = 10
+
+// TaskMonitor is the expected schedule of a task, declared so that a task that stops running is noticed
+type TaskMonitor struct {
+	Id           uuid.UUID `json:"id" ch:"id"`
+	ProjectId    uuid.UUID `json:"projectId" ch:"project_id"`
+	TaskName     string    `json:"taskName" ch:"task_name"`
+	ScheduleType string    `json:"scheduleType" ch:"schedule_type"`
+	// CronExpression is evaluated in Timezone, set for cron schedules
+	CronExpression string `json:"cronExpression,omitempty" ch:"cron_expression"`
+	Timezone       string `json:"timezone" ch:"timezone"`
+	// IntervalSeconds is set for interval schedules
+	IntervalSeconds int `json:"intervalSeconds,omitempty" ch:"interval_seconds"`
+	// GraceSeconds is how late a run may be before it is reported missed
+	GraceSeconds int `json:"graceSeconds" ch:"grace_seconds"`
+	// MaxDurationMs flags longer runs as outliers, when 0 runs are compared against the durations of the last 7 days
+	MaxDurationMs int       `json:"maxDurationMs" ch:"max_duration_ms"`
+	Enabled       bool      `json:"enabled" ch:"enabled"`
+	CreatedAt     time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// TaskMonitorState is the outcome of the last evaluation of a monitor
+type TaskMonitorState struct {
+	MonitorId uuid.UUID  `json:"monitorId" ch:"monitor_id"`
+	ProjectId uuid.UUID  `json:"projectId" ch:"project_id"`
+	Status    string     `json:"status" ch:"status"`
+	LastRunAt *time.Time `json:"lastRunAt" ch:"last_run_at"`
+	// MissedExpectedAt is when the run reported missed was due, set while the status is missed
+	MissedExpectedAt *time.Time `json:"missedExpectedAt" ch:"missed_expected_at"`
+	LastCheckedAt    time.Time  `json:"lastCheckedAt" ch:"last_checked_at"`
+}
+
+// TaskMonitorEvent is a missed, late or recovered run, or a run duration outlier
+type TaskMonitorEvent struct {
+	Id         uuid.UUID  `json:"id" ch:"id"`
+	MonitorId  uuid.UUID  `json:"monitorId" ch:"monitor_id"`
+	ProjectId  uuid.UUID  `json:"projectId" ch:"project_id"`
+	TaskName   string     `json:"taskName" ch:"task_name"`
+	EventType  string     `json:"eventType" ch:"event_type"`
+	ExpectedAt *time.Time `json:"expectedAt" ch:"expected_at"`
+	RunAt      *time.Time `json:"runAt" ch:"run_at"`
+	DurationMs float64    `json:"durationMs" ch:"duration_ms"`
+	CreatedAt  time.Time  `json:"createdAt" ch:"created_at"`
+}
+
+// TaskCheckIn is a run reported through the check-in or heartbeat API by a job without the SDK
+type TaskCheckIn struct {
+	Id         uuid.UUID     `json:"id" ch:"id"`
+	ProjectId  uuid.UUID     `json:"projectId" ch:"project_id"`
+	TaskName   string        `json:"taskName" ch:"task_name"`
+	CheckInId  uuid.UUID     `json:"checkInId" ch:"check_in_id"`
+	Status     string        `json:"status" ch:"status"`
+	Duration   time.Duration `json:"duration" ch:"duration"`
+	RecordedAt time.Time     `json:"recordedAt" ch:"recorded_at"`
+}
+
+// TaskRun is a recorded run of a task
+type TaskRun struct {
+	RecordedAt time.Time
+	DurationMs float64
+}
+
+// Location returns the time zone of the monitor's cron expression
+func (m *TaskMonitor) Location() *time.Location {
+	if location, err := time.LoadLocation(m.Timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+// Grace returns how late a run may be
+func (m *TaskMonitor) Grace() time.Duration {
+	return time.Duration(m.GraceSeconds) * time.Second
+}
+
+// ExpectedRun returns the last run that was due at or before t, with the one due before it, or false when no run
+// was due yet. Interval schedules are due IntervalSeconds after the last run (or the creation of the monitor).
+func (m *TaskMonitor) ExpectedRun(t time.Time, lastRunAt *time.Time) (expected, previous time.Time, ok bool) {
+	switch m.ScheduleType {
+	case TaskScheduleInterval:
+		interval := time.Duration(m.IntervalSeconds) * time.Second
+		from := m.CreatedAt
+		if lastRunAt != nil && lastRunAt.After(from) {
+			from = *lastRunAt
+		}
+		expected = from.Add(interval)
+		return expected, from, !expected.After(t)
+	case TaskScheduleCron:
+		schedule, err := ParseCronSchedule(m.CronExpression, m.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		expected = schedule.Prev(t)
+		if expected.IsZero() || expected.Before(m.CreatedAt) {
+			return time.Time{}, time.Time{}, false
+		}
+		return expected, schedule.Prev(expected.Add(-time.Minute)), true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// NextRun returns when the next run is due after t, the zero time when it can't be known
+func (m *TaskMonitor) NextRun(t time.Time, lastRunAt *time.Time) time.Time {
+	switch m.ScheduleType {
+	case TaskScheduleInterval:
+		from := m.CreatedAt
+		if lastRunAt != nil && lastRunAt.After(from) {
+			from = *lastRunAt
+		}
+		return from.Add(time.Duration(m.IntervalSeconds) * time.Second)
+	case TaskScheduleCron:
+		schedule, err := ParseCronSchedule(m.CronExpression, m.Location())
+		if err != nil {
+			return time.Time{}
+		}
+		return schedule.Next(t)
+	}
+	return time.Time{}
+}
+
+// Evaluate returns the monitor's state at now given the time of the task's last run, and the missed, late or
+// recovered event when the status changed. A run counts for the run due at E when it happened after the
+// midpoint between the run due before E and E, so jobs running a little early aren't reported missed.
+func (m *TaskMonitor) Evaluate(current TaskMonitorState, lastRunAt *time.Time, now time.Time) (TaskMonitorState, *TaskMonitorEvent) {
+	next := current
+	next.MonitorId = m.Id
+	next.ProjectId = m.ProjectId
+	next.LastRunAt = lastRunAt
+	next.LastCheckedAt = now
+	if next.Status == "" {
+		next.Status = TaskMonitorStatusPending
+	}
+
+	event := func(eventType string, expectedAt, runAt *time.Time) *TaskMonitorEvent {
+		return &TaskMonitorEvent{
+			Id:         uuid.New(),
+			MonitorId:  m.Id,
+			ProjectId:  m.ProjectId,
+			TaskName:   m.TaskName,
+			EventType:  eventType,
+			ExpectedAt: expectedAt,
+			RunAt:      runAt,
+			CreatedAt:  now,
+		}
+	}
+
+	// a run after the missed one settles it, late when it came before the next run was due
+	if next.Status == TaskMonitorStatusMissed && next.MissedExpectedAt != nil && lastRunAt != nil && lastRunAt.After(*next.MissedExpectedAt) {
+		missedAt := *next.MissedExpectedAt
+		next.Status = TaskMonitorStatusOk
+		next.MissedExpectedAt = nil
+		eventType := TaskMonitorEventRecovered
+		if nextDue := m.NextRun(missedAt, &missedAt); m.ScheduleType == TaskScheduleInterval || !lastRunAt.After(nextDue) {
+			eventType = TaskMonitorEventLate
+		}
+		return next, event(eventType, &missedAt, lastRunAt)
+	}
+
+	expected, previous, ok := m.ExpectedRun(now.Add(-m.Grace()), lastRunAt)
+	if !ok {
+		if lastRunAt != nil && next.Status == TaskMonitorStatusPending {
+			next.Status = TaskMonitorStatusOk
+		}
+		return next, nil
+	}
+
+	windowStart := expected
+	if !previous.IsZero() {
+		windowStart = previous.Add(expected.Sub(previous) / 2)
+	}
+	if lastRunAt != nil && !lastRunAt.Before(windowStart) {
+		next.Status = TaskMonitorStatusOk
+		return next, nil
+	}
+
+	if next.Status == TaskMonitorStatusMissed && next.MissedExpectedAt != nil && !next.MissedExpectedAt.Before(expected) {
+		return next, nil
+	}
+	// a later run went missed as well, it is reported too
+	next.Status = TaskMonitorStatusMissed
+	next.MissedExpectedAt = &expected
+	return next, event(TaskMonitorEventMissed, &expected, nil)
+}
+
+// DurationOutlierThreshold returns the duration in ms above which a run is an outlier, MaxDurationMs when set and
+// otherwise a robust z-score of DefaultAnomalyThreshold over the past durations. False without enough samples.
+func (m *TaskMonitor) DurationOutlierThreshold(pastDurationsMs []float64) (float64, bool) {
+	if m.MaxDurationMs > 0 {
+		return float64(m.MaxDurationMs), true
+	}
+	if len(pastDurationsMs) < MinTaskDurationSamples {
+		return 0, false
+	}
+	slot := newAnomalyBaselineSlot(0, pastDurationsMs, 1)
+	return slot.Median + DefaultAnomalyThreshold*slot.Deviation, true
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TraceSpanKindEndpoint = "endpoint"
+	TraceSpanKindTask     = "task"
+	TraceSpanKindSegment  = "segment"
+)
+
+// TraceIdFromUUID returns the W3C trace-id (32 lowercase hex chars) derived from a transaction id.
+// Transactions that were not started from an incoming traceparent become the root of their own trace.
+func TraceIdFromUUID(id uuid.UUID) string {
+	return strings.ReplaceAll(id.String(), "-", "")
+}
+
+// SpanIdFromUUID returns the W3C parent-id (16 lowercase hex chars) used to reference a transaction or segment
+// from a downstream service's traceparent header
+func SpanIdFromUUID(id uuid.UUID) string {
+	return TraceIdFromUUID(id)[:16]
+}
+
+// TraceSpan is a single row in the trace waterfall, either a transaction (endpoint/task) or one of its segments
+type TraceSpan struct {
+	SpanId        string        `json:"spanId"`
+	ParentSpanId  string        `json:"parentSpanId"`
+	Kind          string        `json:"kind"` // "endpoint", "task" or "segment"
+	Name          string        `json:"name"`
+	ProjectId     uuid.UUID     `json:"projectId"`
+	ProjectName   string        `json:"projectName"`
+	TransactionId uuid.UUID     `json:"transactionId"`
+	StartTime     time.Time     `json:"startTime"`
+	Duration      time.Duration `json:"duration"`
+	Offset        time.Duration `json:"offset"` // start time relative to the start of the trace
+	Depth         int           `json:"depth"`
+	StatusCode    int16         `json:"statusCode,omitempty"`
+	ServerName    string        `json:"serverName,omitempty"`
+}
+
+// Trace is the assembled waterfall of every transaction and segment sharing a trace id
+type Trace struct {
+	TraceId    string        `json:"traceId"`
+	StartTime  time.Time     `json:"startTime"`
+	Duration   time.Duration `json:"duration"`
+	ProjectIds []uuid.UUID   `json:"projectIds"`
+	Spans      []TraceSpan   `json:"spans"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UptimeServerName is the server name of the issues opened by failing uptime checks
+const UptimeServerName = "traceway-uptime"
+
+// UptimeCheckMethods are the HTTP methods a check can use, checks never send a body
+var UptimeCheckMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"OPTIONS": true,
+}
+
+// UptimeCheck is an HTTP request the backend sends on an interval to check that a URL is up
+type UptimeCheck struct {
+	Id              uuid.UUID `json:"id" ch:"id"`
+	ProjectId       uuid.UUID `json:"projectId" ch:"project_id"`
+	Name            string    `json:"name" ch:"name"`
+	Url             string    `json:"url" ch:"url"`
+	Method          string    `json:"method" ch:"method"`
+	IntervalSeconds int       `json:"intervalSeconds" ch:"interval_seconds"`
+	// ExpectedStatus is the status code the response must have, any 2xx when 0
+	ExpectedStatus int `json:"expectedStatus" ch:"expected_status"`
+	// BodyMatch is a string the response body must contain, not checked when empty
+	BodyMatch string `json:"bodyMatch" ch:"body_match"`
+	TimeoutMs int    `json:"timeoutMs" ch:"timeout_ms"`
+	// CreateIssues records every failure as an occurrence of an issue of the check
+	CreateIssues bool      `json:"createIssues" ch:"create_issues"`
+	Enabled      bool      `json:"enabled" ch:"enabled"`
+	CreatedAt    time.Time `json:"createdAt" ch:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" ch:"updated_at"`
+}
+
+// UptimeResult is the outcome of one run of a check
+type UptimeResult struct {
+	Id         uuid.UUID `json:"id" ch:"id"`
+	CheckId    uuid.UUID `json:"checkId" ch:"check_id"`
+	ProjectId  uuid.UUID `json:"projectId" ch:"project_id"`
+	CheckedAt  time.Time `json:"checkedAt" ch:"checked_at"`
+	Success    bool      `json:"success" ch:"success"`
+	StatusCode int       `json:"statusCode" ch:"status_code"`
+	DurationMs float64   `json:"durationMs" ch:"duration_ms"`
+	Error      string    `json:"error" ch:"error"`
+	// TlsExpiresAt is when the certificate of an https URL expires
+	TlsExpiresAt *time.Time `json:"tlsExpiresAt" ch:"tls_expires_at"`
+}
+
+// UptimeCheckStats summarize the results of a check over a time range
+type UptimeCheckStats struct {
+	Total    uint64 `json:"total"`
+	Failures uint64 `json:"failures"`
+	// Availability is the percentage of successful runs, 100 without runs
+	Availability  float64 `json:"availability"`
+	AvgDurationMs float64 `json:"avgDurationMs"`
+	P95DurationMs float64 `json:"p95DurationMs"`
+}
+
+// StatusMatches reports whether the status code is the one the check expects
+func (c *UptimeCheck) StatusMatches(statusCode int) bool {
+	if c.ExpectedStatus == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	return statusCode == c.ExpectedStatus
+}
+
+// Interval returns how often the check runs
+func (c *UptimeCheck) Interval() time.Duration {
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// Timeout returns how long a run may take before it fails
+func (c *UptimeCheck) Timeout() time.Duration {
+	return time.Duration(c.TimeoutMs) * time.Millisecond
+}
+
+// IssueHash is the exception hash of the issue failures of the check are grouped under
+func (c *UptimeCheck) IssueHash() string {
+	hash := sha256.Sum256([]byte("uptime:" + c.Id.String()))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// FailureStackTrace records a failed run as an occurrence of the check's issue
+func (c *UptimeCheck) FailureStackTrace(result *UptimeResult) ExceptionStackTrace {
+	return ExceptionStackTrace{
+		Id:              uuid.New(),
+		ProjectId:       c.ProjectId,
+		TransactionType: "uptime",
+		ExceptionHash:   c.IssueHash(),
+		StackTrace:      fmt.Sprintf("UptimeCheckFailed: %s (%s %s)\n%s", c.Name, c.Method, c.Url, result.Error),
+		RecordedAt:      result.CheckedAt,
+		ServerName:      UptimeServerName,
+	}
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"backend/app/models"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+var errNoRecipients = errors.New("the channel has no recipients")
+
+// smtpConfig is read from SMTP_HOST, SMTP_PORT (587 by default), SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM.
+// Authentication is skipped when no username is set, which lets a local SMTP stand-in be used for testing.
+type smtpConfig struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func loadSmtpConfig() (*smtpConfig, error) {
+	config := &smtpConfig{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     os.Getenv("SMTP_PORT"),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+	if config.host == "" {
+		return nil, errors.New("SMTP_HOST is not configured")
+	}
+	if config.port == "" {
+		config.port = "587"
+	}
+	if config.from == "" {
+		config.from = "traceway@" + config.host
+	}
+	return config, nil
+}
+
+func sendEmail(channel *models.NotificationChannel, delivery *models.NotificationDelivery) error {
+	if len(channel.Config.Recipients) == 0 {
+		return errNoRecipients
+	}
+
+	config, err := loadSmtpConfig()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if config.username != "" {
+		auth = smtp.PlainAuth("", config.username, config.password, config.host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(config.host, config.port), auth, config.from, channel.Config.Recipients, buildEmail(config.from, channel.Config.Recipients, delivery))
+}
+
+// buildEmail builds a plain text RFC 5322 message, stripping line breaks from the header values
+func buildEmail(from string, to []string, delivery *models.NotificationDelivery) []byte {
+	headerValue := strings.NewReplacer("\r", " ", "\n", " ")
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", headerValue.Replace(from))
+	fmt.Fprintf(&msg, "To: %s\r\n", headerValue.Replace(strings.Join(to, ", ")))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerValue.Replace(delivery.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@traceway>\r\n", delivery.Id)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(delivery.Body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
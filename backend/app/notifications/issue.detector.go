@@ -0,0 +1,200 @@
+package notifications
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/repositories"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// issueClaimTTL is how long a detected issue event is remembered, long enough for the async insert of the
+// occurrence to become visible so concurrent reports of the same hash don't notify twice
+const issueClaimTTL = 10 * time.Minute
+
+type issueClaims struct {
+	claims  map[string]time.Time
+	sweptAt time.Time
+	mu      sync.Mutex
+}
+
+var detectedIssues = &issueClaims{claims: make(map[string]time.Time)}
+
+// claim reports whether the key wasn't claimed in the last issueClaimTTL, and claims it. Expired claims are
+// swept at most once per issueClaimTTL.
+func (i *issueClaims) claim(key string, now time.Time) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if now.Sub(i.sweptAt) > issueClaimTTL {
+		for k, claimedAt := range i.claims {
+			if now.Sub(claimedAt) > issueClaimTTL {
+				delete(i.claims, k)
+			}
+		}
+		i.sweptAt = now
+	}
+	if claimedAt, ok := i.claims[key]; ok && now.Sub(claimedAt) <= issueClaimTTL {
+		return false
+	}
+	i.claims[key] = now
+	return true
+}
+
+// DetectIssueEvents returns an issue.new event for every exception hash never seen in the project before and
+// an issue.regression event for every resolved issue that occurs again after it was resolved, unresolving it.
+// It must run before the stack traces are inserted. Messages aren't issues and are skipped.
+func DetectIssueEvents(ctx context.Context, projectId uuid.UUID, stackTraces []models.ExceptionStackTrace) ([]models.NotificationEvent, error) {
+	// the first occurrence of each hash in the report is the one that gets reported
+	first := make(map[string]*models.ExceptionStackTrace)
+	hashes := make([]string, 0)
+	for i := range stackTraces {
+		est := &stackTraces[i]
+		if est.IsMessage {
+			continue
+		}
+		if existing, ok := first[est.ExceptionHash]; ok {
+			if est.RecordedAt.Before(existing.RecordedAt) {
+				first[est.ExceptionHash] = est
+			}
+			continue
+		}
+		first[est.ExceptionHash] = est
+		hashes = append(hashes, est.ExceptionHash)
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	history, err := repositories.ExceptionStackTraceRepository.FindIssueHistory(ctx, projectId, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var saved []models.Issue
+	var newHashes []string
+	regressions := make(map[string]*models.IssueActivity)
+	for _, hash := range hashes {
+		est := first[hash]
+		h := history[hash]
+
+		// every hash gets an issue when first seen, so the next reports find it in the issues
+		if h.Issue == nil {
+			issue := models.NewIssue(projectId, hash)
+			issue.CreatedAt = est.RecordedAt
+			saved = append(saved, issue)
+		}
+		if !h.Seen {
+			if detectedIssues.claim(projectId.String()+"/new/"+hash, now) {
+				newHashes = append(newHashes, hash)
+			}
+			continue
+		}
+
+		regression, err := isIssueRegression(ctx, h.Issue, est)
+		if err != nil {
+			return nil, err
+		}
+		// keyed by the resolve time so an issue that is resolved again can regress again
+		if regression && detectedIssues.claim(projectId.String()+"/regression/"+hash+"/"+h.Issue.ResolvedAt.String(), now) {
+			issue := *h.Issue
+			regressions[hash] = issue.Regress(est.RecordedAt)
+			saved = append(saved, issue)
+		}
+	}
+
+	// an issue changed concurrently was created or updated by someone else, who reports it
+	conflicts, err := repositories.IssueRepository.Save(ctx, saved)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.NotificationEvent
+	var activities []models.IssueActivity
+	for _, hash := range newHashes {
+		if !conflicts[models.IssueKey(projectId, hash)] {
+			events = append(events, issueNotificationEvent(models.NotificationEventIssueNew, first[hash]))
+		}
+	}
+	for _, hash := range hashes {
+		activity, ok := regressions[hash]
+		if ok && !conflicts[models.IssueKey(projectId, hash)] {
+			events = append(events, issueNotificationEvent(models.NotificationEventIssueRegression, first[hash]))
+			activities = append(activities, *activity)
+		}
+	}
+
+	if err := repositories.IssueRepository.InsertActivities(ctx, activities); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// isIssueRegression reports whether the occurrence reopens the resolved issue. Issues resolved in the next release
+// only reopen on an app version first reported after they were resolved, occurrences on the versions still
+// running at the time are expected.
+func isIssueRegression(ctx context.Context, issue *models.Issue, est *models.ExceptionStackTrace) (bool, error) {
+	if issue == nil || issue.Status != models.IssueStatusResolved {
+		return false, nil
+	}
+
+	var versionFirstSeen *time.Time
+	if issue.Resolution == models.IssueResolutionNextRelease && est.AppVersion != "" && est.AppVersion != issue.ResolvedInVersion {
+		firstSeen, err := cache.AppVersionCache.FirstSeen(ctx, issue.ProjectId, est.AppVersion)
+		if err != nil {
+			return false, err
+		}
+		versionFirstSeen = firstSeen
+	}
+	return issue.IsRegression(est.RecordedAt, est.AppVersion, versionFirstSeen), nil
+}
+
+// NotifyIssueEvents queues the issue events for the project's channels, failing to do so doesn't fail the report
+// or the check that recorded them
+func NotifyIssueEvents(ctx context.Context, events []models.NotificationEvent) {
+	for _, event := range events {
+		if err := Notify(ctx, event); err != nil {
+			log.Printf("Error queueing %s notification for %s: %v", event.Type, event.Fields["exceptionHash"], err)
+		}
+	}
+}
+
+func issueNotificationEvent(eventType string, est *models.ExceptionStackTrace) models.NotificationEvent {
+	title := strings.TrimSpace(strings.SplitN(est.StackTrace, "\n", 2)[0])
+	if runes := []rune(title); len(runes) > 200 {
+		title = string(runes[:200]) + "…"
+	}
+
+	message := "A new issue was seen for the first time."
+	if eventType == models.NotificationEventIssueRegression {
+		message = "A resolved issue occurred again."
+	}
+
+	appVersion := est.AppVersion
+	if appVersion == "" {
+		appVersion = "unknown"
+	}
+
+	fields := map[string]string{
+		"exceptionHash": est.ExceptionHash,
+		"appVersion":    appVersion,
+	}
+	if est.ServerName != "" {
+		fields["serverName"] = est.ServerName
+	}
+
+	return models.NotificationEvent{
+		Type:       eventType,
+		ProjectId:  est.ProjectId,
+		Title:      title,
+		Message:    message,
+		Fields:     fields,
+		Url:        models.IssueUrl(est.ExceptionHash),
+		OccurredAt: est.RecordedAt,
+	}
+}
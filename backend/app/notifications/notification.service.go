@@ -0,0 +1,202 @@
+package notifications
+
+import (
+	"backend/app/cache"
+	"backend/app/models"
+	"backend/app/repositories"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxDeliveryAttempts is the number of times a delivery is tried before it is marked as failed
+	MaxDeliveryAttempts = 6
+	// retryBaseDelay is the delay before the first retry, doubled on every following one up to retryMaxDelay
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+const (
+	defaultSubjectTemplate = `[{{.ProjectName}}] {{.Title}}`
+	defaultBodyTemplate    = `{{.Message}}
+{{range $key, $value := .Fields}}
+{{$key}}: {{$value}}{{end}}{{if .Url}}
+
+{{.Url}}{{end}}`
+)
+
+// Notify queues the event for every enabled channel of its project subscribed to the event type,
+// the deliveries are sent by the notification dispatcher job
+func Notify(ctx context.Context, event models.NotificationEvent) error {
+	prepareEvent(&event)
+
+	channels, err := repositories.NotificationChannelRepository.FindEnabled(ctx, event.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.NotificationDelivery
+	for i := range channels {
+		channel := &channels[i]
+		if !channel.Subscribes(event.Type) {
+			continue
+		}
+		delivery, err := newDelivery(channel, &event)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return repositories.NotificationDeliveryRepository.Save(ctx, deliveries)
+}
+
+// SendTest sends a test event to the channel right away, without retries, and records it in the delivery log
+func SendTest(ctx context.Context, channel *models.NotificationChannel) (*models.NotificationDelivery, error) {
+	event := models.NotificationEvent{
+		Type:      models.NotificationEventTest,
+		ProjectId: channel.ProjectId,
+		Title:     "Test notification",
+		Message:   fmt.Sprintf("This is a test notification for the %q channel.", channel.Name),
+	}
+	prepareEvent(&event)
+
+	delivery, err := newDelivery(channel, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	responseCode, sendErr := Deliver(ctx, channel, delivery)
+	delivery.Attempts = 1
+	delivery.ResponseCode = uint16(responseCode)
+	if sendErr != nil {
+		delivery.Status = models.NotificationDeliveryFailed
+		delivery.LastError = sendErr.Error()
+	} else {
+		delivery.Status = models.NotificationDeliveryDelivered
+	}
+
+	if err := repositories.NotificationDeliveryRepository.Save(ctx, []models.NotificationDelivery{*delivery}); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Deliver sends a delivery to its channel and returns the HTTP status code when the channel is HTTP based
+func Deliver(ctx context.Context, channel *models.NotificationChannel, delivery *models.NotificationDelivery) (int, error) {
+	switch channel.Type {
+	case models.NotificationChannelWebhook:
+		return sendWebhook(ctx, channel, delivery)
+	case models.NotificationChannelSlack:
+		return sendSlack(ctx, channel, delivery)
+	case models.NotificationChannelTeams:
+		return sendTeams(ctx, channel, delivery)
+	case models.NotificationChannelEmail:
+		return 0, sendEmail(channel, delivery)
+	}
+	return 0, fmt.Errorf("unsupported channel type %q", channel.Type)
+}
+
+// RecordAttempt updates a queued delivery with the outcome of a send, scheduling a retry with exponential
+// backoff until MaxDeliveryAttempts is reached
+func RecordAttempt(delivery *models.NotificationDelivery, responseCode int, sendErr error, now time.Time) {
+	delivery.Attempts++
+	delivery.ResponseCode = uint16(responseCode)
+	if sendErr == nil {
+		delivery.Status = models.NotificationDeliveryDelivered
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= MaxDeliveryAttempts {
+		delivery.Status = models.NotificationDeliveryFailed
+		return
+	}
+
+	delay := retryBaseDelay << (delivery.Attempts - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	delivery.NextAttemptAt = now.Add(delay)
+}
+
+// ValidateTemplates checks that the channel's custom templates parse and render against a sample event
+func ValidateTemplates(config *models.NotificationChannelConfig) error {
+	sample := models.NotificationEvent{
+		Type:        models.NotificationEventTest,
+		ProjectName: "project",
+		Title:       "title",
+		Message:     "message",
+		Fields:      map[string]string{"key": "value"},
+		Url:         "https://example.com",
+		OccurredAt:  time.Now(),
+	}
+	if _, err := renderTemplate(config.SubjectTemplate, defaultSubjectTemplate, &sample); err != nil {
+		return fmt.Errorf("subjectTemplate: %w", err)
+	}
+	if _, err := renderTemplate(config.BodyTemplate, defaultBodyTemplate, &sample); err != nil {
+		return fmt.Errorf("bodyTemplate: %w", err)
+	}
+	return nil
+}
+
+func prepareEvent(event *models.NotificationEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if event.ProjectName == "" {
+		if project := cache.ProjectCache.GetById(event.ProjectId); project != nil {
+			event.ProjectName = project.Name
+		}
+	}
+}
+
+// newDelivery renders the event with the channel's templates into a pending delivery
+func newDelivery(channel *models.NotificationChannel, event *models.NotificationEvent) (*models.NotificationDelivery, error) {
+	subject, err := renderTemplate(channel.Config.SubjectTemplate, defaultSubjectTemplate, event)
+	if err != nil {
+		return nil, err
+	}
+	body, err := renderTemplate(channel.Config.BodyTemplate, defaultBodyTemplate, event)
+	if err != nil {
+		return nil, err
+	}
+	eventJson, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationDelivery{
+		Id:            uuid.New(),
+		ProjectId:     channel.ProjectId,
+		ChannelId:     channel.Id,
+		EventType:     event.Type,
+		Subject:       strings.TrimSpace(subject),
+		Body:          strings.TrimSpace(body),
+		Event:         string(eventJson),
+		Status:        models.NotificationDeliveryPending,
+		NextAttemptAt: event.OccurredAt,
+	}, nil
+}
+
+func renderTemplate(text, fallback string, event *models.NotificationEvent) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New("notification").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, event); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
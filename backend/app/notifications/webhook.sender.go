@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"backend/app/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookPayload is the body of generic webhook notifications
+type WebhookPayload struct {
+	DeliveryId string          `json:"deliveryId"`
+	Subject    string          `json:"subject"`
+	Body       string          `json:"body"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>", sent as "X-Traceway-Signature: sha256=<hex>" with
+// the timestamp in X-Traceway-Timestamp so receivers can verify the sender and reject replays
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sendWebhook(ctx context.Context, channel *models.NotificationChannel, delivery *models.NotificationDelivery) (int, error) {
+	body, err := json.Marshal(WebhookPayload{
+		DeliveryId: delivery.Id.String(),
+		Subject:    delivery.Subject,
+		Body:       delivery.Body,
+		Event:      json.RawMessage(delivery.Event),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	headers := map[string]string{
+		"X-Traceway-Event":    delivery.EventType,
+		"X-Traceway-Delivery": delivery.Id.String(),
+	}
+	for key, value := range channel.Config.Headers {
+		headers[key] = value
+	}
+	if channel.Config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-Traceway-Timestamp"] = timestamp
+		headers["X-Traceway-Signature"] = "sha256=" + Sign(channel.Config.Secret, timestamp, body)
+	}
+
+	return postJson(ctx, channel.Config.Url, body, headers)
+}
+
+// sendSlack posts to a Slack incoming webhook, also accepted by Slack-compatible ones (Mattermost, Rocket.Chat, Discord's /slack endpoint)
+func sendSlack(ctx context.Context, channel *models.NotificationChannel, delivery *models.NotificationDelivery) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"text": "*" + delivery.Subject + "*\n" + delivery.Body,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return postJson(ctx, channel.Config.Url, body, nil)
+}
+
+// sendTeams posts a MessageCard to a Microsoft Teams incoming webhook
+func sendTeams(ctx context.Context, channel *models.NotificationChannel, delivery *models.NotificationDelivery) (int, error) {
+	themeColor := "0078D7"
+	switch delivery.EventType {
+	case models.NotificationEventAlertFiring, models.NotificationEventIssueRegression, models.NotificationEventTaskMissed:
+		themeColor = "D70000"
+	case models.NotificationEventIssueNew, models.NotificationEventIssueUnsnoozed, models.NotificationEventTaskLate, models.NotificationEventTaskDurationOutlier:
+		themeColor = "FF8C00"
+	case models.NotificationEventAlertResolved, models.NotificationEventTaskRecovered:
+		themeColor = "2EB886"
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    delivery.Subject,
+		"title":      delivery.Subject,
+		"text":       delivery.Body,
+		"themeColor": themeColor,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return postJson(ctx, channel.Config.Url, body, nil)
+}
+
+// postJson posts the body and treats any non-2xx response as a failure
+func postJson(ctx context.Context, url string, body []byte, headers map[string]string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Traceway-Notifications")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response %d: %s", resp.StatusCode, bytes.TrimSpace(responseBody))
+	}
+	return resp.StatusCode, nil
+}
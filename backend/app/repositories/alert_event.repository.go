@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+type alertEventRepository struct{}
+
+func (r *alertEventRepository) InsertAsync(ctx context.Context, events []models.AlertEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO alert_events (id, rule_id, project_id, rule_name, from_state, to_state, value, threshold, created_at)")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if err := batch.Append(e.Id, e.RuleId, e.ProjectId, e.RuleName, e.FromState, e.ToState, e.Value, e.Threshold, e.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindByProject returns the state transitions of a project's rules (of a single rule when ruleId is set), most recent first
+func (r *alertEventRepository) FindByProject(ctx context.Context, projectId uuid.UUID, ruleId *uuid.UUID, page, pageSize int) ([]models.AlertEvent, int64, error) {
+	offset := (page - 1) * pageSize
+
+	whereClause := "project_id = ?"
+	args := []interface{}{projectId}
+	if ruleId != nil {
+		whereClause += " AND rule_id = ?"
+		args = append(args, *ruleId)
+	}
+
+	var count uint64
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM alert_events WHERE "+whereClause, args...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx,
+		"SELECT id, rule_id, project_id, rule_name, from_state, to_state, value, threshold, created_at FROM alert_events WHERE "+whereClause+" ORDER BY created_at DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []models.AlertEvent{}
+	for rows.Next() {
+		var e models.AlertEvent
+		if err := rows.Scan(&e.Id, &e.RuleId, &e.ProjectId, &e.RuleName, &e.FromState, &e.ToState, &e.Value, &e.Threshold, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+
+	return events, int64(count), nil
+}
+
+var AlertEventRepository = alertEventRepository{}
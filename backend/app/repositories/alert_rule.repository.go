@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrAlertRuleNotFound = errors.New("alert rule not found")
+
+type alertRuleRepository struct{}
+
+const alertRuleColumns = "id, project_id, name, rule_type, metric_name, aggregation, metric_filter, endpoint, operation_type, anomaly_metric, slo_id, comparison, threshold, recovery_threshold, window_minutes, for_minutes, enabled, created_at, updated_at"
+
+// Save inserts a new version of the rule, replacing the previous one on merge
+func (r *alertRuleRepository) Save(ctx context.Context, rule *models.AlertRule) error {
+	filter, err := json.Marshal(rule.MetricFilter)
+	if err != nil {
+		return err
+	}
+	rule.UpdatedAt = time.Now()
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = rule.UpdatedAt
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO alert_rules ("+alertRuleColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		rule.Id, rule.ProjectId, rule.Name, rule.Type, rule.MetricName, rule.Aggregation, string(filter), rule.Endpoint, rule.OperationType, rule.AnomalyMetric, rule.SloId, rule.Comparison,
+		rule.Threshold, rule.RecoveryThreshold, uint32(rule.WindowMinutes), uint32(rule.ForMinutes), rule.Enabled, rule.CreatedAt, rule.UpdatedAt)
+}
+
+// FindAll returns the project's rules ordered by name
+func (r *alertRuleRepository) FindAll(ctx context.Context, projectId uuid.UUID) ([]models.AlertRule, error) {
+	return r.query(ctx, "SELECT "+alertRuleColumns+" FROM alert_rules FINAL WHERE project_id = ? ORDER BY name ASC", projectId)
+}
+
+// FindAllEnabled returns the enabled rules of every project, used by the evaluation scheduler
+func (r *alertRuleRepository) FindAllEnabled(ctx context.Context) ([]models.AlertRule, error) {
+	return r.query(ctx, "SELECT "+alertRuleColumns+" FROM alert_rules FINAL WHERE enabled = true")
+}
+
+func (r *alertRuleRepository) FindById(ctx context.Context, projectId, id uuid.UUID) (*models.AlertRule, error) {
+	rules, err := r.query(ctx, "SELECT "+alertRuleColumns+" FROM alert_rules FINAL WHERE project_id = ? AND id = ?", projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, ErrAlertRuleNotFound
+	}
+	return &rules[0], nil
+}
+
+func (r *alertRuleRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.AlertRule, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.AlertRule{}
+	for rows.Next() {
+		var rule models.AlertRule
+		var filter string
+		var windowMinutes, forMinutes uint32
+		if err := rows.Scan(&rule.Id, &rule.ProjectId, &rule.Name, &rule.Type, &rule.MetricName, &rule.Aggregation, &filter, &rule.Endpoint, &rule.OperationType, &rule.AnomalyMetric, &rule.SloId, &rule.Comparison,
+			&rule.Threshold, &rule.RecoveryThreshold, &windowMinutes, &forMinutes, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(filter), &rule.MetricFilter); err != nil {
+			return nil, err
+		}
+		rule.WindowMinutes = int(windowMinutes)
+		rule.ForMinutes = int(forMinutes)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Delete removes the rule and its state, its history is kept
+func (r *alertRuleRepository) Delete(ctx context.Context, projectId, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	if err := (*chdb.Conn).Exec(ctx, "ALTER TABLE alert_rules DELETE WHERE project_id = ? AND id = ?", projectId, id); err != nil {
+		return err
+	}
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE alert_rule_states DELETE WHERE project_id = ? AND rule_id = ?", projectId, id)
+}
+
+// FindStates returns the latest state of the project's rules (every project when projectId is uuid.Nil), keyed by rule id
+func (r *alertRuleRepository) FindStates(ctx context.Context, projectId uuid.UUID) (map[uuid.UUID]models.AlertRuleState, error) {
+	query := "SELECT rule_id, project_id, state, value, pending_since, state_changed_at, last_evaluated_at FROM alert_rule_states FINAL"
+	var args []interface{}
+	if projectId != uuid.Nil {
+		query += " WHERE project_id = ?"
+		args = append(args, projectId)
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[uuid.UUID]models.AlertRuleState)
+	for rows.Next() {
+		var s models.AlertRuleState
+		if err := rows.Scan(&s.RuleId, &s.ProjectId, &s.State, &s.Value, &s.PendingSince, &s.StateChangedAt, &s.LastEvaluatedAt); err != nil {
+			return nil, err
+		}
+		states[s.RuleId] = s
+	}
+	return states, nil
+}
+
+// SaveStates stores the outcome of an evaluation round
+func (r *alertRuleRepository) SaveStates(ctx context.Context, states []models.AlertRuleState) error {
+	if len(states) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO alert_rule_states (rule_id, project_id, state, value, pending_since, state_changed_at, last_evaluated_at)")
+	if err != nil {
+		return err
+	}
+
+	for _, s := range states {
+		if err := batch.Append(s.RuleId, s.ProjectId, s.State, s.Value, s.PendingSince, s.StateChangedAt, s.LastEvaluatedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+var AlertRuleRepository = alertRuleRepository{}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type appVersionRepository struct{}
+
+// appVersionsRollup is the cutoff of app_versions in rollup_cutoffs, the versions reported before it are only in
+// the raw tables
+const appVersionsRollup = "app_versions"
+
+// appendFirstSeenBeforeCutoff appends a subquery with when each app version of the project matching where was first
+// reported before the cutoff of app_versions, by any endpoint, task or exception
+func appendFirstSeenBeforeCutoff(ctx context.Context, query string, args []interface{}, projectId uuid.UUID, where string, whereArgs ...interface{}) (string, []interface{}, error) {
+	cutoffs, err := findRollupCutoffs(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	// without a cutoff app_versions holds every report
+	cutoff, ok := cutoffs[appVersionsRollup]
+	if !ok {
+		cutoff = time.Unix(0, 0)
+	}
+
+	for i, table := range []string{"endpoints", "tasks", "exception_stack_traces"} {
+		if i > 0 {
+			query += " UNION ALL "
+		}
+		query += "SELECT app_version, min(recorded_at) AS first_seen FROM " + table +
+			" WHERE project_id = ? AND app_version != '' AND recorded_at < ?" + where + " GROUP BY app_version"
+		args = append(append(args, projectId, cutoff), whereArgs...)
+	}
+	return query, args, nil
+}
+
+// FindLatest returns the most recently deployed app version of the project, the one first reported last, or the
+// empty string when no version was ever reported
+func (r *appVersionRepository) FindLatest(ctx context.Context, projectId uuid.UUID) (string, error) {
+	query, args, err := appendFirstSeenBeforeCutoff(ctx, `SELECT app_version, min(first_seen) as first_seen
+		FROM (
+			SELECT app_version, first_seen FROM app_versions WHERE project_id = ?
+			UNION ALL `, []interface{}{projectId}, projectId, "")
+	if err != nil {
+		return "", err
+	}
+	query += `)
+		GROUP BY app_version
+		ORDER BY first_seen DESC
+		LIMIT 1`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", nil
+	}
+	var version string
+	var firstSeen time.Time
+	if err := rows.Scan(&version, &firstSeen); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// FindFirstSeen returns when the app version was first reported by the project since the cutoff of app_versions,
+// nil when it wasn't. FindFirstSeenBeforeCutoff has the earlier reports.
+func (r *appVersionRepository) FindFirstSeen(ctx context.Context, projectId uuid.UUID, version string) (*time.Time, error) {
+	return r.findFirstSeen(ctx, "SELECT min(first_seen) FROM app_versions WHERE project_id = ? AND app_version = ? GROUP BY app_version", projectId, version)
+}
+
+// FindFirstSeenBeforeCutoff returns when the app version was first reported by the project before the cutoff of
+// app_versions, read from the raw tables, nil when it wasn't. The result never changes.
+func (r *appVersionRepository) FindFirstSeenBeforeCutoff(ctx context.Context, projectId uuid.UUID, version string) (*time.Time, error) {
+	query, args, err := appendFirstSeenBeforeCutoff(ctx, "SELECT min(first_seen) FROM (", nil, projectId, " AND app_version = ?", version)
+	if err != nil {
+		return nil, err
+	}
+	return r.findFirstSeen(ctx, query+") GROUP BY app_version", args...)
+}
+
+func (r *appVersionRepository) findFirstSeen(ctx context.Context, query string, args ...interface{}) (*time.Time, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, nil
+	}
+	var firstSeen time.Time
+	if err := rows.Scan(&firstSeen); err != nil {
+		return nil, err
+	}
+	return &firstSeen, nil
+}
+
+var AppVersionRepository = appVersionRepository{}
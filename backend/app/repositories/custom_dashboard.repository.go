@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrCustomDashboardNotFound = errors.New("custom dashboard not found")
+
+type customDashboardRepository struct{}
+
+const customDashboardColumns = "id, project_id, name, description, default_time_range, widgets, created_at, updated_at"
+
+// Save inserts a new version of the dashboard, replacing the previous one on merge
+func (r *customDashboardRepository) Save(ctx context.Context, dashboard *models.CustomDashboard) error {
+	widgets, err := json.Marshal(dashboard.Widgets)
+	if err != nil {
+		return err
+	}
+	dashboard.UpdatedAt = time.Now()
+	if dashboard.CreatedAt.IsZero() {
+		dashboard.CreatedAt = dashboard.UpdatedAt
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO custom_dashboards ("+customDashboardColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		dashboard.Id, dashboard.ProjectId, dashboard.Name, dashboard.Description, dashboard.DefaultTimeRange, string(widgets), dashboard.CreatedAt, dashboard.UpdatedAt)
+}
+
+// FindAll returns the project's dashboards ordered by name
+func (r *customDashboardRepository) FindAll(ctx context.Context, projectId uuid.UUID) ([]models.CustomDashboard, error) {
+	rows, err := (*chdb.Conn).Query(ctx, "SELECT "+customDashboardColumns+" FROM custom_dashboards FINAL WHERE project_id = ? ORDER BY name ASC", projectId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dashboards := []models.CustomDashboard{}
+	for rows.Next() {
+		var d models.CustomDashboard
+		var widgets string
+		if err := rows.Scan(&d.Id, &d.ProjectId, &d.Name, &d.Description, &d.DefaultTimeRange, &widgets, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(widgets), &d.Widgets); err != nil {
+			return nil, err
+		}
+		dashboards = append(dashboards, d)
+	}
+	return dashboards, nil
+}
+
+func (r *customDashboardRepository) FindById(ctx context.Context, projectId, id uuid.UUID) (*models.CustomDashboard, error) {
+	var d models.CustomDashboard
+	var widgets string
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT "+customDashboardColumns+" FROM custom_dashboards FINAL WHERE project_id = ? AND id = ?", projectId, id).
+		Scan(&d.Id, &d.ProjectId, &d.Name, &d.Description, &d.DefaultTimeRange, &widgets, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, ErrCustomDashboardNotFound
+	}
+	if err := json.Unmarshal([]byte(widgets), &d.Widgets); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Delete removes the dashboard, waiting for the mutation so it is gone from the next listing
+func (r *customDashboardRepository) Delete(ctx context.Context, projectId, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE custom_dashboards DELETE WHERE project_id = ? AND id = ?", projectId, id)
+}
+
+var CustomDashboardRepository = customDashboardRepository{}
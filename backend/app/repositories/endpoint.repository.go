@@ -14,7 +14,7 @@ import (
 type endpointRepository struct{}
 
 func (e *endpointRepository) InsertAsync(ctx context.Context, lines []models.Endpoint) error {
-	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO endpoints (id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name)")
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO endpoints (id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name, trace_id, parent_span_id)")
 	if err != nil {
 		return err
 	}
@@ -25,7 +25,7 @@ func (e *endpointRepository) InsertAsync(ctx context.Context, lines []models.End
 				scopeJSON = string(scopeBytes)
 			}
 		}
-		if err := batch.Append(t.Id, t.ProjectId, t.Endpoint, t.Duration, t.RecordedAt, t.StatusCode, t.BodySize, t.ClientIP, scopeJSON, t.AppVersion, t.ServerName); err != nil {
+		if err := batch.Append(t.Id, t.ProjectId, t.Endpoint, t.Duration, t.RecordedAt, t.StatusCode, t.BodySize, t.ClientIP, scopeJSON, t.AppVersion, t.ServerName, t.TraceId, t.ParentSpanId); err != nil {
 			return err
 		}
 	}
@@ -38,9 +38,12 @@ func (e *endpointRepository) CountBetween(ctx context.Context, projectId uuid.UU
 	return int64(count), err
 }
 
-func (e *endpointRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string) ([]models.Endpoint, int64, error) {
+func (e *endpointRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.Endpoint, int64, error) {
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND recorded_at >= ? AND recorded_at <= ?", []interface{}{projectId, fromDate, toDate}, "scope", scopeFilters)
+	whereClause, whereArgs = appendFilterExpression(whereClause, whereArgs, "", expression)
+
 	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM endpoints WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?", projectId, fromDate, toDate).Scan(&count)
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM endpoints WHERE "+whereClause, whereArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -58,8 +61,8 @@ func (e *endpointRepository) FindAll(ctx context.Context, projectId uuid.UUID, f
 		orderBy = "recorded_at"
 	}
 
-	query := "SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name FROM endpoints WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ? ORDER BY " + orderBy + " DESC LIMIT ? OFFSET ?"
-	rows, err := (*chdb.Conn).Query(ctx, query, projectId, fromDate, toDate, pageSize, offset)
+	query := "SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name, trace_id, parent_span_id FROM endpoints WHERE " + whereClause + " ORDER BY " + orderBy + " DESC LIMIT ? OFFSET ?"
+	rows, err := (*chdb.Conn).Query(ctx, query, append(whereArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -69,7 +72,7 @@ func (e *endpointRepository) FindAll(ctx context.Context, projectId uuid.UUID, f
 	for rows.Next() {
 		var t models.Endpoint
 		var scopeJSON string
-		if err := rows.Scan(&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt, &t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName); err != nil {
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt, &t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
 			return nil, 0, err
 		}
 		// Parse scope JSON
@@ -84,10 +87,15 @@ func (e *endpointRepository) FindAll(ctx context.Context, projectId uuid.UUID, f
 	return endpoints, int64(count), nil
 }
 
-func (e *endpointRepository) FindGroupedByEndpoint(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string) ([]models.EndpointStats, int64, error) {
+func (e *endpointRepository) FindGroupedByEndpoint(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.EndpointStats, int64, error) {
+	source, sourceArgs, err := appendRollupSource(ctx, "", nil, endpointRollupSource, "project_id = ?", []interface{}{projectId}, fromDate, toDate, 0, scopeFilters, expression)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Count unique endpoints
 	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx, "SELECT uniq(endpoint) FROM endpoints WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?", projectId, fromDate, toDate).Scan(&count)
+	err = (*chdb.Conn).QueryRow(ctx, "SELECT uniq(endpoint) FROM "+source, sourceArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -117,18 +125,17 @@ func (e *endpointRepository) FindGroupedByEndpoint(ctx context.Context, projectI
 
 	query := `SELECT
 		endpoint,
-		count() as count,
-		quantile(0.5)(duration) as p50_duration,
-		quantile(0.95)(duration) as p95_duration,
-		avg(duration) as avg_duration,
-		max(recorded_at) as last_seen
-	FROM endpoints
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		sum(requests) as count,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[1] as p50_duration,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[2] as p95_duration,
+		sum(duration_sum) / sum(requests) as avg_duration,
+		max(max_recorded_at) as last_seen
+	FROM ` + source + `
 	GROUP BY endpoint
 	ORDER BY ` + orderExpr + ` ` + sortDir + `
 	LIMIT ? OFFSET ?`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, projectId, fromDate, toDate, pageSize, offset)
+	rows, err := (*chdb.Conn).Query(ctx, query, append(sourceArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -150,9 +157,12 @@ func (e *endpointRepository) FindGroupedByEndpoint(ctx context.Context, projectI
 	return stats, int64(count), nil
 }
 
-func (e *endpointRepository) FindByEndpoint(ctx context.Context, projectId uuid.UUID, endpoint string, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string) ([]models.Endpoint, int64, error) {
+func (e *endpointRepository) FindByEndpoint(ctx context.Context, projectId uuid.UUID, endpoint string, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.Endpoint, int64, error) {
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND endpoint = ? AND recorded_at >= ? AND recorded_at <= ?", []interface{}{projectId, endpoint, fromDate, toDate}, "scope", scopeFilters)
+	whereClause, whereArgs = appendFilterExpression(whereClause, whereArgs, "", expression)
+
 	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM endpoints WHERE project_id = ? AND endpoint = ? AND recorded_at >= ? AND recorded_at <= ?", projectId, endpoint, fromDate, toDate).Scan(&count)
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM endpoints WHERE "+whereClause, whereArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -176,8 +186,8 @@ func (e *endpointRepository) FindByEndpoint(ctx context.Context, projectId uuid.
 		sortDir = "ASC"
 	}
 
-	query := "SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name FROM endpoints WHERE project_id = ? AND endpoint = ? AND recorded_at >= ? AND recorded_at <= ? ORDER BY " + orderBy + " " + sortDir + " LIMIT ? OFFSET ?"
-	rows, err := (*chdb.Conn).Query(ctx, query, projectId, endpoint, fromDate, toDate, pageSize, offset)
+	query := "SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name, trace_id, parent_span_id FROM endpoints WHERE " + whereClause + " ORDER BY " + orderBy + " " + sortDir + " LIMIT ? OFFSET ?"
+	rows, err := (*chdb.Conn).Query(ctx, query, append(whereArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -187,7 +197,7 @@ func (e *endpointRepository) FindByEndpoint(ctx context.Context, projectId uuid.
 	for rows.Next() {
 		var t models.Endpoint
 		var scopeJSON string
-		if err := rows.Scan(&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt, &t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName); err != nil {
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt, &t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
 			return nil, 0, err
 		}
 		// Parse scope JSON
@@ -202,9 +212,93 @@ func (e *endpointRepository) FindByEndpoint(ctx context.Context, projectId uuid.
 	return endpoints, int64(count), nil
 }
 
+// endpointPageSortColumns are the columns an endpoint listing can be sorted and paginated on
+var endpointPageSortColumns = map[string]bool{
+	"recorded_at": true,
+	"duration":    true,
+	"status_code": true,
+	"body_size":   true,
+}
+
+// pageWhere returns the conditions of a listing of the requests of a project, or of one endpoint when endpoint
+// isn't empty
+func (e *endpointRepository) pageWhere(projectId uuid.UUID, endpoint string, fromDate, toDate time.Time, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (string, []interface{}) {
+	whereClause := "project_id = ? AND recorded_at >= ? AND recorded_at <= ?"
+	whereArgs := []interface{}{projectId, fromDate, toDate}
+	if endpoint != "" {
+		whereClause += " AND endpoint = ?"
+		whereArgs = append(whereArgs, endpoint)
+	}
+	whereClause, whereArgs = appendScopeFilters(whereClause, whereArgs, "scope", scopeFilters)
+	return appendFilterExpression(whereClause, whereArgs, "", expression)
+}
+
+// FindPage lists the requests of a project, or of one endpoint when endpoint isn't empty, a page at a time with
+// keyset pagination. It returns the cursor of the next page, nil on the last page.
+func (e *endpointRepository) FindPage(ctx context.Context, projectId uuid.UUID, endpoint string, fromDate, toDate time.Time, pageSize int, orderBy string, sortDirection string, cursor *models.PageCursor, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.Endpoint, *models.PageCursor, error) {
+	if !endpointPageSortColumns[orderBy] {
+		orderBy = "recorded_at"
+	}
+	desc := sortDirection != "asc"
+
+	whereClause, whereArgs := e.pageWhere(projectId, endpoint, fromDate, toDate, scopeFilters, expression)
+	query, args, err := appendKeyset("SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name, trace_id, parent_span_id FROM endpoints WHERE "+whereClause,
+		whereArgs, orderBy, desc, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	endpoints := []models.Endpoint{}
+	for rows.Next() {
+		var t models.Endpoint
+		var scopeJSON string
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt, &t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
+			return nil, nil, err
+		}
+		if scopeJSON != "" && scopeJSON != "{}" {
+			if err := json.Unmarshal([]byte(scopeJSON), &t.Scope); err != nil {
+				t.Scope = nil
+			}
+		}
+		endpoints = append(endpoints, t)
+	}
+
+	if len(endpoints) <= pageSize {
+		return endpoints, nil, nil
+	}
+	endpoints = endpoints[:pageSize]
+	last := endpoints[pageSize-1]
+	next := &models.PageCursor{SortBy: orderBy, Desc: desc, Id: last.Id}
+	switch orderBy {
+	case "recorded_at":
+		next.Value = last.RecordedAt.Unix()
+	case "duration":
+		next.Value = int64(last.Duration)
+	case "status_code":
+		next.Value = int64(last.StatusCode)
+	case "body_size":
+		next.Value = int64(last.BodySize)
+	}
+	return endpoints, next, nil
+}
+
+// Count counts the requests of a listing, it is only run when a total is asked for as it reads every matching row
+func (e *endpointRepository) Count(ctx context.Context, projectId uuid.UUID, endpoint string, fromDate, toDate time.Time, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (int64, error) {
+	whereClause, whereArgs := e.pageWhere(projectId, endpoint, fromDate, toDate, scopeFilters, expression)
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM endpoints WHERE "+whereClause, whereArgs...).Scan(&count)
+	return int64(count), err
+}
+
 // FindById returns a single endpoint by ID
 func (e *endpointRepository) FindById(ctx context.Context, projectId, endpointId uuid.UUID) (*models.Endpoint, error) {
-	query := `SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name
+	query := `SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name, trace_id, parent_span_id
 		FROM endpoints
 		WHERE project_id = ? AND id = ?
 		LIMIT 1`
@@ -214,7 +308,7 @@ func (e *endpointRepository) FindById(ctx context.Context, projectId, endpointId
 
 	err := (*chdb.Conn).QueryRow(ctx, query, projectId, endpointId).Scan(
 		&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt,
-		&t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName)
+		&t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId)
 
 	if err != nil {
 		return nil, err
@@ -230,6 +324,38 @@ func (e *endpointRepository) FindById(ctx context.Context, projectId, endpointId
 	return &t, nil
 }
 
+// FindByTraceId returns every endpoint across all projects that belongs to the given trace
+func (e *endpointRepository) FindByTraceId(ctx context.Context, traceId string, fromDate, toDate time.Time) ([]models.Endpoint, error) {
+	query := `SELECT id, project_id, endpoint, duration, recorded_at, status_code, body_size, client_ip, scope, app_version, server_name, trace_id, parent_span_id
+		FROM endpoints
+		WHERE trace_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		ORDER BY recorded_at ASC`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, traceId, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []models.Endpoint
+	for rows.Next() {
+		var t models.Endpoint
+		var scopeJSON string
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.Endpoint, &t.Duration, &t.RecordedAt, &t.StatusCode, &t.BodySize, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
+			return nil, err
+		}
+		// Parse scope JSON
+		if scopeJSON != "" && scopeJSON != "{}" {
+			if err := json.Unmarshal([]byte(scopeJSON), &t.Scope); err != nil {
+				t.Scope = nil
+			}
+		}
+		endpoints = append(endpoints, t)
+	}
+
+	return endpoints, nil
+}
+
 // CountByHour returns endpoint counts grouped by hour
 func (e *endpointRepository) CountByHour(ctx context.Context, projectId uuid.UUID, start, end time.Time) ([]models.TimeSeriesPoint, error) {
 	query := `SELECT
@@ -316,15 +442,18 @@ func (e *endpointRepository) ErrorRateByHour(ctx context.Context, projectId uuid
 
 // CountByInterval returns endpoint counts grouped by configurable interval in minutes
 func (e *endpointRepository) CountByInterval(ctx context.Context, projectId uuid.UUID, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
-	query := `SELECT
-		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
-		toFloat64(count()) as count
-	FROM endpoints
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
-	GROUP BY bucket
-	ORDER BY bucket ASC`
+	query, args, err := appendRollupSource(ctx, `SELECT
+		toStartOfInterval(bucket, INTERVAL ? MINUTE) as period,
+		toFloat64(sum(requests)) as count
+	FROM `, []interface{}{intervalMinutes}, endpointRollupSource, "project_id = ?", []interface{}{projectId}, start, end, intervalMinutes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	query += `
+	GROUP BY period
+	ORDER BY period ASC`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, intervalMinutes, projectId, start, end)
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -344,15 +473,18 @@ func (e *endpointRepository) CountByInterval(ctx context.Context, projectId uuid
 
 // AvgDurationByInterval returns average response time in ms grouped by configurable interval
 func (e *endpointRepository) AvgDurationByInterval(ctx context.Context, projectId uuid.UUID, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
-	query := `SELECT
-		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
-		avg(duration) / 1000000 as avg_duration_ms
-	FROM endpoints
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
-	GROUP BY bucket
-	ORDER BY bucket ASC`
+	query, args, err := appendRollupSource(ctx, `SELECT
+		toStartOfInterval(bucket, INTERVAL ? MINUTE) as period,
+		sum(duration_sum) / sum(requests) / 1000000 as avg_duration_ms
+	FROM `, []interface{}{intervalMinutes}, endpointRollupSource, "project_id = ?", []interface{}{projectId}, start, end, intervalMinutes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	query += `
+	GROUP BY period
+	ORDER BY period ASC`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, intervalMinutes, projectId, start, end)
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -372,15 +504,87 @@ func (e *endpointRepository) AvgDurationByInterval(ctx context.Context, projectI
 
 // ErrorRateByInterval returns error rate (percentage) grouped by configurable interval
 func (e *endpointRepository) ErrorRateByInterval(ctx context.Context, projectId uuid.UUID, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
+	query, args, err := appendRollupSource(ctx, `SELECT
+		toStartOfInterval(bucket, INTERVAL ? MINUTE) as period,
+		sum(errors) * 100.0 / sum(requests) as error_rate
+	FROM `, []interface{}{intervalMinutes}, endpointRollupSource, "project_id = ?", []interface{}{projectId}, start, end, intervalMinutes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	query += `
+	GROUP BY period
+	ORDER BY period ASC`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []models.TimeSeriesPoint
+	for rows.Next() {
+		var p models.TimeSeriesPoint
+		if err := rows.Scan(&p.Timestamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// LatencyByInterval returns the avg and p95 response time in ms of one endpoint (or all when empty) grouped by
+// configurable interval, keyed "avg" and "p95"
+func (e *endpointRepository) LatencyByInterval(ctx context.Context, projectId uuid.UUID, endpoint string, start, end time.Time, intervalMinutes int) (map[string][]models.TimeSeriesPoint, error) {
+	query := `SELECT
+		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
+		avg(duration) / 1000000 as avg_duration_ms,
+		quantile(0.95)(duration) / 1000000 as p95_duration_ms
+	FROM endpoints
+	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?`
+	args := []interface{}{intervalMinutes, projectId, start, end}
+
+	if endpoint != "" {
+		query += " AND endpoint = ?"
+		args = append(args, endpoint)
+	}
+	query += " GROUP BY bucket ORDER BY bucket ASC"
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string][]models.TimeSeriesPoint{"avg": {}, "p95": {}}
+	for rows.Next() {
+		var bucket time.Time
+		var avg, p95 float64
+		if err := rows.Scan(&bucket, &avg, &p95); err != nil {
+			return nil, err
+		}
+		result["avg"] = append(result["avg"], models.TimeSeriesPoint{Timestamp: bucket, Value: avg})
+		result["p95"] = append(result["p95"], models.TimeSeriesPoint{Timestamp: bucket, Value: p95})
+	}
+
+	return result, nil
+}
+
+// ErrorRateByIntervalForEndpoint returns the error rate (percentage) of one endpoint (or all when empty) grouped by configurable interval
+func (e *endpointRepository) ErrorRateByIntervalForEndpoint(ctx context.Context, projectId uuid.UUID, endpoint string, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
+	if endpoint == "" {
+		return e.ErrorRateByInterval(ctx, projectId, start, end, intervalMinutes)
+	}
+
 	query := `SELECT
 		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
 		countIf(status_code >= 400) * 100.0 / count() as error_rate
 	FROM endpoints
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
+	WHERE project_id = ? AND endpoint = ? AND recorded_at >= ? AND recorded_at <= ?
 	GROUP BY bucket
 	ORDER BY bucket ASC`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, intervalMinutes, projectId, start, end)
+	rows, err := (*chdb.Conn).Query(ctx, query, intervalMinutes, projectId, endpoint, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -436,32 +640,39 @@ func (e *endpointRepository) FindWorstEndpoints(ctx context.Context, projectId u
 	return stats, nil
 }
 
-// GetEndpointStats returns aggregate statistics for a specific endpoint
-func (e *endpointRepository) GetEndpointStats(ctx context.Context, projectId uuid.UUID, endpoint string, start, end time.Time) (*models.EndpointDetailStats, error) {
+// GetEndpointStats returns aggregate statistics for a specific endpoint, or for all endpoints when endpoint is empty,
+// limited to the requests matching the scope filters and filter expression
+func (e *endpointRepository) GetEndpointStats(ctx context.Context, projectId uuid.UUID, endpoint string, start, end time.Time, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (*models.EndpointDetailStats, error) {
 	// Calculate time range duration for throughput calculation
 	durationMinutes := end.Sub(start).Minutes()
 	if durationMinutes < 1 {
 		durationMinutes = 1
 	}
 
-	query := `SELECT
-		count() as count,
-		avg(duration) / 1000000 as avg_duration_ms,
-		quantile(0.5)(duration) / 1000000 as p50_duration_ms,
-		quantile(0.95)(duration) / 1000000 as p95_duration_ms,
-		quantile(0.99)(duration) / 1000000 as p99_duration_ms,
-		countIf(status_code >= 400) * 100.0 / count() as error_rate,
-		countIf(duration <= 500000000 AND status_code < 400) +
-			(countIf(duration > 500000000 AND duration <= 2000000000 AND status_code < 400) * 0.5)
-			as satisfied_tolerating
-	FROM endpoints
-	WHERE project_id = ? AND endpoint = ? AND recorded_at >= ? AND recorded_at <= ?`
+	where, whereArgs := "project_id = ?", []interface{}{projectId}
+	if endpoint != "" {
+		where += " AND endpoint = ?"
+		whereArgs = append(whereArgs, endpoint)
+	}
+
+	query, args, err := appendRollupSource(ctx, `SELECT
+		sum(requests) as count,
+		sum(duration_sum) / sum(requests) / 1000000 as avg_duration_ms,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[1] / 1000000 as p50_duration_ms,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[2] / 1000000 as p95_duration_ms,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[3] / 1000000 as p99_duration_ms,
+		sum(errors) * 100.0 / sum(requests) as error_rate,
+		sum(satisfied) + (sum(tolerating) * 0.5) as satisfied_tolerating
+	FROM `, nil, endpointRollupSource, where, whereArgs, start, end, 0, scopeFilters, expression)
+	if err != nil {
+		return nil, err
+	}
 
 	var stats models.EndpointDetailStats
 	var count uint64
 	var satisfiedTolerating float64
 
-	err := (*chdb.Conn).QueryRow(ctx, query, projectId, endpoint, start, end).Scan(
+	err = (*chdb.Conn).QueryRow(ctx, query, args...).Scan(
 		&count,
 		&stats.AvgDuration,
 		&stats.MedianDuration,
@@ -50,7 +50,84 @@ func (e *exceptionStackTraceRepository) CountBetween(ctx context.Context, projec
 	return int64(count), err
 }
 
-func (e *exceptionStackTraceRepository) FindGrouped(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, search string, searchType string, includeArchived bool) ([]models.ExceptionGroup, int64, error) {
+// CountExceptionsBetween returns the number of exception occurrences (not messages) recorded in the range
+func (e *exceptionStackTraceRepository) CountExceptionsBetween(ctx context.Context, projectId uuid.UUID, start, end time.Time) (int64, error) {
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM exception_stack_traces WHERE project_id = ? AND is_message = 0 AND recorded_at >= ? AND recorded_at <= ?", projectId, start, end).Scan(&count)
+	return int64(count), err
+}
+
+// CountNewIssuesBetween returns the number of exception hashes (not messages) whose first occurrence falls in the range
+func (e *exceptionStackTraceRepository) CountNewIssuesBetween(ctx context.Context, projectId uuid.UUID, start, end time.Time) (int64, error) {
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, `SELECT count() FROM (
+		SELECT exception_hash, min(recorded_at) as first_seen
+		FROM exception_stack_traces
+		WHERE project_id = ? AND is_message = 0 AND recorded_at <= ?
+		GROUP BY exception_hash
+		HAVING first_seen >= ?
+	)`, projectId, end, start).Scan(&count)
+	return int64(count), err
+}
+
+// issueSightingLookback bounds the raw lookup of hashes without an issue row. The ingest saves an issue for every
+// hash it sees, only hashes last seen before it did lack one, and those older than the lookback are reported new.
+const issueSightingLookback = 30 * 24 * time.Hour
+
+// FindIssueHistory returns whether each of the hashes was seen before and its issue. The issues are read first,
+// only the hashes without one are looked up in the occurrences of the last issueSightingLookback.
+func (e *exceptionStackTraceRepository) FindIssueHistory(ctx context.Context, projectId uuid.UUID, hashes []string) (map[string]models.IssueHistory, error) {
+	history := make(map[string]models.IssueHistory)
+	if len(hashes) == 0 {
+		return history, nil
+	}
+
+	issues, err := IssueRepository.FindByHashes(ctx, projectId, hashes)
+	if err != nil {
+		return nil, err
+	}
+	var unknown []string
+	for _, hash := range hashes {
+		issue, ok := issues[hash]
+		if !ok {
+			unknown = append(unknown, hash)
+			continue
+		}
+		history[hash] = models.IssueHistory{Seen: true, Issue: &issue}
+	}
+	if len(unknown) == 0 {
+		return history, nil
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT DISTINCT exception_hash
+		FROM exception_stack_traces
+		WHERE project_id = ? AND recorded_at >= ? AND exception_hash IN (?)`, projectId, time.Now().Add(-issueSightingLookback), unknown)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		history[hash] = models.IssueHistory{Seen: true}
+	}
+	return history, rows.Err()
+}
+
+// issueSortExpressions are the ORDER BY expressions of the sort fields of FindGrouped
+var issueSortExpressions = map[string]string{
+	"last_seen":  "last_seen",
+	"first_seen": "first_seen",
+	"count":      "count",
+	"status":     "issue_status",
+	"assignee":   "issue_assignee",
+	"priority":   "indexOf(['low', 'medium', 'high', 'critical'], issue_priority)",
+}
+
+func (e *exceptionStackTraceRepository) FindGrouped(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, search string, searchType string, filter models.IssueFilter) ([]models.ExceptionGroup, int64, error) {
 	offset := (page - 1) * pageSize
 
 	// Parse sort direction from orderBy (e.g., "last_seen_asc" -> "last_seen", "ASC")
@@ -60,24 +137,17 @@ func (e *exceptionStackTraceRepository) FindGrouped(ctx context.Context, project
 		sortDirection = "ASC"
 	}
 
-	allowedOrderBy := map[string]bool{
-		"last_seen":  true,
-		"first_seen": true,
-		"count":      true,
-	}
-
-	if !allowedOrderBy[orderBy] {
-		orderBy = "count"
+	sortExpression, ok := issueSortExpressions[orderBy]
+	if !ok {
+		sortExpression = "count"
 	}
 
 	// Build WHERE clause dynamically based on search filter
 	whereClause := "e.project_id = ? AND e.recorded_at >= ? AND e.recorded_at <= ?"
 	args := []interface{}{projectId, fromDate, toDate}
 
-	if search != "" {
-		whereClause += " AND positionCaseInsensitive(e.stack_trace, ?) > 0"
-		args = append(args, search)
-	}
+	searchQuery := models.ParseSearchQuery(search)
+	whereClause, args = appendSearchQuery(whereClause, args, "e.stack_trace", searchQuery)
 
 	// Add searchType filter
 	if searchType == "issues" {
@@ -87,46 +157,72 @@ func (e *exceptionStackTraceRepository) FindGrouped(ctx context.Context, project
 	}
 	// "all" or empty = no filter
 
-	// Build HAVING clause for archive filtering
-	// Show exceptions if: not archived OR last occurrence is after archive time
+	whereClause, args = appendScopeFilters(whereClause, args, "e.scope", filter.Scope)
+	whereClause, args = appendFilterExpression(whereClause, args, "e", filter.Expression)
+
+	// Build HAVING clause for the issue filters, hashes without an issue row are unresolved with the default priority
+	var having []string
+	var havingArgs []interface{}
+	if len(filter.Statuses) > 0 {
+		having = append(having, "issue_status IN (?)")
+		havingArgs = append(havingArgs, filter.Statuses)
+	}
+	if filter.Assignee != nil {
+		having = append(having, "issue_assignee = ?")
+		havingArgs = append(havingArgs, *filter.Assignee)
+	}
+	if len(filter.Priorities) > 0 {
+		having = append(having, "issue_priority IN (?)")
+		havingArgs = append(havingArgs, filter.Priorities)
+	}
 	havingClause := ""
-	if !includeArchived {
-		havingClause = " HAVING any(a.archived_at) IS NULL OR max(e.recorded_at) > any(a.archived_at)"
+	if len(having) > 0 {
+		havingClause = " HAVING " + strings.Join(having, " AND ")
 	}
 
-	// Subquery to get max archived_at per exception hash
-	archiveSubquery := `LEFT JOIN (
-		SELECT exception_hash, max(archived_at) as archived_at
-		FROM archived_exceptions FINAL
+	// Subquery to get the issue of each exception hash
+	issueSubquery := `LEFT JOIN (
+		SELECT exception_hash, status, assignee, priority, resolved_at, resolution
+		FROM issues FINAL
 		WHERE project_id = ?
-		GROUP BY exception_hash
-	) a ON e.exception_hash = a.exception_hash`
+	) i ON e.exception_hash = i.exception_hash`
+
+	// a resolved issue that occurred after it was resolved is unresolved again, even before the ingest reopened it.
+	// Issues resolved in the next release keep occurring on older versions and are only reopened by the ingest.
+	issueColumns := `multiIf(any(i.status) = '', 'unresolved',
+			any(i.status) = 'resolved' AND any(i.resolution) != 'next_release' AND max(e.recorded_at) > any(i.resolved_at), 'unresolved',
+			any(i.status)) as issue_status,
+		any(i.assignee) as issue_assignee,
+		if(any(i.priority) = '', 'medium', any(i.priority)) as issue_priority`
 
 	// Count query needs to wrap the grouped query to apply HAVING filter correctly
 	countQuery := `SELECT count() FROM (
-		SELECT e.exception_hash
+		SELECT e.exception_hash, max(e.recorded_at) as last_seen, ` + issueColumns + `
 		FROM exception_stack_traces e
-		` + archiveSubquery + `
+		` + issueSubquery + `
 		WHERE ` + whereClause + `
 		GROUP BY e.exception_hash` + havingClause + `
 	)`
 
 	countArgs := append([]interface{}{projectId}, args...)
+	countArgs = append(countArgs, havingArgs...)
 	var count uint64
 	err := (*chdb.Conn).QueryRow(ctx, countQuery, countArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Main query with archive-aware filtering
-	fullQuery := `SELECT e.exception_hash, any(e.stack_trace), max(e.recorded_at) as last_seen, min(e.recorded_at) as first_seen, count() as count
+	// Main query with issue-aware filtering
+	fullQuery := `SELECT e.exception_hash, any(e.stack_trace), max(e.recorded_at) as last_seen, min(e.recorded_at) as first_seen, count() as count,
+		` + issueColumns + `
 		FROM exception_stack_traces e
-		` + archiveSubquery + `
+		` + issueSubquery + `
 		WHERE ` + whereClause + `
 		GROUP BY e.exception_hash` + havingClause + `
-		ORDER BY ` + orderBy + ` ` + sortDirection + ` LIMIT ? OFFSET ?`
+		ORDER BY ` + sortExpression + ` ` + sortDirection + `, e.exception_hash ASC LIMIT ? OFFSET ?`
 
 	queryArgs := append([]interface{}{projectId}, args...)
+	queryArgs = append(queryArgs, havingArgs...)
 	queryArgs = append(queryArgs, pageSize, offset)
 	rows, err := (*chdb.Conn).Query(ctx, fullQuery, queryArgs...)
 	if err != nil {
@@ -137,32 +233,212 @@ func (e *exceptionStackTraceRepository) FindGrouped(ctx context.Context, project
 	var groups []models.ExceptionGroup
 	for rows.Next() {
 		var g models.ExceptionGroup
-		if err := rows.Scan(&g.ExceptionHash, &g.StackTrace, &g.LastSeen, &g.FirstSeen, &g.Count); err != nil {
+		if err := rows.Scan(&g.ExceptionHash, &g.StackTrace, &g.LastSeen, &g.FirstSeen, &g.Count, &g.Status, &g.Assignee, &g.Priority); err != nil {
 			return nil, 0, err
 		}
+		g.Highlights = searchQuery.Highlight(g.StackTrace)
 		groups = append(groups, g)
 	}
 
 	return groups, int64(count), nil
 }
 
-func (e *exceptionStackTraceRepository) FindByHash(ctx context.Context, projectId uuid.UUID, exceptionHash string, page, pageSize int) (*models.ExceptionGroup, []models.ExceptionStackTrace, int64, error) {
-	offset := (page - 1) * pageSize
+// GetSnoozeStats counts the occurrences of a snoozed issue after since, those after hourStart, and the distinct
+// users and servers they affected
+func (e *exceptionStackTraceRepository) GetSnoozeStats(ctx context.Context, projectId uuid.UUID, exceptionHash string, since, hourStart time.Time) (models.IssueSnoozeStats, error) {
+	args := []interface{}{hourStart}
+	userExpressions := make([]string, len(models.IssueUserScopeKeys))
+	for i, key := range models.IssueUserScopeKeys {
+		userExpressions[i] = "nullIf(JSONExtractString(scope, ?), '')"
+		args = append(args, key)
+	}
+	args = append(args, projectId, exceptionHash, since)
+
+	var stats models.IssueSnoozeStats
+	err := (*chdb.Conn).QueryRow(ctx, `SELECT
+			count(),
+			countIf(recorded_at >= ?),
+			uniqExact(coalesce(`+strings.Join(userExpressions, ", ")+`)),
+			uniqExactIf(server_name, server_name != '')
+		FROM exception_stack_traces
+		WHERE project_id = ? AND exception_hash = ? AND recorded_at > ?`, args...).Scan(
+		&stats.Occurrences, &stats.LastHour, &stats.Users, &stats.Servers)
+	return stats, err
+}
 
-	// Get grouped info
-	var group models.ExceptionGroup
+// exceptionFacetFields are the columns every exception group is broken down by, in the order they are returned
+var exceptionFacetFields = []string{"server_name", "app_version", "transaction_type"}
+
+// maxExceptionFacetScopeKeys caps the scope keys broken down, the ones most occurrences have are kept
+const maxExceptionFacetScopeKeys = 50
+
+// FindFacets breaks the occurrences of an exception group in the range down by server, app version, transaction
+// type and each scope key, returning the limit most frequent values of each
+func (e *exceptionStackTraceRepository) FindFacets(ctx context.Context, projectId uuid.UUID, exceptionHash string, fromDate, toDate time.Time, limit int) (*models.ExceptionFacets, error) {
+	whereClause := "project_id = ? AND exception_hash = ? AND recorded_at >= ? AND recorded_at <= ?"
+	whereArgs := []interface{}{projectId, exceptionHash, fromDate, toDate}
+
+	facets := &models.ExceptionFacets{Facets: []models.Facet{}}
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM exception_stack_traces WHERE "+whereClause, whereArgs...).Scan(&facets.Total); err != nil {
+		return nil, err
+	}
+	if facets.Total == 0 {
+		return facets, nil
+	}
+
+	fieldQueries := make([]string, len(exceptionFacetFields))
+	var fieldArgs []interface{}
+	for i, field := range exceptionFacetFields {
+		fieldQueries[i] = "SELECT '" + field + "' as facet, toString(" + field + ") as value, count() as c FROM exception_stack_traces WHERE " + whereClause + " GROUP BY value"
+		fieldArgs = append(fieldArgs, whereArgs...)
+	}
+	fieldValues, err := e.queryFacetValues(ctx, `SELECT facet, value, c FROM (
+		`+strings.Join(fieldQueries, "\n\t\tUNION ALL\n\t\t")+`
+	)
+	ORDER BY facet ASC, c DESC, value ASC
+	LIMIT ? BY facet`, append(fieldArgs, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range exceptionFacetFields {
+		facet := models.Facet{Key: field, Source: models.FacetSourceField, Count: facets.Total, Values: []models.FacetValue{}}
+		for _, v := range fieldValues[field] {
+			v.Percentage = facets.Percentage(v.Count)
+			facet.Values = append(facet.Values, v)
+		}
+		facets.Facets = append(facets.Facets, facet)
+	}
+
+	// the scope keys most occurrences have, scope is stored as a JSON object of strings
+	keyRows, err := (*chdb.Conn).Query(ctx, `SELECT kv.1 as key, count() as c
+		FROM exception_stack_traces
+		ARRAY JOIN JSONExtractKeysAndValues(scope, 'String') as kv
+		WHERE `+whereClause+`
+		GROUP BY key
+		ORDER BY c DESC, key ASC
+		LIMIT ?`, append(whereArgs, maxExceptionFacetScopeKeys)...)
+	if err != nil {
+		return nil, err
+	}
+	defer keyRows.Close()
+
+	var keys []string
+	keyCounts := make(map[string]uint64)
+	for keyRows.Next() {
+		var key string
+		var count uint64
+		if err := keyRows.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		keyCounts[key] = count
+	}
+	if len(keys) == 0 {
+		return facets, nil
+	}
+
+	scopeValues, err := e.queryFacetValues(ctx, `SELECT kv.1 as facet, kv.2 as value, count() as c
+		FROM exception_stack_traces
+		ARRAY JOIN JSONExtractKeysAndValues(scope, 'String') as kv
+		WHERE `+whereClause+` AND kv.1 IN (?)
+		GROUP BY facet, value
+		ORDER BY facet ASC, c DESC, value ASC
+		LIMIT ? BY facet`, append(whereArgs, keys, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		facet := models.Facet{Key: key, Source: models.FacetSourceScope, Count: keyCounts[key], Values: []models.FacetValue{}}
+		for _, v := range scopeValues[key] {
+			v.Percentage = facets.Percentage(v.Count)
+			facet.Values = append(facet.Values, v)
+		}
+		facets.Facets = append(facets.Facets, facet)
+	}
+
+	return facets, nil
+}
+
+// queryFacetValues runs a query returning (facet, value, count) rows and groups the values by facet
+func (e *exceptionStackTraceRepository) queryFacetValues(ctx context.Context, query string, args ...interface{}) (map[string][]models.FacetValue, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string][]models.FacetValue)
+	for rows.Next() {
+		var facet string
+		var v models.FacetValue
+		if err := rows.Scan(&facet, &v.Value, &v.Count); err != nil {
+			return nil, err
+		}
+		values[facet] = append(values[facet], v)
+	}
+	return values, nil
+}
+
+// Exists reports whether the hash has any occurrence in the project
+func (e *exceptionStackTraceRepository) Exists(ctx context.Context, projectId uuid.UUID, exceptionHash string) (bool, error) {
+	var count uint64
 	err := (*chdb.Conn).QueryRow(ctx,
-		"SELECT exception_hash, any(stack_trace), max(recorded_at) as last_seen, min(recorded_at) as first_seen, count() as count FROM exception_stack_traces WHERE project_id = ? AND exception_hash = ? GROUP BY exception_hash",
-		projectId, exceptionHash).Scan(&group.ExceptionHash, &group.StackTrace, &group.LastSeen, &group.FirstSeen, &group.Count)
+		"SELECT count() FROM (SELECT 1 FROM exception_stack_traces WHERE project_id = ? AND exception_hash = ? LIMIT 1)",
+		projectId, exceptionHash).Scan(&count)
 	if err != nil {
-		// ClickHouse returns error when no rows found in QueryRow
-		return nil, nil, 0, ErrExceptionNotFound
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindExistingHashes returns the distinct hashes among the given ones that have occurrences in the project
+func (e *exceptionStackTraceRepository) FindExistingHashes(ctx context.Context, projectId uuid.UUID, exceptionHashes []string) ([]string, error) {
+	if len(exceptionHashes) == 0 {
+		return nil, nil
 	}
 
+	rows, err := (*chdb.Conn).Query(ctx,
+		"SELECT DISTINCT exception_hash FROM exception_stack_traces WHERE project_id = ? AND exception_hash IN (?)",
+		projectId, exceptionHashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// FindByHash returns the group of an exception hash and a page of its occurrences matching the scope filters, with
+// their total
+func (e *exceptionStackTraceRepository) FindByHash(ctx context.Context, projectId uuid.UUID, exceptionHash string, page, pageSize int, scopeFilters []models.ScopeFilter) (*models.ExceptionGroup, []models.ExceptionStackTrace, int64, error) {
+	offset := (page - 1) * pageSize
+
+	group, err := e.FindGroupByHash(ctx, projectId, exceptionHash)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	total := int64(group.Count)
+	if len(scopeFilters) > 0 {
+		if total, err = e.CountOccurrences(ctx, projectId, exceptionHash, scopeFilters); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND exception_hash = ?", []interface{}{projectId, exceptionHash}, "scope", scopeFilters)
+
 	// Get individual occurrences with pagination (including scope)
 	rows, err := (*chdb.Conn).Query(ctx,
-		"SELECT id, project_id, transaction_id, transaction_type, exception_hash, stack_trace, recorded_at, scope, app_version, server_name, is_message FROM exception_stack_traces WHERE project_id = ? AND exception_hash = ? ORDER BY recorded_at DESC LIMIT ? OFFSET ?",
-		projectId, exceptionHash, pageSize, offset)
+		"SELECT id, project_id, transaction_id, transaction_type, exception_hash, stack_trace, recorded_at, scope, app_version, server_name, is_message FROM exception_stack_traces WHERE "+whereClause+" ORDER BY recorded_at DESC LIMIT ? OFFSET ?",
+		append(whereArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -186,7 +462,69 @@ func (e *exceptionStackTraceRepository) FindByHash(ctx context.Context, projectI
 		occurrences = append(occurrences, o)
 	}
 
-	return &group, occurrences, int64(group.Count), nil
+	return group, occurrences, total, nil
+}
+
+// FindGroupByHash returns the totals of an exception hash over all its occurrences
+func (e *exceptionStackTraceRepository) FindGroupByHash(ctx context.Context, projectId uuid.UUID, exceptionHash string) (*models.ExceptionGroup, error) {
+	var group models.ExceptionGroup
+	err := (*chdb.Conn).QueryRow(ctx,
+		"SELECT exception_hash, any(stack_trace), max(recorded_at) as last_seen, min(recorded_at) as first_seen, count() as count FROM exception_stack_traces WHERE project_id = ? AND exception_hash = ? GROUP BY exception_hash",
+		projectId, exceptionHash).Scan(&group.ExceptionHash, &group.StackTrace, &group.LastSeen, &group.FirstSeen, &group.Count)
+	if err != nil {
+		// ClickHouse returns error when no rows found in QueryRow
+		return nil, ErrExceptionNotFound
+	}
+	return &group, nil
+}
+
+// CountOccurrences counts the occurrences of an exception hash matching the scope filters
+func (e *exceptionStackTraceRepository) CountOccurrences(ctx context.Context, projectId uuid.UUID, exceptionHash string, scopeFilters []models.ScopeFilter) (int64, error) {
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND exception_hash = ?", []interface{}{projectId, exceptionHash}, "scope", scopeFilters)
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM exception_stack_traces WHERE "+whereClause, whereArgs...).Scan(&count)
+	return int64(count), err
+}
+
+// FindOccurrencesPage lists the occurrences of an exception hash matching the scope filters, most recent first, a
+// page at a time with keyset pagination. It returns the cursor of the next page, nil on the last page.
+func (e *exceptionStackTraceRepository) FindOccurrencesPage(ctx context.Context, projectId uuid.UUID, exceptionHash string, pageSize int, cursor *models.PageCursor, scopeFilters []models.ScopeFilter) ([]models.ExceptionStackTrace, *models.PageCursor, error) {
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND exception_hash = ?", []interface{}{projectId, exceptionHash}, "scope", scopeFilters)
+	query, args, err := appendKeyset("SELECT id, project_id, transaction_id, transaction_type, exception_hash, stack_trace, recorded_at, scope, app_version, server_name, is_message FROM exception_stack_traces WHERE "+whereClause,
+		whereArgs, "recorded_at", true, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	occurrences := []models.ExceptionStackTrace{}
+	for rows.Next() {
+		var o models.ExceptionStackTrace
+		var scopeJSON string
+		var isMessage uint8
+		if err := rows.Scan(&o.Id, &o.ProjectId, &o.TransactionId, &o.TransactionType, &o.ExceptionHash, &o.StackTrace, &o.RecordedAt, &scopeJSON, &o.AppVersion, &o.ServerName, &isMessage); err != nil {
+			return nil, nil, err
+		}
+		o.IsMessage = isMessage == 1
+		if scopeJSON != "" && scopeJSON != "{}" {
+			if err := json.Unmarshal([]byte(scopeJSON), &o.Scope); err != nil {
+				o.Scope = nil
+			}
+		}
+		occurrences = append(occurrences, o)
+	}
+
+	if len(occurrences) <= pageSize {
+		return occurrences, nil, nil
+	}
+	occurrences = occurrences[:pageSize]
+	last := occurrences[pageSize-1]
+	return occurrences, &models.PageCursor{SortBy: "recorded_at", Desc: true, Value: last.RecordedAt.Unix(), Id: last.Id}, nil
 }
 
 // CountByHour returns exception counts grouped by hour
@@ -279,49 +617,6 @@ func (e *exceptionStackTraceRepository) GetHourlyTrendForHashes(ctx context.Cont
 	return result, nil
 }
 
-// ArchiveByHashes archives exceptions by their hashes
-func (e *exceptionStackTraceRepository) ArchiveByHashes(ctx context.Context, projectId uuid.UUID, hashes []string) error {
-	if len(hashes) == 0 {
-		return nil
-	}
-
-	batch, err := (*chdb.Conn).PrepareBatch(ctx, "INSERT INTO archived_exceptions (project_id, exception_hash)")
-	if err != nil {
-		return err
-	}
-
-	for _, hash := range hashes {
-		if err := batch.Append(projectId, hash); err != nil {
-			return err
-		}
-	}
-
-	return batch.Send()
-}
-
-// UnarchiveByHashes removes exceptions from the archive
-func (e *exceptionStackTraceRepository) UnarchiveByHashes(ctx context.Context, projectId uuid.UUID, hashes []string) error {
-	if len(hashes) == 0 {
-		return nil
-	}
-
-	// In ClickHouse, we use ALTER TABLE DELETE for removing rows
-	query := "ALTER TABLE archived_exceptions DELETE WHERE project_id = ? AND exception_hash IN (?)"
-	return (*chdb.Conn).Exec(ctx, query, projectId, hashes)
-}
-
-// IsArchived checks if a specific exception hash is archived
-func (e *exceptionStackTraceRepository) IsArchived(ctx context.Context, projectId uuid.UUID, hash string) (bool, error) {
-	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx,
-		"SELECT count() FROM archived_exceptions FINAL WHERE project_id = ? AND exception_hash = ?",
-		projectId, hash).Scan(&count)
-	if err != nil {
-		return false, err
-	}
-	return count > 0, nil
-}
-
 func (e *exceptionStackTraceRepository) FindExceptionByTransactionId(ctx context.Context, projectId uuid.UUID, transactionId uuid.UUID) (*models.ExceptionStackTrace, error) {
 	var est models.ExceptionStackTrace
 	var scopeJSON string
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"backend/app/models"
+	"strings"
+)
+
+// appendFilterExpression limits a query to the records matching a parsed filter expression. Columns are prefixed
+// with the alias of the filtered table when the query joins others.
+func appendFilterExpression(query string, args []interface{}, alias string, expression *models.FilterNode) (string, []interface{}) {
+	if expression == nil {
+		return query, args
+	}
+	condition, conditionArgs := filterNodeSQL(*expression, alias)
+	return query + " AND " + condition, append(args, conditionArgs...)
+}
+
+func filterNodeSQL(node models.FilterNode, alias string) (string, []interface{}) {
+	switch node.Kind {
+	case models.FilterNodeAnd, models.FilterNodeOr:
+		parts := make([]string, len(node.Children))
+		var args []interface{}
+		for i, child := range node.Children {
+			var childArgs []interface{}
+			parts[i], childArgs = filterNodeSQL(child, alias)
+			args = append(args, childArgs...)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(node.Kind)+" ") + ")", args
+	case models.FilterNodeNot:
+		condition, args := filterNodeSQL(node.Children[0], alias)
+		return "NOT (" + condition + ")", args
+	}
+	return filterConditionSQL(*node.Condition, alias)
+}
+
+// filterConditionSQL only writes whitelisted column names and operators into the query, values are parameters
+func filterConditionSQL(c models.FilterCondition, alias string) (string, []interface{}) {
+	column := c.Column
+	if alias != "" {
+		column = alias + "." + column
+	}
+
+	var args []interface{}
+	if c.ScopeKey != "" {
+		if c.Operator == models.FilterOpExists {
+			return "JSONHas(" + column + ", ?)", []interface{}{c.ScopeKey}
+		}
+		column = "JSONExtractString(" + column + ", ?)"
+		args = append(args, c.ScopeKey)
+	}
+
+	switch c.Operator {
+	case models.FilterOpIn, models.FilterOpNotIn:
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(c.Values)), ", ")
+		return column + " " + c.Operator + " (" + placeholders + ")", append(args, c.Values...)
+	case models.FilterOpContains:
+		return "positionCaseInsensitive(" + column + ", ?) > 0", append(args, c.Values...)
+	}
+	return column + " " + c.Operator + " ?", append(args, c.Values...)
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"backend/app/models"
+	"reflect"
+	"testing"
+)
+
+func TestAppendFilterExpression(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		fields    map[string]models.FilterField
+		alias     string
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{"empty", "", models.EndpointFilterFields, "",
+			"project_id = ?", []interface{}{"p"}},
+		{"comparison", "status_code >= 500", models.EndpointFilterFields, "",
+			"project_id = ? AND status_code >= ?", []interface{}{"p", int64(500)}},
+		{"precedence", "status_code = 1 OR status_code = 2 AND endpoint != a", models.EndpointFilterFields, "",
+			"project_id = ? AND (status_code = ? OR (status_code = ? AND endpoint != ?))", []interface{}{"p", int64(1), int64(2), "a"}},
+		{"NOT", "NOT (duration > 1s OR endpoint = a)", models.EndpointFilterFields, "",
+			"project_id = ? AND NOT ((duration > ? OR endpoint = ?))", []interface{}{"p", int64(1000000000), "a"}},
+		{"IN", `server_name IN (a, "b c")`, models.EndpointFilterFields, "",
+			"project_id = ? AND server_name IN (?, ?)", []interface{}{"p", "a", "b c"}},
+		{"NOT IN", "status_code NOT IN (500)", models.EndpointFilterFields, "",
+			"project_id = ? AND status_code NOT IN (?)", []interface{}{"p", int64(500)}},
+		{"CONTAINS", "stack_trace CONTAINS timeout", models.ExceptionFilterFields, "",
+			"project_id = ? AND positionCaseInsensitive(stack_trace, ?) > 0", []interface{}{"p", "timeout"}},
+		{"bool", "is_message = false", models.ExceptionFilterFields, "",
+			"project_id = ? AND is_message = ?", []interface{}{"p", int64(0)}},
+		{"scope", `scope.tenant = "acme"`, models.TaskFilterFields, "",
+			"project_id = ? AND JSONExtractString(scope, ?) = ?", []interface{}{"p", "tenant", "acme"}},
+		{"scope IN", "scope.tenant IN (a, b)", models.TaskFilterFields, "",
+			"project_id = ? AND JSONExtractString(scope, ?) IN (?, ?)", []interface{}{"p", "tenant", "a", "b"}},
+		{"scope NOT EXISTS", "scope.tenant NOT EXISTS", models.TaskFilterFields, "",
+			"project_id = ? AND NOT (JSONHas(scope, ?))", []interface{}{"p", "tenant"}},
+		{"alias", "app_version = v1 AND scope.tenant CONTAINS ac", models.ExceptionFilterFields, "e",
+			"project_id = ? AND (e.app_version = ? AND positionCaseInsensitive(JSONExtractString(e.scope, ?), ?) > 0)",
+			[]interface{}{"p", "v1", "tenant", "ac"}},
+		{"values stay parameters", `endpoint = "x' OR 1 = 1 --"`, models.EndpointFilterFields, "",
+			"project_id = ? AND endpoint = ?", []interface{}{"p", "x' OR 1 = 1 --"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := models.ParseFilterExpression(tt.input, tt.fields)
+			if err != nil {
+				t.Fatalf("ParseFilterExpression(%q) returned %v", tt.input, err)
+			}
+			query, args := appendFilterExpression("project_id = ?", []interface{}{"p"}, tt.alias, expression)
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
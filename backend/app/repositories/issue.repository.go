@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+// ErrIssueConflict is returned when issues kept changing while they were being saved
+var ErrIssueConflict = errors.New("issue was changed concurrently, try again")
+
+type issueRepository struct{}
+
+const issueColumns = "project_id, exception_hash, status, assignee, priority, resolved_at, resolution, resolved_in_version, " +
+	"snoozed_at, snooze_until, snooze_occurrences, snooze_occurrences_per_hour, snooze_users, snooze_servers, created_at, updated_at"
+
+// Save inserts a new version of the issues that weren't changed since they were read, replacing the previous
+// ones on merge. An issue is unchanged while the stored updated_at is the one it was read with, or it is still not
+// stored when it was read as a new issue. Returns the IssueKey of the changed ones, which aren't saved and must be
+// read again.
+func (r *issueRepository) Save(ctx context.Context, issues []models.Issue) (map[string]bool, error) {
+	conflicts := make(map[string]bool)
+	if len(issues) == 0 {
+		return conflicts, nil
+	}
+
+	hashesByProject := make(map[uuid.UUID][]string)
+	for _, issue := range issues {
+		hashesByProject[issue.ProjectId] = append(hashesByProject[issue.ProjectId], issue.ExceptionHash)
+	}
+	stored := make(map[string]time.Time)
+	for projectId, hashes := range hashesByProject {
+		rows, err := (*chdb.Conn).Query(ctx, "SELECT exception_hash, updated_at FROM issues FINAL WHERE project_id = ? AND exception_hash IN (?)",
+			projectId, hashes)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var hash string
+			var updatedAt time.Time
+			if err := rows.Scan(&hash, &updatedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			stored[models.IssueKey(projectId, hash)] = updatedAt
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO issues ("+issueColumns+")")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range issues {
+		issue := &issues[i]
+		key := models.IssueKey(issue.ProjectId, issue.ExceptionHash)
+		// zero on both sides for a new issue that is still not stored
+		if !stored[key].Equal(issue.UpdatedAt) {
+			conflicts[key] = true
+			continue
+		}
+		issue.UpdatedAt = now
+		if issue.CreatedAt.IsZero() {
+			issue.CreatedAt = now
+		}
+		if err := batch.Append(issue.ProjectId, issue.ExceptionHash, issue.Status, issue.Assignee, issue.Priority,
+			issue.ResolvedAt, issue.Resolution, issue.ResolvedInVersion, issue.SnoozedAt, issue.Snooze.Until, uint32(issue.Snooze.Occurrences),
+			uint32(issue.Snooze.OccurrencesPerHour), uint32(issue.Snooze.Users), uint32(issue.Snooze.Servers), issue.CreatedAt, issue.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(conflicts) == len(issues) {
+		return conflicts, batch.Abort()
+	}
+	return conflicts, batch.Send()
+}
+
+// FindByHash returns the issue of the hash, a new unresolved one when it was never changed
+func (r *issueRepository) FindByHash(ctx context.Context, projectId uuid.UUID, exceptionHash string) (models.Issue, error) {
+	issues, err := r.FindByHashes(ctx, projectId, []string{exceptionHash})
+	if err != nil {
+		return models.Issue{}, err
+	}
+	if issue, ok := issues[exceptionHash]; ok {
+		return issue, nil
+	}
+	return models.NewIssue(projectId, exceptionHash), nil
+}
+
+// FindByHashes returns the stored issues of the hashes, keyed by hash. Hashes without a stored issue are
+// left out.
+func (r *issueRepository) FindByHashes(ctx context.Context, projectId uuid.UUID, hashes []string) (map[string]models.Issue, error) {
+	issues := make(map[string]models.Issue)
+	if len(hashes) == 0 {
+		return issues, nil
+	}
+
+	found, err := r.query(ctx, "SELECT "+issueColumns+" FROM issues FINAL WHERE project_id = ? AND exception_hash IN (?)", projectId, hashes)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range found {
+		issues[issue.ExceptionHash] = issue
+	}
+	return issues, nil
+}
+
+// FindAllSnoozed returns the snoozed issues of every project, used by the snooze job
+func (r *issueRepository) FindAllSnoozed(ctx context.Context) ([]models.Issue, error) {
+	return r.query(ctx, "SELECT "+issueColumns+" FROM issues FINAL WHERE status = ?", models.IssueStatusSnoozed)
+}
+
+func (r *issueRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.Issue, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issues := []models.Issue{}
+	for rows.Next() {
+		var issue models.Issue
+		var occurrences, occurrencesPerHour, users, servers uint32
+		if err := rows.Scan(&issue.ProjectId, &issue.ExceptionHash, &issue.Status, &issue.Assignee, &issue.Priority,
+			&issue.ResolvedAt, &issue.Resolution, &issue.ResolvedInVersion, &issue.SnoozedAt, &issue.Snooze.Until,
+			&occurrences, &occurrencesPerHour, &users, &servers, &issue.CreatedAt, &issue.UpdatedAt); err != nil {
+			return nil, err
+		}
+		issue.Snooze.Occurrences = int(occurrences)
+		issue.Snooze.OccurrencesPerHour = int(occurrencesPerHour)
+		issue.Snooze.Users = int(users)
+		issue.Snooze.Servers = int(servers)
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// InsertActivities appends entries to the timelines of issues
+func (r *issueRepository) InsertActivities(ctx context.Context, activities []models.IssueActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO issue_activities (id, project_id, exception_hash, actor, action, old_value, new_value, created_at)")
+	if err != nil {
+		return err
+	}
+
+	for _, a := range activities {
+		if err := batch.Append(a.Id, a.ProjectId, a.ExceptionHash, a.Actor, a.Action, a.OldValue, a.NewValue, a.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindActivities returns the timeline of an issue, most recent first
+func (r *issueRepository) FindActivities(ctx context.Context, projectId uuid.UUID, exceptionHash string, page, pageSize int) ([]models.IssueActivity, int64, error) {
+	offset := (page - 1) * pageSize
+
+	var count uint64
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM issue_activities WHERE project_id = ? AND exception_hash = ?",
+		projectId, exceptionHash).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT id, project_id, exception_hash, actor, action, old_value, new_value, created_at
+		FROM issue_activities
+		WHERE project_id = ? AND exception_hash = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?`, projectId, exceptionHash, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	activities := []models.IssueActivity{}
+	for rows.Next() {
+		var a models.IssueActivity
+		if err := rows.Scan(&a.Id, &a.ProjectId, &a.ExceptionHash, &a.Actor, &a.Action, &a.OldValue, &a.NewValue, &a.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		activities = append(activities, a)
+	}
+
+	return activities, int64(count), nil
+}
+
+var IssueRepository = issueRepository{}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrIssueCommentNotFound = errors.New("issue comment not found")
+
+type issueCommentRepository struct{}
+
+const issueCommentColumns = "id, project_id, exception_hash, author, body, created_at, updated_at"
+
+// Save inserts a new version of the comment, replacing the previous one on merge
+func (r *issueCommentRepository) Save(ctx context.Context, comment *models.IssueComment) error {
+	comment.UpdatedAt = time.Now()
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = comment.UpdatedAt
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO issue_comments ("+issueCommentColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		comment.Id, comment.ProjectId, comment.ExceptionHash, comment.Author, comment.Body, comment.CreatedAt, comment.UpdatedAt)
+}
+
+// FindByHash returns the comments of an issue, oldest first
+func (r *issueCommentRepository) FindByHash(ctx context.Context, projectId uuid.UUID, exceptionHash string) ([]models.IssueComment, error) {
+	return r.query(ctx, "SELECT "+issueCommentColumns+" FROM issue_comments FINAL WHERE project_id = ? AND exception_hash = ? ORDER BY created_at ASC",
+		projectId, exceptionHash)
+}
+
+func (r *issueCommentRepository) FindById(ctx context.Context, projectId uuid.UUID, exceptionHash string, id uuid.UUID) (*models.IssueComment, error) {
+	comments, err := r.query(ctx, "SELECT "+issueCommentColumns+" FROM issue_comments FINAL WHERE project_id = ? AND exception_hash = ? AND id = ?",
+		projectId, exceptionHash, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, ErrIssueCommentNotFound
+	}
+	return &comments[0], nil
+}
+
+func (r *issueCommentRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.IssueComment, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.IssueComment{}
+	for rows.Next() {
+		var c models.IssueComment
+		if err := rows.Scan(&c.Id, &c.ProjectId, &c.ExceptionHash, &c.Author, &c.Body, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+func (r *issueCommentRepository) Delete(ctx context.Context, projectId uuid.UUID, exceptionHash string, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE issue_comments DELETE WHERE project_id = ? AND exception_hash = ? AND id = ?", projectId, exceptionHash, id)
+}
+
+var IssueCommentRepository = issueCommentRepository{}
@@ -4,6 +4,7 @@ import (
 	"backend/app/chdb"
 	"backend/app/models"
 	"context"
+	"sort"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -13,12 +14,28 @@ import (
 type metricRecordRepository struct{}
 
 func (e *metricRecordRepository) InsertAsync(ctx context.Context, lines []models.MetricRecord) error {
-	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO metric_records (project_id, name, value, recorded_at, server_name)")
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO metric_records (project_id, name, value, recorded_at, server_name, metric_type, bucket_bounds, bucket_counts, tags)")
 	if err != nil {
 		return err
 	}
 	for _, m := range lines {
-		if err := batch.Append(m.ProjectId, m.Name, m.Value, m.RecordedAt, m.ServerName); err != nil {
+		metricType := m.Type
+		if metricType == "" {
+			metricType = models.DefaultMetricType(m.Name)
+		}
+		bucketBounds := m.BucketBounds
+		if bucketBounds == nil {
+			bucketBounds = []float64{}
+		}
+		bucketCounts := m.BucketCounts
+		if bucketCounts == nil {
+			bucketCounts = []uint64{}
+		}
+		tags := m.Tags
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		if err := batch.Append(m.ProjectId, m.Name, m.Value, m.RecordedAt, m.ServerName, metricType, bucketBounds, bucketCounts, tags); err != nil {
 			return err
 		}
 	}
@@ -113,23 +130,153 @@ func (e *metricRecordRepository) GetDistinctServers(ctx context.Context, project
 
 // GetAverageByIntervalPerServer returns metric averages grouped by interval and server
 func (e *metricRecordRepository) GetAverageByIntervalPerServer(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, servers []string) (map[string][]models.TimeSeriesPoint, error) {
+	return e.GetAverageByIntervalGrouped(ctx, projectId, name, start, end, intervalMinutes, models.MetricSeriesFilter{Servers: servers})
+}
+
+// GetAverageByIntervalGrouped returns metric averages grouped by interval and by the filter's series key (server, tag or none)
+func (e *metricRecordRepository) GetAverageByIntervalGrouped(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, filter models.MetricSeriesFilter) (map[string][]models.TimeSeriesPoint, error) {
+	return e.GetAggregateByIntervalGrouped(ctx, projectId, name, models.MetricAggregationAvg, start, end, intervalMinutes, filter)
+}
+
+// valueAggregations maps the aggregations computed directly over sample values to their SQL expression
+var valueAggregations = map[string]string{
+	models.MetricAggregationAvg: "avg(value)",
+	models.MetricAggregationMin: "min(value)",
+	models.MetricAggregationMax: "max(value)",
+	models.MetricAggregationSum: "sum(value)",
+	models.MetricAggregationP95: "quantile(0.95)(value)",
+}
+
+// GetAggregateByIntervalGrouped aggregates the sample values of a metric (avg, min, max, sum or p95) grouped by
+// interval and series key. Histogram percentiles and counter rates have their own functions.
+func (e *metricRecordRepository) GetAggregateByIntervalGrouped(ctx context.Context, projectId uuid.UUID, name, aggregation string, start, end time.Time, intervalMinutes int, filter models.MetricSeriesFilter) (map[string][]models.TimeSeriesPoint, error) {
+	aggregate, ok := valueAggregations[aggregation]
+	if !ok {
+		aggregate = valueAggregations[models.MetricAggregationAvg]
+	}
+
+	seriesKey, seriesArgs := seriesKeyExpr(filter.GroupBy)
 	query := `SELECT
 		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
-		server_name,
-		avg(value) as avg_value
+		` + seriesKey + ` as series_key,
+		` + aggregate + ` as aggregate_value
 	FROM metric_records
 	WHERE project_id = ? AND name = ? AND recorded_at >= ? AND recorded_at <= ?`
 
-	args := []interface{}{intervalMinutes, projectId, name, start, end}
+	args := append([]interface{}{intervalMinutes}, seriesArgs...)
+	args = append(args, projectId, name, start, end)
+
+	query, args = appendSeriesFilter(query, args, filter)
+
+	query += " GROUP BY bucket, series_key ORDER BY bucket ASC, series_key ASC"
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]models.TimeSeriesPoint)
+	for rows.Next() {
+		var bucket time.Time
+		var key string
+		var value float64
+		if err := rows.Scan(&bucket, &key, &value); err != nil {
+			return nil, err
+		}
+		result[key] = append(result[key], models.TimeSeriesPoint{
+			Timestamp: bucket,
+			Value:     value,
+		})
+	}
+	return result, nil
+}
 
+// appendServerFilter limits a metric query to the selected servers, or to all named servers when none are selected
+func appendServerFilter(query string, args []interface{}, servers []string) (string, []interface{}) {
 	if len(servers) > 0 {
 		query += " AND server_name IN (?)"
 		args = append(args, servers)
 	} else {
 		query += " AND server_name != ''"
 	}
+	return query, args
+}
+
+// appendSeriesFilter limits a metric query to the filter's servers and tag values
+func appendSeriesFilter(query string, args []interface{}, filter models.MetricSeriesFilter) (string, []interface{}) {
+	query, args = appendServerFilter(query, args, filter.Servers)
+
+	keys := make([]string, 0, len(filter.Tags))
+	for key := range filter.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query += " AND tags[?] = ?"
+		args = append(args, key, filter.Tags[key])
+	}
+	return query, args
+}
+
+// seriesKeyExpr returns the column expression a metric query is split into series by, records without the
+// grouped tag end up in the "" series
+func seriesKeyExpr(groupBy string) (string, []interface{}) {
+	switch groupBy {
+	case "", models.MetricGroupByServer:
+		return "server_name", nil
+	case models.MetricGroupByNone:
+		return "''", nil
+	}
+	return "tags[?]", []interface{}{groupBy}
+}
+
+// counterValues is the values of a counter's samples sorted by time
+const counterValues = "arrayMap(s -> s.2, arraySort(groupArray((recorded_at, value))))"
+
+// counterIncreaseExpr is how much a counter grew between its samples. A value lower than the one before it means
+// the counter was reset (process restart) and counted from 0.
+const counterIncreaseExpr = "arraySum(arrayMap((d, v) -> if(d >= 0, d, v), arrayDifference(" + counterValues + "), " + counterValues + "))"
+
+// counterIncrease returns how much a counter grew in a bucket given the increase between the samples of the bucket,
+// its first sample and the last sample of the previous bucket, the counter being reset when first is lower
+func counterIncrease(prevLast float64, hasPrev bool, first, increase float64) float64 {
+	if hasPrev {
+		if first >= prevLast {
+			increase += first - prevLast
+		} else {
+			increase += first
+		}
+	}
+	return increase
+}
 
-	query += " GROUP BY bucket, server_name ORDER BY bucket ASC, server_name ASC"
+// GetIncreaseByIntervalPerServer returns how much a counter metric increased in each interval, per server
+func (e *metricRecordRepository) GetIncreaseByIntervalPerServer(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, servers []string) (map[string][]models.TimeSeriesPoint, error) {
+	return e.GetIncreaseByIntervalGrouped(ctx, projectId, name, start, end, intervalMinutes, models.MetricSeriesFilter{Servers: servers})
+}
+
+// GetIncreaseByIntervalGrouped returns how much a counter metric increased in each interval, per series key.
+// Counters are tracked per server and tag set, so the increase is computed for each of those before summing into series.
+func (e *metricRecordRepository) GetIncreaseByIntervalGrouped(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, filter models.MetricSeriesFilter) (map[string][]models.TimeSeriesPoint, error) {
+	seriesKey, seriesArgs := seriesKeyExpr(filter.GroupBy)
+	query := `SELECT
+		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
+		` + seriesKey + ` as series_key,
+		server_name,
+		toString(tags) as tag_set,
+		argMin(value, recorded_at) as first_value,
+		argMax(value, recorded_at) as last_value,
+		` + counterIncreaseExpr + ` as increase
+	FROM metric_records
+	WHERE project_id = ? AND name = ? AND recorded_at >= ? AND recorded_at <= ?`
+
+	args := append([]interface{}{intervalMinutes}, seriesArgs...)
+	args = append(args, projectId, name, start, end)
+
+	query, args = appendSeriesFilter(query, args, filter)
+
+	query += " GROUP BY bucket, series_key, server_name, tag_set ORDER BY server_name ASC, tag_set ASC, bucket ASC"
 
 	rows, err := (*chdb.Conn).Query(ctx, query, args...)
 	if err != nil {
@@ -137,20 +284,391 @@ func (e *metricRecordRepository) GetAverageByIntervalPerServer(ctx context.Conte
 	}
 	defer rows.Close()
 
-	result := make(map[string][]models.TimeSeriesPoint)
+	increases := make(map[string]map[time.Time]float64)
+	prevLast := make(map[string]float64)
 	for rows.Next() {
 		var bucket time.Time
+		var key, serverName, tagSet string
+		var first, last, increase float64
+		if err := rows.Scan(&bucket, &key, &serverName, &tagSet, &first, &last, &increase); err != nil {
+			return nil, err
+		}
+		counter := serverName + "\x00" + tagSet
+		previous, hasPrev := prevLast[counter]
+		if increases[key] == nil {
+			increases[key] = make(map[time.Time]float64)
+		}
+		increases[key][bucket] += counterIncrease(previous, hasPrev, first, increase)
+		prevLast[counter] = last
+	}
+
+	result := make(map[string][]models.TimeSeriesPoint, len(increases))
+	for key, buckets := range increases {
+		points := make([]models.TimeSeriesPoint, 0, len(buckets))
+		for bucket, value := range buckets {
+			points = append(points, models.TimeSeriesPoint{Timestamp: bucket, Value: value})
+		}
+		sort.Slice(points, func(i, j int) bool {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		})
+		result[key] = points
+	}
+	return result, nil
+}
+
+// GetRateByIntervalPerServer returns the per-second rate of a counter metric in each interval, per server
+func (e *metricRecordRepository) GetRateByIntervalPerServer(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, servers []string) (map[string][]models.TimeSeriesPoint, error) {
+	return e.GetRateByIntervalGrouped(ctx, projectId, name, start, end, intervalMinutes, models.MetricSeriesFilter{Servers: servers})
+}
+
+// GetRateByIntervalGrouped returns the per-second rate of a counter metric in each interval, per series key
+func (e *metricRecordRepository) GetRateByIntervalGrouped(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, filter models.MetricSeriesFilter) (map[string][]models.TimeSeriesPoint, error) {
+	result, err := e.GetIncreaseByIntervalGrouped(ctx, projectId, name, start, end, intervalMinutes, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := float64(intervalMinutes * 60)
+	for key, points := range result {
+		for i := range points {
+			result[key][i].Value = points[i].Value / seconds
+		}
+	}
+	return result, nil
+}
+
+// GetRateBetween returns the per-second rate of a counter metric over the whole range, averaged across servers
+// (matching how dashboards aggregate per server trends)
+func (e *metricRecordRepository) GetRateBetween(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time) (float64, error) {
+	// counters are tracked per server and tag set, the increase of each is summed into its server
+	query := `SELECT
+		server_name,
+		` + counterIncreaseExpr + ` as increase
+	FROM metric_records
+	WHERE project_id = ? AND name = ? AND recorded_at >= ? AND recorded_at <= ?
+	GROUP BY server_name, toString(tags)`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, projectId, name, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var increase float64
+	servers := make(map[string]bool)
+	for rows.Next() {
 		var serverName string
-		var value float64
-		if err := rows.Scan(&bucket, &serverName, &value); err != nil {
+		var seriesIncrease float64
+		if err := rows.Scan(&serverName, &seriesIncrease); err != nil {
+			return 0, err
+		}
+		increase += seriesIncrease
+		servers[serverName] = true
+	}
+
+	seconds := end.Sub(start).Seconds()
+	if seconds <= 0 || len(servers) == 0 {
+		return 0, nil
+	}
+	return increase / float64(len(servers)) / seconds, nil
+}
+
+// GetPercentileByIntervalPerServer returns the q-quantile (0-1) of a histogram metric in each interval, per server.
+// Bucket counts of the records of an interval sharing the most common layout are merged before the quantile is
+// estimated.
+func (e *metricRecordRepository) GetPercentileByIntervalPerServer(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, servers []string, q float64) (map[string][]models.TimeSeriesPoint, error) {
+	return e.GetPercentileByIntervalGrouped(ctx, projectId, name, start, end, intervalMinutes, models.MetricSeriesFilter{Servers: servers}, q)
+}
+
+// GetPercentileByIntervalGrouped returns the q-quantile (0-1) of a histogram metric in each interval, per series key
+func (e *metricRecordRepository) GetPercentileByIntervalGrouped(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, intervalMinutes int, filter models.MetricSeriesFilter, q float64) (map[string][]models.TimeSeriesPoint, error) {
+	seriesKey, seriesArgs := seriesKeyExpr(filter.GroupBy)
+	query := `SELECT
+		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
+		` + seriesKey + ` as series_key,
+		bucket_bounds,
+		sumForEach(bucket_counts) as counts
+	FROM metric_records
+	WHERE project_id = ? AND name = ? AND metric_type = 'histogram' AND recorded_at >= ? AND recorded_at <= ?`
+
+	args := append([]interface{}{intervalMinutes}, seriesArgs...)
+	args = append(args, projectId, name, start, end)
+
+	query, args = appendSeriesFilter(query, args, filter)
+
+	query += " GROUP BY bucket, series_key, bucket_bounds ORDER BY bucket ASC, series_key ASC"
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type point struct {
+		bucket time.Time
+		key    string
+	}
+	var points []point
+	layouts := make(map[point]histogramLayout)
+	for rows.Next() {
+		var p point
+		var layout histogramLayout
+		if err := rows.Scan(&p.bucket, &p.key, &layout.bounds, &layout.counts); err != nil {
 			return nil, err
 		}
-		result[serverName] = append(result[serverName], models.TimeSeriesPoint{
-			Timestamp: bucket,
-			Value:     value,
+		current, ok := layouts[p]
+		if !ok {
+			points = append(points, p)
+		}
+		if !ok || layout.total() > current.total() {
+			layouts[p] = layout
+		}
+	}
+
+	result := make(map[string][]models.TimeSeriesPoint)
+	for _, p := range points {
+		layout := layouts[p]
+		result[p.key] = append(result[p.key], models.TimeSeriesPoint{
+			Timestamp: p.bucket,
+			Value:     models.HistogramQuantile(q, layout.bounds, layout.counts),
 		})
 	}
 	return result, nil
 }
 
+// histogramLayout is the merged bucket counts of the histogram samples sharing the same bucket bounds
+type histogramLayout struct {
+	bounds []float64
+	counts []uint64
+}
+
+func (h histogramLayout) total() uint64 {
+	var total uint64
+	for _, count := range h.counts {
+		total += count
+	}
+	return total
+}
+
+// queryHistogramLayout merges the bucket counts of the histogram samples matching a query selecting bucket_bounds
+// and sumForEach(bucket_counts) grouped by bucket_bounds. Counts only add up between samples with the same bounds,
+// so when reporters use different layouts the one holding the most observations is kept and the others dropped.
+func queryHistogramLayout(ctx context.Context, query string, args ...interface{}) (histogramLayout, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return histogramLayout{}, err
+	}
+	defer rows.Close()
+
+	var merged histogramLayout
+	for rows.Next() {
+		var layout histogramLayout
+		if err := rows.Scan(&layout.bounds, &layout.counts); err != nil {
+			return histogramLayout{}, err
+		}
+		if layout.total() > merged.total() {
+			merged = layout
+		}
+	}
+	return merged, rows.Err()
+}
+
+// GetPercentileBetween returns the q-quantile (0-1) of a histogram metric over the whole range across all servers
+func (e *metricRecordRepository) GetPercentileBetween(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, q float64) (float64, error) {
+	layout, err := queryHistogramLayout(ctx, `SELECT
+		bucket_bounds,
+		sumForEach(bucket_counts)
+	FROM metric_records
+	WHERE project_id = ? AND name = ? AND metric_type = 'histogram' AND recorded_at >= ? AND recorded_at <= ?
+	GROUP BY bucket_bounds`,
+		projectId, name, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return models.HistogramQuantile(q, layout.bounds, layout.counts), nil
+}
+
+// GetMetricType returns the type of the most recently recorded sample of a metric
+func (e *metricRecordRepository) GetMetricType(ctx context.Context, projectId uuid.UUID, name string) (string, error) {
+	var metricType string
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT coalesce(argMax(metric_type, recorded_at), '') FROM metric_records WHERE project_id = ? AND name = ?", projectId, name).Scan(&metricType)
+	if err != nil {
+		return "", err
+	}
+	if metricType == "" {
+		metricType = models.DefaultMetricType(name)
+	}
+	return metricType, nil
+}
+
+// GetAggregateBetween returns a single aggregated value of a metric over the whole range, restricted by the
+// filter's servers and tags (GroupBy is ignored). Rates are summed across servers and tag sets and histogram p95
+// is computed from the merged buckets.
+func (e *metricRecordRepository) GetAggregateBetween(ctx context.Context, projectId uuid.UUID, name, aggregation string, start, end time.Time, filter models.MetricSeriesFilter) (float64, error) {
+	if aggregation == models.MetricAggregationRate {
+		return e.getRateBetweenFiltered(ctx, projectId, name, start, end, filter)
+	}
+
+	metricType, err := e.GetMetricType(ctx, projectId, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if aggregation == models.MetricAggregationP95 && metricType == models.MetricTypeHistogram {
+		query := `SELECT
+			bucket_bounds,
+			sumForEach(bucket_counts)
+		FROM metric_records
+		WHERE project_id = ? AND name = ? AND metric_type = 'histogram' AND recorded_at >= ? AND recorded_at <= ?`
+		args := []interface{}{projectId, name, start, end}
+		query, args = appendSeriesFilter(query, args, filter)
+		query += " GROUP BY bucket_bounds"
+
+		layout, err := queryHistogramLayout(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return models.HistogramQuantile(0.95, layout.bounds, layout.counts), nil
+	}
+
+	aggregate, ok := valueAggregations[aggregation]
+	if !ok {
+		aggregate = valueAggregations[models.MetricAggregationAvg]
+	}
+	query := `SELECT ifNotFinite(coalesce(` + aggregate + `, 0), 0)
+	FROM metric_records
+	WHERE project_id = ? AND name = ? AND recorded_at >= ? AND recorded_at <= ?`
+	args := []interface{}{projectId, name, start, end}
+	query, args = appendSeriesFilter(query, args, filter)
+
+	var value float64
+	err = (*chdb.Conn).QueryRow(ctx, query, args...).Scan(&value)
+	return value, err
+}
+
+// getRateBetweenFiltered returns the summed per-second rate of the counters matching the filter over the range
+func (e *metricRecordRepository) getRateBetweenFiltered(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time, filter models.MetricSeriesFilter) (float64, error) {
+	query := `SELECT ` + counterIncreaseExpr + ` as increase
+	FROM metric_records
+	WHERE project_id = ? AND name = ? AND recorded_at >= ? AND recorded_at <= ?`
+	args := []interface{}{projectId, name, start, end}
+	query, args = appendSeriesFilter(query, args, filter)
+	query += " GROUP BY server_name, toString(tags)"
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var increase float64
+	for rows.Next() {
+		var seriesIncrease float64
+		if err := rows.Scan(&seriesIncrease); err != nil {
+			return 0, err
+		}
+		increase += seriesIncrease
+	}
+
+	seconds := end.Sub(start).Seconds()
+	if seconds <= 0 {
+		return 0, nil
+	}
+	return increase / seconds, nil
+}
+
+// GetCatalog lists every metric name a project reported in the time range with its type, last sample time,
+// servers, sample count and tag keys
+func (e *metricRecordRepository) GetCatalog(ctx context.Context, projectId uuid.UUID, start, end time.Time) ([]models.MetricCatalogEntry, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT
+		name,
+		argMax(metric_type, recorded_at) as metric_type,
+		max(recorded_at) as last_seen,
+		groupUniqArrayIf(100)(server_name, server_name != '') as servers,
+		count() as sample_count,
+		groupUniqArrayArray(100)(mapKeys(tags)) as tag_keys
+	FROM metric_records
+	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
+	GROUP BY name
+	ORDER BY name ASC`, projectId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalog := []models.MetricCatalogEntry{}
+	for rows.Next() {
+		var m models.MetricCatalogEntry
+		if err := rows.Scan(&m.Name, &m.Type, &m.LastSeen, &m.Servers, &m.SampleCount, &m.TagKeys); err != nil {
+			return nil, err
+		}
+		if m.Type == "" {
+			m.Type = models.DefaultMetricType(m.Name)
+		}
+		sort.Strings(m.Servers)
+		sort.Strings(m.TagKeys)
+		catalog = append(catalog, m)
+	}
+	return catalog, nil
+}
+
+// GetTagStats returns the tag keys recorded for a metric with their number of distinct values, highest cardinality first
+func (e *metricRecordRepository) GetTagStats(ctx context.Context, projectId uuid.UUID, name string, start, end time.Time) ([]models.MetricTagStats, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT
+		key,
+		uniq(tags[key]) as cardinality,
+		groupUniqArray(20)(tags[key]) as sample_values
+	FROM metric_records
+	ARRAY JOIN mapKeys(tags) AS key
+	WHERE project_id = ? AND name = ? AND recorded_at >= ? AND recorded_at <= ?
+	GROUP BY key
+	ORDER BY cardinality DESC, key ASC`, projectId, name, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.MetricTagStats{}
+	for rows.Next() {
+		var s models.MetricTagStats
+		if err := rows.Scan(&s.Key, &s.Cardinality, &s.SampleValues); err != nil {
+			return nil, err
+		}
+		sort.Strings(s.SampleValues)
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// GetKnownTagValues returns up to limit distinct values per metric name and tag key recorded since the given time,
+// keyed by metric name then tag key
+func (e *metricRecordRepository) GetKnownTagValues(ctx context.Context, projectId uuid.UUID, since time.Time, limit int) (map[string]map[string][]string, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT
+		name,
+		key,
+		groupUniqArray(?)(tags[key]) as tag_values
+	FROM metric_records
+	ARRAY JOIN mapKeys(tags) AS key
+	WHERE project_id = ? AND recorded_at >= ?
+	GROUP BY name, key`, limit, projectId, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[string][]string)
+	for rows.Next() {
+		var name, key string
+		var values []string
+		if err := rows.Scan(&name, &key, &values); err != nil {
+			return nil, err
+		}
+		if result[name] == nil {
+			result[name] = make(map[string][]string)
+		}
+		result[name][key] = values
+	}
+	return result, nil
+}
+
 var MetricRecordRepository = metricRecordRepository{}
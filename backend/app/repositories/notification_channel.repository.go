@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrNotificationChannelNotFound = errors.New("notification channel not found")
+
+type notificationChannelRepository struct{}
+
+const notificationChannelColumns = "id, project_id, name, channel_type, config, event_types, enabled, created_at, updated_at"
+
+// Save inserts a new version of the channel, replacing the previous one on merge
+func (r *notificationChannelRepository) Save(ctx context.Context, channel *models.NotificationChannel) error {
+	config := channel.Config
+	config.SecretSet = false
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	channel.UpdatedAt = time.Now()
+	if channel.CreatedAt.IsZero() {
+		channel.CreatedAt = channel.UpdatedAt
+	}
+	eventTypes := channel.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO notification_channels ("+notificationChannelColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		channel.Id, channel.ProjectId, channel.Name, channel.Type, string(configJson), eventTypes, channel.Enabled, channel.CreatedAt, channel.UpdatedAt)
+}
+
+// FindAll returns the project's channels ordered by name
+func (r *notificationChannelRepository) FindAll(ctx context.Context, projectId uuid.UUID) ([]models.NotificationChannel, error) {
+	return r.query(ctx, "SELECT "+notificationChannelColumns+" FROM notification_channels FINAL WHERE project_id = ? ORDER BY name ASC", projectId)
+}
+
+// FindEnabled returns the project's enabled channels
+func (r *notificationChannelRepository) FindEnabled(ctx context.Context, projectId uuid.UUID) ([]models.NotificationChannel, error) {
+	return r.query(ctx, "SELECT "+notificationChannelColumns+" FROM notification_channels FINAL WHERE project_id = ? AND enabled = true", projectId)
+}
+
+func (r *notificationChannelRepository) FindById(ctx context.Context, projectId, id uuid.UUID) (*models.NotificationChannel, error) {
+	channels, err := r.query(ctx, "SELECT "+notificationChannelColumns+" FROM notification_channels FINAL WHERE project_id = ? AND id = ?", projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, ErrNotificationChannelNotFound
+	}
+	return &channels[0], nil
+}
+
+func (r *notificationChannelRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.NotificationChannel, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := []models.NotificationChannel{}
+	for rows.Next() {
+		var channel models.NotificationChannel
+		var config string
+		if err := rows.Scan(&channel.Id, &channel.ProjectId, &channel.Name, &channel.Type, &config, &channel.EventTypes, &channel.Enabled, &channel.CreatedAt, &channel.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(config), &channel.Config); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// Delete removes the channel, its delivery log is kept
+func (r *notificationChannelRepository) Delete(ctx context.Context, projectId, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE notification_channels DELETE WHERE project_id = ? AND id = ?", projectId, id)
+}
+
+var NotificationChannelRepository = notificationChannelRepository{}
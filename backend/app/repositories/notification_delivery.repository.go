@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+type notificationDeliveryRepository struct{}
+
+const notificationDeliveryColumns = "id, project_id, channel_id, event_type, subject, body, event, status, attempts, last_error, response_code, next_attempt_at, created_at, updated_at"
+
+// Save inserts new deliveries or new versions of existing ones (the latest version wins on merge)
+func (r *notificationDeliveryRepository) Save(ctx context.Context, deliveries []models.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO notification_deliveries ("+notificationDeliveryColumns+")")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.UpdatedAt = now
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt = now
+		}
+		if err := batch.Append(d.Id, d.ProjectId, d.ChannelId, d.EventType, d.Subject, d.Body, d.Event, d.Status, d.Attempts, d.LastError, d.ResponseCode, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindDue returns pending deliveries of every project whose next attempt is due, oldest first.
+// Deliveries older than maxAge are not retried anymore.
+func (r *notificationDeliveryRepository) FindDue(ctx context.Context, now time.Time, maxAge time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	return r.query(ctx, "SELECT "+notificationDeliveryColumns+` FROM notification_deliveries FINAL
+		WHERE created_at >= ? AND status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC LIMIT ?`,
+		now.Add(-maxAge), models.NotificationDeliveryPending, now, limit)
+}
+
+// FindByProject returns the delivery log of a project, optionally narrowed to a channel and status, most recent first
+func (r *notificationDeliveryRepository) FindByProject(ctx context.Context, projectId uuid.UUID, channelId *uuid.UUID, status string, page, pageSize int) ([]models.NotificationDelivery, int64, error) {
+	offset := (page - 1) * pageSize
+
+	whereClause := "project_id = ?"
+	args := []interface{}{projectId}
+	if channelId != nil {
+		whereClause += " AND channel_id = ?"
+		args = append(args, *channelId)
+	}
+	if status != "" {
+		whereClause += " AND status = ?"
+		args = append(args, status)
+	}
+
+	var count uint64
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM notification_deliveries FINAL WHERE "+whereClause, args...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	deliveries, err := r.query(ctx, "SELECT "+notificationDeliveryColumns+" FROM notification_deliveries FINAL WHERE "+whereClause+" ORDER BY created_at DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, int64(count), nil
+}
+
+func (r *notificationDeliveryRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.NotificationDelivery, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.NotificationDelivery{}
+	for rows.Next() {
+		var d models.NotificationDelivery
+		if err := rows.Scan(&d.Id, &d.ProjectId, &d.ChannelId, &d.EventType, &d.Subject, &d.Body, &d.Event, &d.Status, &d.Attempts, &d.LastError, &d.ResponseCode, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
+}
+
+var NotificationDeliveryRepository = notificationDeliveryRepository{}
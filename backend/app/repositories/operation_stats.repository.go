@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type operationStatsRepository struct{}
+
+type operationTable struct {
+	table  string
+	column string
+	errors string
+}
+
+// operationTables maps an operation type to its table, the column naming the operation and its error count
+var operationTables = map[string]operationTable{
+	models.OperationTypeEndpoint: {table: "endpoints", column: "endpoint", errors: "countIf(status_code >= 400)"},
+	models.OperationTypeTask:     {table: "tasks", column: "task_name", errors: "toUInt64(0)"},
+}
+
+// FindHourly returns the stats of every hour with requests between start and end, of one operation or of all
+// of them when operation is empty
+func (o *operationStatsRepository) FindHourly(ctx context.Context, projectId uuid.UUID, operationType, operation string, start, end time.Time) ([]models.OperationStats, error) {
+	return o.query(ctx, "toStartOfHour(recorded_at)", projectId, operationType, operation, start, end)
+}
+
+// FindBetween returns the stats of each operation with requests between start and end as a single bucket
+// starting at start, of one operation or of all of them when operation is empty
+func (o *operationStatsRepository) FindBetween(ctx context.Context, projectId uuid.UUID, operationType, operation string, start, end time.Time) ([]models.OperationStats, error) {
+	return o.query(ctx, "toDateTime(?)", projectId, operationType, operation, start, end, start)
+}
+
+func (o *operationStatsRepository) query(ctx context.Context, bucketExpr string, projectId uuid.UUID, operationType, operation string, start, end time.Time, bucketArgs ...interface{}) ([]models.OperationStats, error) {
+	t := operationTables[operationType]
+	query := `SELECT ` + t.column + `, ` + bucketExpr + ` as bucket, count(), quantile(0.95)(duration) / 1000000, ` + t.errors + `
+		FROM ` + t.table + `
+		WHERE project_id = ? AND recorded_at >= ? AND recorded_at < ?`
+	args := append(bucketArgs, projectId, start, end)
+	if operation != "" {
+		query += " AND " + t.column + " = ?"
+		args = append(args, operation)
+	}
+	query += " GROUP BY " + t.column + ", bucket"
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.OperationStats{}
+	for rows.Next() {
+		var s models.OperationStats
+		if err := rows.Scan(&s.Operation, &s.Time, &s.Count, &s.P95, &s.Errors); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+var OperationStatsRepository = operationStatsRepository{}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"backend/app/models"
+	"time"
+)
+
+// appendKeyset selects the page of rows after the cursor in a listing sorted by sortBy then id, with one extra row
+// telling whether there is a next page. Seeking on the sort column instead of skipping rows with OFFSET keeps
+// deep pages as cheap as the first one. sortBy must be a whitelisted column.
+func appendKeyset(query string, args []interface{}, sortBy string, desc bool, cursor *models.PageCursor, pageSize int) (string, []interface{}, error) {
+	direction, op, bound := "ASC", ">", ">="
+	if desc {
+		direction, op, bound = "DESC", "<", "<="
+	}
+
+	if cursor != nil {
+		if cursor.SortBy != sortBy || cursor.Desc != desc {
+			return "", nil, models.ErrInvalidPageCursor
+		}
+		var value interface{} = cursor.Value
+		if sortBy == "recorded_at" {
+			value = time.Unix(cursor.Value, 0)
+			// the tuple comparison can't use the primary key, the plain bound on recorded_at can
+			query += " AND recorded_at " + bound + " ?"
+			args = append(args, value)
+		}
+		query += " AND (" + sortBy + ", id) " + op + " (?, ?)"
+		args = append(args, value, cursor.Id)
+	}
+
+	query += " ORDER BY " + sortBy + " " + direction + ", id " + direction + " LIMIT ?"
+	return query, append(args, pageSize+1), nil
+}
@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrPerformanceIssueNotFound = errors.New("performance issue not found")
+
+type performanceIssueRepository struct{}
+
+func (r *performanceIssueRepository) InsertAsync(ctx context.Context, issues []models.PerformanceIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO performance_issues (id, project_id, issue_type, issue_hash, transaction_id, transaction_type, transaction_name, segment_name, repeat_count, total_duration, recorded_at, app_version, server_name)")
+	if err != nil {
+		return err
+	}
+
+	for _, p := range issues {
+		if err := batch.Append(p.Id, p.ProjectId, p.IssueType, p.IssueHash, p.TransactionId, p.TransactionType, p.TransactionName, p.SegmentName, p.RepeatCount, p.TotalDuration, p.RecordedAt, p.AppVersion, p.ServerName); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindGrouped returns performance issues grouped by issue hash, hiding archived ones unless they recurred after archiving
+func (r *performanceIssueRepository) FindGrouped(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, issueType string, includeArchived bool) ([]models.PerformanceIssueGroup, int64, error) {
+	offset := (page - 1) * pageSize
+
+	// Parse sort direction from orderBy (e.g., "last_seen_asc" -> "last_seen", "ASC")
+	sortDirection := "DESC"
+	if strings.HasSuffix(orderBy, "_asc") {
+		orderBy = strings.TrimSuffix(orderBy, "_asc")
+		sortDirection = "ASC"
+	}
+
+	allowedOrderBy := map[string]bool{
+		"last_seen":          true,
+		"first_seen":         true,
+		"count":              true,
+		"max_repeat_count":   true,
+		"avg_total_duration": true,
+	}
+
+	if !allowedOrderBy[orderBy] {
+		orderBy = "count"
+	}
+
+	whereClause := "p.project_id = ? AND p.recorded_at >= ? AND p.recorded_at <= ?"
+	args := []interface{}{projectId, fromDate, toDate}
+
+	if issueType != "" {
+		whereClause += " AND p.issue_type = ?"
+		args = append(args, issueType)
+	}
+
+	// Archived performance issues share the archived_exceptions table with exceptions, keyed by hash
+	havingClause := ""
+	if !includeArchived {
+		havingClause = " HAVING any(a.archived_at) IS NULL OR max(p.recorded_at) > any(a.archived_at)"
+	}
+
+	archiveSubquery := `LEFT JOIN (
+		SELECT exception_hash, max(archived_at) as archived_at
+		FROM archived_exceptions FINAL
+		WHERE project_id = ?
+		GROUP BY exception_hash
+	) a ON p.issue_hash = a.exception_hash`
+
+	countQuery := `SELECT count() FROM (
+		SELECT p.issue_hash
+		FROM performance_issues p
+		` + archiveSubquery + `
+		WHERE ` + whereClause + `
+		GROUP BY p.issue_hash` + havingClause + `
+	)`
+
+	countArgs := append([]interface{}{projectId}, args...)
+	var count uint64
+	if err := (*chdb.Conn).QueryRow(ctx, countQuery, countArgs...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	fullQuery := `SELECT
+			p.issue_hash,
+			any(p.issue_type),
+			any(p.transaction_type),
+			any(p.transaction_name),
+			any(p.segment_name),
+			count() as count,
+			max(p.repeat_count) as max_repeat_count,
+			avg(p.repeat_count) as avg_repeat_count,
+			avg(p.total_duration) as avg_total_duration,
+			min(p.recorded_at) as first_seen,
+			max(p.recorded_at) as last_seen,
+			groupArray(5)(p.transaction_id) as sample_transactions
+		FROM performance_issues p
+		` + archiveSubquery + `
+		WHERE ` + whereClause + `
+		GROUP BY p.issue_hash` + havingClause + `
+		ORDER BY ` + orderBy + ` ` + sortDirection + ` LIMIT ? OFFSET ?`
+
+	queryArgs := append([]interface{}{projectId}, args...)
+	queryArgs = append(queryArgs, pageSize, offset)
+	rows, err := (*chdb.Conn).Query(ctx, fullQuery, queryArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var groups []models.PerformanceIssueGroup
+	for rows.Next() {
+		var g models.PerformanceIssueGroup
+		var avgTotalDuration float64
+		if err := rows.Scan(&g.IssueHash, &g.IssueType, &g.TransactionType, &g.TransactionName, &g.SegmentName, &g.Count, &g.MaxRepeatCount, &g.AvgRepeatCount, &avgTotalDuration, &g.FirstSeen, &g.LastSeen, &g.SampleTransactions); err != nil {
+			return nil, 0, err
+		}
+		g.AvgTotalDuration = time.Duration(avgTotalDuration)
+		groups = append(groups, g)
+	}
+
+	return groups, int64(count), nil
+}
+
+// FindByHash returns the grouped info for an issue hash and its individual occurrences (most recent first)
+func (r *performanceIssueRepository) FindByHash(ctx context.Context, projectId uuid.UUID, issueHash string, page, pageSize int) (*models.PerformanceIssueGroup, []models.PerformanceIssue, int64, error) {
+	offset := (page - 1) * pageSize
+
+	var group models.PerformanceIssueGroup
+	var avgTotalDuration float64
+	err := (*chdb.Conn).QueryRow(ctx,
+		`SELECT issue_hash, any(issue_type), any(transaction_type), any(transaction_name), any(segment_name), count(),
+			max(repeat_count), avg(repeat_count), avg(total_duration), min(recorded_at), max(recorded_at), groupArray(5)(transaction_id)
+		FROM performance_issues
+		WHERE project_id = ? AND issue_hash = ?
+		GROUP BY issue_hash`,
+		projectId, issueHash).Scan(&group.IssueHash, &group.IssueType, &group.TransactionType, &group.TransactionName, &group.SegmentName, &group.Count,
+		&group.MaxRepeatCount, &group.AvgRepeatCount, &avgTotalDuration, &group.FirstSeen, &group.LastSeen, &group.SampleTransactions)
+	if err != nil {
+		// ClickHouse returns error when no rows found in QueryRow
+		return nil, nil, 0, ErrPerformanceIssueNotFound
+	}
+	group.AvgTotalDuration = time.Duration(avgTotalDuration)
+
+	rows, err := (*chdb.Conn).Query(ctx,
+		`SELECT id, project_id, issue_type, issue_hash, transaction_id, transaction_type, transaction_name, segment_name, repeat_count, total_duration, recorded_at, app_version, server_name
+		FROM performance_issues
+		WHERE project_id = ? AND issue_hash = ?
+		ORDER BY recorded_at DESC LIMIT ? OFFSET ?`,
+		projectId, issueHash, pageSize, offset)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer rows.Close()
+
+	var occurrences []models.PerformanceIssue
+	for rows.Next() {
+		var p models.PerformanceIssue
+		if err := rows.Scan(&p.Id, &p.ProjectId, &p.IssueType, &p.IssueHash, &p.TransactionId, &p.TransactionType, &p.TransactionName, &p.SegmentName, &p.RepeatCount, &p.TotalDuration, &p.RecordedAt, &p.AppVersion, &p.ServerName); err != nil {
+			return nil, nil, 0, err
+		}
+		occurrences = append(occurrences, p)
+	}
+
+	return &group, occurrences, int64(group.Count), nil
+}
+
+// FindByTransactionId returns the performance issues detected in a single transaction
+func (r *performanceIssueRepository) FindByTransactionId(ctx context.Context, projectId, transactionId uuid.UUID) ([]models.PerformanceIssue, error) {
+	rows, err := (*chdb.Conn).Query(ctx,
+		`SELECT id, project_id, issue_type, issue_hash, transaction_id, transaction_type, transaction_name, segment_name, repeat_count, total_duration, recorded_at, app_version, server_name
+		FROM performance_issues
+		WHERE project_id = ? AND transaction_id = ?
+		ORDER BY repeat_count DESC`,
+		projectId, transactionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []models.PerformanceIssue
+	for rows.Next() {
+		var p models.PerformanceIssue
+		if err := rows.Scan(&p.Id, &p.ProjectId, &p.IssueType, &p.IssueHash, &p.TransactionId, &p.TransactionType, &p.TransactionName, &p.SegmentName, &p.RepeatCount, &p.TotalDuration, &p.RecordedAt, &p.AppVersion, &p.ServerName); err != nil {
+			return nil, err
+		}
+		issues = append(issues, p)
+	}
+
+	return issues, nil
+}
+
+var PerformanceIssueRepository = performanceIssueRepository{}
+
+// FindExistingHashes returns the distinct issue hashes among the given ones that have occurrences in the project
+func (r *performanceIssueRepository) FindExistingHashes(ctx context.Context, projectId uuid.UUID, issueHashes []string) ([]string, error) {
+	if len(issueHashes) == 0 {
+		return nil, nil
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx,
+		"SELECT DISTINCT issue_hash FROM performance_issues WHERE project_id = ? AND issue_hash IN (?)",
+		projectId, issueHashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// ArchiveByHashes archives performance issues by their issue hashes, exceptions are resolved in the issues instead
+func (r *performanceIssueRepository) ArchiveByHashes(ctx context.Context, projectId uuid.UUID, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(ctx, "INSERT INTO archived_exceptions (project_id, exception_hash)")
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		if err := batch.Append(projectId, hash); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// UnarchiveByHashes removes performance issues from the archive
+func (r *performanceIssueRepository) UnarchiveByHashes(ctx context.Context, projectId uuid.UUID, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	// In ClickHouse, we use ALTER TABLE DELETE for removing rows
+	query := "ALTER TABLE archived_exceptions DELETE WHERE project_id = ? AND exception_hash IN (?)"
+	return (*chdb.Conn).Exec(ctx, query, projectId, hashes)
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type projectSettingsRepository struct{}
+
+const projectSettingsColumns = "project_id, metric_tag_key_limit, metric_tag_value_limit, endpoint_retention_days, task_retention_days, " +
+	"segment_retention_days, exception_retention_days, message_retention_days, metric_retention_days, updated_at"
+
+// FindByProjectId returns the project's settings, or empty settings (all defaults) when none were saved
+func (p *projectSettingsRepository) FindByProjectId(ctx context.Context, projectId uuid.UUID) (*models.ProjectSettings, error) {
+	found, err := p.query(ctx, "SELECT "+projectSettingsColumns+" FROM project_settings FINAL WHERE project_id = ?", projectId)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return &models.ProjectSettings{ProjectId: projectId}, nil
+	}
+	return &found[0], nil
+}
+
+// FindAll returns the saved settings of every project, keyed by project. Projects that never saved settings are
+// left out.
+func (p *projectSettingsRepository) FindAll(ctx context.Context) (map[uuid.UUID]models.ProjectSettings, error) {
+	found, err := p.query(ctx, "SELECT "+projectSettingsColumns+" FROM project_settings FINAL")
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[uuid.UUID]models.ProjectSettings, len(found))
+	for _, s := range found {
+		settings[s.ProjectId] = s
+	}
+	return settings, nil
+}
+
+func (p *projectSettingsRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.ProjectSettings, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []models.ProjectSettings
+	for rows.Next() {
+		var s models.ProjectSettings
+		if err := rows.Scan(&s.ProjectId, &s.MetricTagKeyLimit, &s.MetricTagValueLimit, &s.EndpointRetentionDays, &s.TaskRetentionDays,
+			&s.SegmentRetentionDays, &s.ExceptionRetentionDays, &s.MessageRetentionDays, &s.MetricRetentionDays, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		settings = append(settings, s)
+	}
+	return settings, nil
+}
+
+// Save replaces the project's settings
+func (p *projectSettingsRepository) Save(ctx context.Context, settings *models.ProjectSettings) error {
+	settings.UpdatedAt = time.Now()
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO project_settings ("+projectSettingsColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		settings.ProjectId, settings.MetricTagKeyLimit, settings.MetricTagValueLimit, settings.EndpointRetentionDays, settings.TaskRetentionDays,
+		settings.SegmentRetentionDays, settings.ExceptionRetentionDays, settings.MessageRetentionDays, settings.MetricRetentionDays, settings.UpdatedAt)
+}
+
+var ProjectSettingsRepository = projectSettingsRepository{}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// rollup is a table a materialized view fills with the rows of a raw table aggregated per bucket of granularity
+type rollup struct {
+	table       string
+	granularity time.Duration
+}
+
+// rollupSource describes the rollups of a raw table and how to aggregate its rows the same way
+type rollupSource struct {
+	// rollups ordered from the coarsest
+	rollups []rollup
+	// columns read from the rollups, in the order rawSelect aggregates them
+	columns string
+	// rawSelect aggregates the rows of the raw table per minute like the rollups, followed by the WHERE conditions
+	rawSelect  string
+	rawGroupBy string
+}
+
+var endpointRollupSource = rollupSource{
+	rollups: []rollup{
+		{table: "endpoint_rollups_1h", granularity: time.Hour},
+		{table: "endpoint_rollups_1m", granularity: time.Minute},
+	},
+	columns: "bucket, endpoint, server_name, requests, errors, satisfied, tolerating, duration_sum, duration_quantiles, max_recorded_at",
+	rawSelect: `SELECT
+			toStartOfMinute(recorded_at) AS bucket,
+			endpoint,
+			server_name,
+			count() AS requests,
+			countIf(status_code >= 400) AS errors,
+			countIf(duration <= 500000000 AND status_code < 400) AS satisfied,
+			countIf(duration > 500000000 AND duration <= 2000000000 AND status_code < 400) AS tolerating,
+			sum(duration) AS duration_sum,
+			quantilesState(0.5, 0.95, 0.99)(duration) AS duration_quantiles,
+			max(recorded_at) AS max_recorded_at
+		FROM endpoints
+		WHERE `,
+	rawGroupBy: " GROUP BY bucket, endpoint, server_name",
+}
+
+var taskRollupSource = rollupSource{
+	rollups: []rollup{
+		{table: "task_rollups_1h", granularity: time.Hour},
+		{table: "task_rollups_1m", granularity: time.Minute},
+	},
+	columns: "bucket, task_name, server_name, runs, duration_sum, duration_quantiles, max_recorded_at",
+	rawSelect: `SELECT
+			toStartOfMinute(recorded_at) AS bucket,
+			task_name,
+			server_name,
+			count() AS runs,
+			sum(duration) AS duration_sum,
+			quantilesState(0.5, 0.95, 0.99)(duration) AS duration_quantiles,
+			max(recorded_at) AS max_recorded_at
+		FROM tasks
+		WHERE `,
+	rawGroupBy: " GROUP BY bucket, task_name, server_name",
+}
+
+// rollupCutoffs are when the materialized views started filling the rollups, set once by the migrations. Rows
+// recorded before a cutoff are only in the raw table.
+var rollupCutoffs struct {
+	sync.Mutex
+	values map[string]time.Time
+}
+
+// findRollupCutoffs returns the cutoffs of the rollups keyed by table, loaded on first use as they never change
+func findRollupCutoffs(ctx context.Context) (map[string]time.Time, error) {
+	rollupCutoffs.Lock()
+	defer rollupCutoffs.Unlock()
+	if rollupCutoffs.values != nil {
+		return rollupCutoffs.values, nil
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, "SELECT rollup, cutoff FROM rollup_cutoffs")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]time.Time)
+	for rows.Next() {
+		var table string
+		var cutoff time.Time
+		if err := rows.Scan(&table, &cutoff); err != nil {
+			return nil, err
+		}
+		values[table] = cutoff
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rollupCutoffs.values = values
+	return values, nil
+}
+
+// find returns the coarsest rollup whose buckets fit in the intervals of a series (0 for a single aggregate) and
+// cover at least one whole bucket of the range after its cutoff, along with the [from, to) part of the range its
+// buckets cover
+func (s rollupSource) find(start, end time.Time, intervalMinutes int, cutoffs map[string]time.Time) (*rollup, time.Time, time.Time) {
+	interval := time.Duration(intervalMinutes) * time.Minute
+	for i := range s.rollups {
+		r := &s.rollups[i]
+		cutoff, ok := cutoffs[r.table]
+		if !ok || interval%r.granularity != 0 {
+			continue
+		}
+		covered := start
+		if covered.Before(cutoff) {
+			covered = cutoff
+		}
+		from := covered.Truncate(r.granularity)
+		if from.Before(covered) {
+			from = from.Add(r.granularity)
+		}
+		to := end.Truncate(r.granularity)
+		if from.Before(to) {
+			return r, from, to
+		}
+	}
+	return nil, time.Time{}, time.Time{}
+}
+
+// appendRollupSource appends a subquery with the rows matching where between start and end aggregated per bucket.
+// Whole buckets are read from the coarsest rollup that fits, only the edges of the range and the time before the
+// rollup's cutoff are aggregated from the raw table. The rollups can't apply scope filters and filter expressions,
+// the raw table is read alone when given any. where may only use the key columns of the rollups.
+func appendRollupSource(ctx context.Context, query string, args []interface{}, source rollupSource, where string, whereArgs []interface{}, start, end time.Time, intervalMinutes int, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (string, []interface{}, error) {
+	var r *rollup
+	var from, to time.Time
+	if len(scopeFilters) == 0 && expression == nil {
+		cutoffs, err := findRollupCutoffs(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		r, from, to = source.find(start, end, intervalMinutes, cutoffs)
+	}
+
+	if r == nil {
+		rawWhere, rawArgs := appendScopeFilters(where+" AND recorded_at >= ? AND recorded_at <= ?", append(append([]interface{}{}, whereArgs...), start, end), "scope", scopeFilters)
+		rawWhere, rawArgs = appendFilterExpression(rawWhere, rawArgs, "", expression)
+		return query + "(" + source.rawSelect + rawWhere + source.rawGroupBy + ")", append(args, rawArgs...), nil
+	}
+
+	query += "(SELECT " + source.columns + " FROM " + r.table + " WHERE " + where + " AND bucket >= ? AND bucket < ?"
+	args = append(append(args, whereArgs...), from, to)
+	query += " UNION ALL " + source.rawSelect + where +
+		" AND ((recorded_at >= ? AND recorded_at < ?) OR (recorded_at >= ? AND recorded_at <= ?))" + source.rawGroupBy + ")"
+	args = append(append(args, whereArgs...), start, from, to, end)
+	return query, args, nil
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"backend/app/models"
+	"encoding/json"
+)
+
+// scopeLikePattern matches the serialized `"key":` or `"key":"value"` pair in a scope column. Scopes are written
+// with encoding/json so the pair is found as is, which lets the idx_scope ngram index skip granules before the
+// JSON is parsed.
+func scopeLikePattern(key string, value *string) string {
+	encodedKey, _ := json.Marshal(key)
+	pattern := likeEscaper.Replace(string(encodedKey)) + ":"
+	if value != nil {
+		encodedValue, _ := json.Marshal(*value)
+		pattern += likeEscaper.Replace(string(encodedValue))
+	}
+	return "%" + pattern + "%"
+}
+
+// appendScopeFilters limits a query to the records whose scope column matches every filter
+func appendScopeFilters(query string, args []interface{}, column string, filters []models.ScopeFilter) (string, []interface{}) {
+	for _, f := range filters {
+		switch f.Operator {
+		case models.ScopeFilterEquals:
+			query += " AND " + column + " LIKE ? AND JSONExtractString(" + column + ", ?) = ?"
+			args = append(args, scopeLikePattern(f.Key, &f.Value), f.Key, f.Value)
+		case models.ScopeFilterContains:
+			query += " AND " + column + " LIKE ? AND positionCaseInsensitive(JSONExtractString(" + column + ", ?), ?) > 0"
+			args = append(args, scopeLikePattern(f.Key, nil), f.Key, f.Value)
+		case models.ScopeFilterExists:
+			query += " AND " + column + " LIKE ? AND JSONHas(" + column + ", ?)"
+			args = append(args, scopeLikePattern(f.Key, nil), f.Key)
+		case models.ScopeFilterNotExists:
+			query += " AND NOT JSONHas(" + column + ", ?)"
+			args = append(args, f.Key)
+		}
+	}
+	return query, args
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"backend/app/models"
+	"strings"
+)
+
+// appendSearchQuery limits a query to the records whose column contains every positive term of the search and
+// none of the negated ones, ignoring case. Words are matched as whole tokens with hasToken and phrases with
+// multiSearchAny on the lower cased column, which the tokenbf_v1 index on lowerUTF8 of the column can skip
+// granules with.
+func appendSearchQuery(query string, args []interface{}, column string, search models.SearchQuery) (string, []interface{}) {
+	for _, term := range search.Terms {
+		text := strings.ToLower(term.Text)
+
+		var condition string
+		if term.IsToken() {
+			condition = "hasToken(lowerUTF8(" + column + "), ?)"
+			args = append(args, text)
+		} else {
+			condition = "multiSearchAny(lowerUTF8(" + column + "), ?)"
+			args = append(args, []string{text})
+		}
+
+		if term.Negated {
+			condition = "NOT " + condition
+		}
+		query += " AND " + condition
+	}
+	return query, args
+}
@@ -4,6 +4,9 @@ import (
 	"backend/app/chdb"
 	"backend/app/models"
 	"context"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/google/uuid"
@@ -17,12 +20,18 @@ func (r *segmentRepository) InsertAsync(ctx context.Context, segments []models.S
 	}
 
 	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
-		"INSERT INTO segments (id, transaction_id, project_id, name, start_time, duration, recorded_at)")
+		"INSERT INTO segments (id, transaction_id, project_id, name, start_time, duration, recorded_at, trace_id, parent_span_id, parent_id, attributes)")
 	if err != nil {
 		return err
 	}
 
 	for _, s := range segments {
+		attributesJSON := "{}"
+		if len(s.Attributes) != 0 {
+			if attributesBytes, err := json.Marshal(s.Attributes); err == nil {
+				attributesJSON = string(attributesBytes)
+			}
+		}
 		if err := batch.Append(
 			s.Id,
 			s.TransactionId,
@@ -31,6 +40,10 @@ func (r *segmentRepository) InsertAsync(ctx context.Context, segments []models.S
 			s.StartTime,
 			s.Duration,
 			s.RecordedAt,
+			s.TraceId,
+			s.ParentSpanId,
+			s.ParentId,
+			attributesJSON,
 		); err != nil {
 			return err
 		}
@@ -41,7 +54,7 @@ func (r *segmentRepository) InsertAsync(ctx context.Context, segments []models.S
 
 func (r *segmentRepository) FindByTransactionId(ctx context.Context, projectId, transactionId uuid.UUID) ([]models.Segment, error) {
 	query := `SELECT
-		id, transaction_id, project_id, name, start_time, duration, recorded_at
+		id, transaction_id, project_id, name, start_time, duration, recorded_at, trace_id, parent_span_id, parent_id, attributes
 	FROM segments
 	WHERE project_id = ? AND transaction_id = ?
 	ORDER BY start_time ASC`
@@ -55,16 +68,239 @@ func (r *segmentRepository) FindByTransactionId(ctx context.Context, projectId,
 	var segments []models.Segment
 	for rows.Next() {
 		var s models.Segment
+		var attributesJSON string
 		if err := rows.Scan(
 			&s.Id, &s.TransactionId, &s.ProjectId,
 			&s.Name, &s.StartTime, &s.Duration, &s.RecordedAt,
+			&s.TraceId, &s.ParentSpanId, &s.ParentId, &attributesJSON,
 		); err != nil {
 			return nil, err
 		}
+		// Parse attributes JSON
+		if attributesJSON != "" && attributesJSON != "{}" {
+			if err := json.Unmarshal([]byte(attributesJSON), &s.Attributes); err != nil {
+				s.Attributes = nil
+			}
+		}
 		segments = append(segments, s)
 	}
 
 	return segments, nil
 }
 
+// FindByTraceId returns every segment across all projects that belongs to the given trace
+func (r *segmentRepository) FindByTraceId(ctx context.Context, traceId string, fromDate, toDate time.Time) ([]models.Segment, error) {
+	query := `SELECT
+		id, transaction_id, project_id, name, start_time, duration, recorded_at, trace_id, parent_span_id, parent_id, attributes
+	FROM segments
+	WHERE trace_id = ? AND recorded_at >= ? AND recorded_at <= ?
+	ORDER BY start_time ASC`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, traceId, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []models.Segment
+	for rows.Next() {
+		var s models.Segment
+		var attributesJSON string
+		if err := rows.Scan(
+			&s.Id, &s.TransactionId, &s.ProjectId,
+			&s.Name, &s.StartTime, &s.Duration, &s.RecordedAt,
+			&s.TraceId, &s.ParentSpanId, &s.ParentId, &attributesJSON,
+		); err != nil {
+			return nil, err
+		}
+		// Parse attributes JSON
+		if attributesJSON != "" && attributesJSON != "{}" {
+			if err := json.Unmarshal([]byte(attributesJSON), &s.Attributes); err != nil {
+				s.Attributes = nil
+			}
+		}
+		segments = append(segments, s)
+	}
+
+	return segments, nil
+}
+
+// segmentTransactionJoin builds the FROM clause joining per-transaction segment aggregates with the transactions
+// (endpoints and/or tasks) that contain them. Segments are pre-aggregated per (name, transaction) so the
+// transaction duration is only counted once per segment name when computing its share of transaction time.
+func segmentTransactionJoin(projectId uuid.UUID, fromDate, toDate time.Time, segmentName, transactionType, transactionName string) (string, []interface{}) {
+	segmentWhere := "project_id = ? AND recorded_at >= ? AND recorded_at <= ?"
+	args := []interface{}{projectId, fromDate, toDate}
+	if segmentName != "" {
+		segmentWhere += " AND name = ?"
+		args = append(args, segmentName)
+	}
+
+	var transactionQueries []string
+	if transactionType == "" || transactionType == "endpoint" {
+		query := "SELECT id, duration, toString(endpoint) AS transaction_name, 'endpoint' AS transaction_type FROM endpoints WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?"
+		args = append(args, projectId, fromDate, toDate)
+		if transactionName != "" {
+			query += " AND endpoint = ?"
+			args = append(args, transactionName)
+		}
+		transactionQueries = append(transactionQueries, query)
+	}
+	if transactionType == "" || transactionType == "task" {
+		query := "SELECT id, duration, toString(task_name) AS transaction_name, 'task' AS transaction_type FROM tasks WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?"
+		args = append(args, projectId, fromDate, toDate)
+		if transactionName != "" {
+			query += " AND task_name = ?"
+			args = append(args, transactionName)
+		}
+		transactionQueries = append(transactionQueries, query)
+	}
+
+	from := `(
+		SELECT name, transaction_id, count() AS seg_count, sum(duration) AS seg_total, quantilesState(0.5, 0.95)(duration) AS q_state
+		FROM segments
+		WHERE ` + segmentWhere + `
+		GROUP BY name, transaction_id
+	) s
+	INNER JOIN (
+		` + strings.Join(transactionQueries, " UNION ALL ") + `
+	) t ON s.transaction_id = t.id`
+
+	return from, args
+}
+
+// segmentStatsOrderBy maps frontend field names to SQL expressions for the grouped segment queries
+var segmentStatsOrderBy = map[string]string{
+	"total_duration":         "total_duration",
+	"count":                  "count",
+	"transaction_count":      "transaction_count",
+	"avg_duration":           "avg_duration",
+	"p50_duration":           "p50_duration",
+	"p95_duration":           "p95_duration",
+	"transaction_time_share": "transaction_time_share",
+}
+
+const segmentStatsSelect = `sum(s.seg_count) AS count,
+		uniqExact(s.transaction_id) AS transaction_count,
+		sum(s.seg_total) AS total_duration,
+		sum(s.seg_total) / sum(s.seg_count) AS avg_duration,
+		quantilesMerge(0.5, 0.95)(s.q_state) AS q,
+		q[1] AS p50_duration,
+		q[2] AS p95_duration,
+		if(sum(t.duration) > 0, sum(s.seg_total) * 100.0 / sum(t.duration), 0) AS transaction_time_share`
+
+// FindGroupedByName returns per segment name statistics, project-wide or limited to one endpoint/task.
+// transactionType can be "endpoint", "task" or empty for both.
+func (r *segmentRepository) FindGroupedByName(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, transactionType, transactionName string, page, pageSize int, orderBy, sortDirection string) ([]models.SegmentStats, int64, error) {
+	from, args := segmentTransactionJoin(projectId, fromDate, toDate, "", transactionType, transactionName)
+
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM (SELECT s.name FROM "+from+" GROUP BY s.name)", args...).Scan(&count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	orderExpr, ok := segmentStatsOrderBy[orderBy]
+	if !ok {
+		orderExpr = segmentStatsOrderBy["total_duration"] // Default to the most expensive segments overall
+	}
+
+	// Validate sort direction
+	sortDir := "DESC"
+	if sortDirection == "asc" {
+		sortDir = "ASC"
+	}
+
+	query := `SELECT
+		s.name,
+		` + segmentStatsSelect + `
+	FROM ` + from + `
+	GROUP BY s.name
+	ORDER BY ` + orderExpr + ` ` + sortDir + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var stats []models.SegmentStats
+	for rows.Next() {
+		var st models.SegmentStats
+		var total int64
+		var q []float64
+		var avg, p50, p95 float64
+		if err := rows.Scan(&st.Name, &st.Count, &st.TransactionCount, &total, &avg, &q, &p50, &p95, &st.TransactionTimeShare); err != nil {
+			return nil, 0, err
+		}
+		st.TotalDuration = time.Duration(total)
+		st.AvgDuration = time.Duration(avg)
+		st.P50Duration = time.Duration(p50)
+		st.P95Duration = time.Duration(p95)
+		stats = append(stats, st)
+	}
+
+	return stats, int64(count), nil
+}
+
+// FindGroupedByTransaction returns statistics for a single segment name broken down by the endpoints/tasks that run it
+func (r *segmentRepository) FindGroupedByTransaction(ctx context.Context, projectId uuid.UUID, segmentName string, fromDate, toDate time.Time, transactionType string, page, pageSize int, orderBy, sortDirection string) ([]models.SegmentTransactionStats, int64, error) {
+	from, args := segmentTransactionJoin(projectId, fromDate, toDate, segmentName, transactionType, "")
+
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM (SELECT t.transaction_name, t.transaction_type FROM "+from+" GROUP BY t.transaction_name, t.transaction_type)", args...).Scan(&count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	orderExpr, ok := segmentStatsOrderBy[orderBy]
+	if !ok {
+		orderExpr = segmentStatsOrderBy["total_duration"]
+	}
+
+	// Validate sort direction
+	sortDir := "DESC"
+	if sortDirection == "asc" {
+		sortDir = "ASC"
+	}
+
+	query := `SELECT
+		t.transaction_name,
+		t.transaction_type,
+		` + segmentStatsSelect + `
+	FROM ` + from + `
+	GROUP BY t.transaction_name, t.transaction_type
+	ORDER BY ` + orderExpr + ` ` + sortDir + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var stats []models.SegmentTransactionStats
+	for rows.Next() {
+		var st models.SegmentTransactionStats
+		var total int64
+		var q []float64
+		var avg, p50, p95 float64
+		if err := rows.Scan(&st.TransactionName, &st.TransactionType, &st.Count, &st.TransactionCount, &total, &avg, &q, &p50, &p95, &st.TransactionTimeShare); err != nil {
+			return nil, 0, err
+		}
+		st.TotalDuration = time.Duration(total)
+		st.AvgDuration = time.Duration(avg)
+		st.P50Duration = time.Duration(p50)
+		st.P95Duration = time.Duration(p95)
+		stats = append(stats, st)
+	}
+
+	return stats, int64(count), nil
+}
+
 var SegmentRepository = segmentRepository{}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrSloNotFound = errors.New("slo not found")
+
+type sloRepository struct{}
+
+const sloColumns = "id, project_id, name, description, endpoint_patterns, max_good_status_code, max_duration_ms, target, window_days, created_at, updated_at"
+
+// likeEscaper escapes the LIKE wildcards of an endpoint pattern, * is then turned into %
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Save inserts a new version of the SLO, replacing the previous one on merge
+func (r *sloRepository) Save(ctx context.Context, slo *models.Slo) error {
+	slo.UpdatedAt = time.Now()
+	if slo.CreatedAt.IsZero() {
+		slo.CreatedAt = slo.UpdatedAt
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO slos ("+sloColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		slo.Id, slo.ProjectId, slo.Name, slo.Description, slo.EndpointPatterns, uint16(slo.MaxGoodStatusCode), uint32(slo.MaxDurationMs),
+		slo.Target, uint16(slo.WindowDays), slo.CreatedAt, slo.UpdatedAt)
+}
+
+// FindAll returns the project's SLOs ordered by name
+func (r *sloRepository) FindAll(ctx context.Context, projectId uuid.UUID) ([]models.Slo, error) {
+	return r.query(ctx, "SELECT "+sloColumns+" FROM slos FINAL WHERE project_id = ? ORDER BY name ASC", projectId)
+}
+
+func (r *sloRepository) FindById(ctx context.Context, projectId, id uuid.UUID) (*models.Slo, error) {
+	slos, err := r.query(ctx, "SELECT "+sloColumns+" FROM slos FINAL WHERE project_id = ? AND id = ?", projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(slos) == 0 {
+		return nil, ErrSloNotFound
+	}
+	return &slos[0], nil
+}
+
+func (r *sloRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.Slo, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slos := []models.Slo{}
+	for rows.Next() {
+		var slo models.Slo
+		var maxGoodStatusCode, windowDays uint16
+		var maxDurationMs uint32
+		if err := rows.Scan(&slo.Id, &slo.ProjectId, &slo.Name, &slo.Description, &slo.EndpointPatterns, &maxGoodStatusCode, &maxDurationMs,
+			&slo.Target, &windowDays, &slo.CreatedAt, &slo.UpdatedAt); err != nil {
+			return nil, err
+		}
+		slo.MaxGoodStatusCode = int(maxGoodStatusCode)
+		slo.MaxDurationMs = int(maxDurationMs)
+		slo.WindowDays = int(windowDays)
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+// Delete removes the SLO, waiting for the mutation so it is gone from the next listing
+func (r *sloRepository) Delete(ctx context.Context, projectId, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE slos DELETE WHERE project_id = ? AND id = ?", projectId, id)
+}
+
+// CountEvents returns the total and good requests matched by the SLO in each of the windows ending at end,
+// scanning the endpoints table once
+func (r *sloRepository) CountEvents(ctx context.Context, slo *models.Slo, end time.Time, windows []time.Duration) ([]models.SloEventCounts, error) {
+	var longest time.Duration
+	for _, w := range windows {
+		if w > longest {
+			longest = w
+		}
+	}
+
+	var selects []string
+	var args []interface{}
+	for _, w := range windows {
+		selects = append(selects, "countIf(recorded_at >= ?)", "countIf(recorded_at >= ? AND good)")
+		args = append(args, end.Add(-w), end.Add(-w))
+	}
+
+	good := "status_code <= ?"
+	args = append(args, slo.MaxGoodStatusCode)
+	if slo.MaxDurationMs > 0 {
+		good += " AND duration <= ?"
+		args = append(args, int64(slo.MaxDurationMs)*int64(time.Millisecond))
+	}
+
+	query := `SELECT ` + strings.Join(selects, ", ") + `
+		FROM (
+			SELECT recorded_at, ` + good + ` as good
+			FROM endpoints
+			WHERE project_id = ? AND recorded_at >= ? AND recorded_at < ?`
+	args = append(args, slo.ProjectId, end.Add(-longest), end)
+	if len(slo.EndpointPatterns) > 0 {
+		conditions := make([]string, len(slo.EndpointPatterns))
+		for i, pattern := range slo.EndpointPatterns {
+			conditions[i] = "endpoint LIKE ?"
+			args = append(args, strings.ReplaceAll(likeEscaper.Replace(pattern), "*", "%"))
+		}
+		query += " AND (" + strings.Join(conditions, " OR ") + ")"
+	}
+	query += ")"
+
+	values := make([]uint64, len(windows)*2)
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := (*chdb.Conn).QueryRow(ctx, query, args...).Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.SloEventCounts, len(windows))
+	for i := range windows {
+		counts[i] = models.SloEventCounts{Total: values[i*2], Good: values[i*2+1]}
+	}
+	return counts, nil
+}
+
+var SloRepository = sloRepository{}
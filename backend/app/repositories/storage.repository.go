@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type storageRepository struct{}
+
+// RetentionTarget is a table the rows of a data type are stored in, Condition selecting them when the table is
+// shared with another data type. Every table is partitioned by day of its TimeColumn, recorded_at when empty.
+type RetentionTarget struct {
+	DataType   string
+	Table      string
+	Condition  string
+	TimeColumn string
+}
+
+var RetentionTargets = []RetentionTarget{
+	{DataType: models.DataTypeEndpoints, Table: "endpoints"},
+	{DataType: models.DataTypeEndpoints, Table: "endpoint_rollups_1m", TimeColumn: "bucket"},
+	{DataType: models.DataTypeEndpoints, Table: "endpoint_rollups_1h", TimeColumn: "bucket"},
+	{DataType: models.DataTypeTasks, Table: "tasks"},
+	{DataType: models.DataTypeTasks, Table: "task_rollups_1m", TimeColumn: "bucket"},
+	{DataType: models.DataTypeTasks, Table: "task_rollups_1h", TimeColumn: "bucket"},
+	{DataType: models.DataTypeSegments, Table: "segments"},
+	{DataType: models.DataTypeExceptions, Table: "exception_stack_traces", Condition: "is_message = 0"},
+	{DataType: models.DataTypeMessages, Table: "exception_stack_traces", Condition: "is_message = 1"},
+	{DataType: models.DataTypeMetrics, Table: "metric_records"},
+}
+
+func (t RetentionTarget) expiredWhere() string {
+	timeColumn := t.TimeColumn
+	if timeColumn == "" {
+		timeColumn = "recorded_at"
+	}
+	where := "project_id = ? AND " + timeColumn + " < ?"
+	if t.Condition != "" {
+		where += " AND " + t.Condition
+	}
+	return where
+}
+
+// HasExpiredRows reports whether the project has rows of the data type recorded before cutoff
+func (s *storageRepository) HasExpiredRows(ctx context.Context, target RetentionTarget, projectId uuid.UUID, cutoff time.Time) (bool, error) {
+	rows, err := (*chdb.Conn).Query(ctx, "SELECT 1 FROM "+target.Table+" WHERE "+target.expiredWhere()+" LIMIT 1", projectId, cutoff)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
+
+// DeleteExpiredRows queues a mutation deleting the rows of the data type the project recorded before cutoff. It
+// doesn't wait for the mutation, which rewrites the affected parts in the background.
+func (s *storageRepository) DeleteExpiredRows(ctx context.Context, target RetentionTarget, projectId uuid.UUID, cutoff time.Time) error {
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE "+target.Table+" DELETE WHERE "+target.expiredWhere(), projectId, cutoff)
+}
+
+// HasPendingMutations reports whether mutations of the table are still running, new deletes then wait for them
+func (s *storageRepository) HasPendingMutations(ctx context.Context, table string) (bool, error) {
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM system.mutations WHERE database = currentDatabase() AND table = ? AND is_done = 0", table).Scan(&count)
+	return count > 0, err
+}
+
+// FindPartitions returns the ids of the active partitions of a table, YYYYMMDD days for the tables of RetentionTargets
+func (s *storageRepository) FindPartitions(ctx context.Context, table string) ([]string, error) {
+	rows, err := (*chdb.Conn).Query(ctx, "SELECT DISTINCT partition_id FROM system.parts WHERE database = currentDatabase() AND table = ? AND active ORDER BY partition_id", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []string
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions, nil
+}
+
+// FindServerLocation returns the time zone of the ClickHouse server, in which toYYYYMMDD partition ids are days
+func (s *storageRepository) FindServerLocation(ctx context.Context) (*time.Location, error) {
+	var timezone string
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT timezone()").Scan(&timezone); err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(timezone)
+}
+
+// DropPartition removes a whole partition of a table, which is instant compared to deleting its rows
+func (s *storageRepository) DropPartition(ctx context.Context, table string, partitionId string) error {
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE "+table+" DROP PARTITION ID ?", partitionId)
+}
+
+// FindTableStorage returns the disk usage of every table of the database, largest first
+func (s *storageRepository) FindTableStorage(ctx context.Context) ([]models.TableStorage, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT table, sum(rows), sum(bytes_on_disk), sum(data_uncompressed_bytes), uniq(partition_id)
+		FROM system.parts
+		WHERE database = currentDatabase() AND active
+		GROUP BY table
+		ORDER BY sum(bytes_on_disk) DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []models.TableStorage{}
+	for rows.Next() {
+		var t models.TableStorage
+		if err := rows.Scan(&t.Table, &t.Rows, &t.BytesOnDisk, &t.UncompressedBytes, &t.Partitions); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// FindProjectRows counts the rows of each project in every table having a project_id column, keyed by table then
+// project. Rows of replacing tables that weren't merged yet are counted too.
+func (s *storageRepository) FindProjectRows(ctx context.Context) (map[string]map[uuid.UUID]uint64, error) {
+	tableRows, err := (*chdb.Conn).Query(ctx, `SELECT c.table
+		FROM system.columns c
+		INNER JOIN system.tables t ON t.database = c.database AND t.name = c.table
+		WHERE c.database = currentDatabase() AND c.name = 'project_id' AND t.engine LIKE '%MergeTree'`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for tableRows.Next() {
+		var table string
+		if err := tableRows.Scan(&table); err != nil {
+			tableRows.Close()
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	tableRows.Close()
+
+	counts := make(map[string]map[uuid.UUID]uint64, len(tables))
+	for _, table := range tables {
+		rows, err := (*chdb.Conn).Query(ctx, "SELECT project_id, count() FROM `"+table+"` GROUP BY project_id")
+		if err != nil {
+			return nil, err
+		}
+		counts[table] = make(map[uuid.UUID]uint64)
+		for rows.Next() {
+			var projectId uuid.UUID
+			var count uint64
+			if err := rows.Scan(&projectId, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			counts[table][projectId] = count
+		}
+		rows.Close()
+	}
+	return counts, nil
+}
+
+var StorageRepository = storageRepository{}
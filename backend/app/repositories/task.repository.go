@@ -14,7 +14,7 @@ import (
 type taskRepository struct{}
 
 func (e *taskRepository) InsertAsync(ctx context.Context, lines []models.Task) error {
-	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO tasks (id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name)")
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO tasks (id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name, trace_id, parent_span_id)")
 	if err != nil {
 		return err
 	}
@@ -25,7 +25,7 @@ func (e *taskRepository) InsertAsync(ctx context.Context, lines []models.Task) e
 				scopeJSON = string(scopeBytes)
 			}
 		}
-		if err := batch.Append(t.Id, t.ProjectId, t.TaskName, t.Duration, t.RecordedAt, t.ClientIP, scopeJSON, t.AppVersion, t.ServerName); err != nil {
+		if err := batch.Append(t.Id, t.ProjectId, t.TaskName, t.Duration, t.RecordedAt, t.ClientIP, scopeJSON, t.AppVersion, t.ServerName, t.TraceId, t.ParentSpanId); err != nil {
 			return err
 		}
 	}
@@ -38,9 +38,12 @@ func (e *taskRepository) CountBetween(ctx context.Context, projectId uuid.UUID,
 	return int64(count), err
 }
 
-func (e *taskRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string) ([]models.Task, int64, error) {
+func (e *taskRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.Task, int64, error) {
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND recorded_at >= ? AND recorded_at <= ?", []interface{}{projectId, fromDate, toDate}, "scope", scopeFilters)
+	whereClause, whereArgs = appendFilterExpression(whereClause, whereArgs, "", expression)
+
 	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM tasks WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?", projectId, fromDate, toDate).Scan(&count)
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM tasks WHERE "+whereClause, whereArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -56,8 +59,8 @@ func (e *taskRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromD
 		orderBy = "recorded_at"
 	}
 
-	query := "SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name FROM tasks WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ? ORDER BY " + orderBy + " DESC LIMIT ? OFFSET ?"
-	rows, err := (*chdb.Conn).Query(ctx, query, projectId, fromDate, toDate, pageSize, offset)
+	query := "SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name, trace_id, parent_span_id FROM tasks WHERE " + whereClause + " ORDER BY " + orderBy + " DESC LIMIT ? OFFSET ?"
+	rows, err := (*chdb.Conn).Query(ctx, query, append(whereArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -67,7 +70,7 @@ func (e *taskRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromD
 	for rows.Next() {
 		var t models.Task
 		var scopeJSON string
-		if err := rows.Scan(&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName); err != nil {
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
 			return nil, 0, err
 		}
 		// Parse scope JSON
@@ -82,10 +85,15 @@ func (e *taskRepository) FindAll(ctx context.Context, projectId uuid.UUID, fromD
 	return tasks, int64(count), nil
 }
 
-func (e *taskRepository) FindGroupedByTaskName(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string) ([]models.TaskStats, int64, error) {
+func (e *taskRepository) FindGroupedByTaskName(ctx context.Context, projectId uuid.UUID, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.TaskStats, int64, error) {
 	// Count unique task names
+	source, sourceArgs, err := appendRollupSource(ctx, "", nil, taskRollupSource, "project_id = ?", []interface{}{projectId}, fromDate, toDate, 0, scopeFilters, expression)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx, "SELECT uniq(task_name) FROM tasks WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?", projectId, fromDate, toDate).Scan(&count)
+	err = (*chdb.Conn).QueryRow(ctx, "SELECT uniq(task_name) FROM "+source, sourceArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -115,18 +123,17 @@ func (e *taskRepository) FindGroupedByTaskName(ctx context.Context, projectId uu
 
 	query := `SELECT
 		task_name,
-		count() as count,
-		quantile(0.5)(duration) as p50_duration,
-		quantile(0.95)(duration) as p95_duration,
-		avg(duration) as avg_duration,
-		max(recorded_at) as last_seen
-	FROM tasks
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		sum(runs) as count,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[1] as p50_duration,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[2] as p95_duration,
+		sum(duration_sum) / sum(runs) as avg_duration,
+		max(max_recorded_at) as last_seen
+	FROM ` + source + `
 	GROUP BY task_name
 	ORDER BY ` + orderExpr + ` ` + sortDir + `
 	LIMIT ? OFFSET ?`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, projectId, fromDate, toDate, pageSize, offset)
+	rows, err := (*chdb.Conn).Query(ctx, query, append(sourceArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -148,9 +155,12 @@ func (e *taskRepository) FindGroupedByTaskName(ctx context.Context, projectId uu
 	return stats, int64(count), nil
 }
 
-func (e *taskRepository) FindByTaskName(ctx context.Context, projectId uuid.UUID, taskName string, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string) ([]models.Task, int64, error) {
+func (e *taskRepository) FindByTaskName(ctx context.Context, projectId uuid.UUID, taskName string, fromDate, toDate time.Time, page, pageSize int, orderBy string, sortDirection string, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.Task, int64, error) {
+	whereClause, whereArgs := appendScopeFilters("project_id = ? AND task_name = ? AND recorded_at >= ? AND recorded_at <= ?", []interface{}{projectId, taskName, fromDate, toDate}, "scope", scopeFilters)
+	whereClause, whereArgs = appendFilterExpression(whereClause, whereArgs, "", expression)
+
 	var count uint64
-	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM tasks WHERE project_id = ? AND task_name = ? AND recorded_at >= ? AND recorded_at <= ?", projectId, taskName, fromDate, toDate).Scan(&count)
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM tasks WHERE "+whereClause, whereArgs...).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -172,8 +182,8 @@ func (e *taskRepository) FindByTaskName(ctx context.Context, projectId uuid.UUID
 		sortDir = "ASC"
 	}
 
-	query := "SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name FROM tasks WHERE project_id = ? AND task_name = ? AND recorded_at >= ? AND recorded_at <= ? ORDER BY " + orderBy + " " + sortDir + " LIMIT ? OFFSET ?"
-	rows, err := (*chdb.Conn).Query(ctx, query, projectId, taskName, fromDate, toDate, pageSize, offset)
+	query := "SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name, trace_id, parent_span_id FROM tasks WHERE " + whereClause + " ORDER BY " + orderBy + " " + sortDir + " LIMIT ? OFFSET ?"
+	rows, err := (*chdb.Conn).Query(ctx, query, append(whereArgs, pageSize, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -183,7 +193,7 @@ func (e *taskRepository) FindByTaskName(ctx context.Context, projectId uuid.UUID
 	for rows.Next() {
 		var t models.Task
 		var scopeJSON string
-		if err := rows.Scan(&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName); err != nil {
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
 			return nil, 0, err
 		}
 		// Parse scope JSON
@@ -198,9 +208,84 @@ func (e *taskRepository) FindByTaskName(ctx context.Context, projectId uuid.UUID
 	return tasks, int64(count), nil
 }
 
+// taskPageSortColumns are the columns a task listing can be sorted and paginated on
+var taskPageSortColumns = map[string]bool{
+	"recorded_at": true,
+	"duration":    true,
+}
+
+// pageWhere returns the conditions of a listing of the task runs of a project, or of one task when taskName isn't
+// empty
+func (e *taskRepository) pageWhere(projectId uuid.UUID, taskName string, fromDate, toDate time.Time, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (string, []interface{}) {
+	whereClause := "project_id = ? AND recorded_at >= ? AND recorded_at <= ?"
+	whereArgs := []interface{}{projectId, fromDate, toDate}
+	if taskName != "" {
+		whereClause += " AND task_name = ?"
+		whereArgs = append(whereArgs, taskName)
+	}
+	whereClause, whereArgs = appendScopeFilters(whereClause, whereArgs, "scope", scopeFilters)
+	return appendFilterExpression(whereClause, whereArgs, "", expression)
+}
+
+// FindPage lists the task runs of a project, or of one task when taskName isn't empty, a page at a time with
+// keyset pagination. It returns the cursor of the next page, nil on the last page.
+func (e *taskRepository) FindPage(ctx context.Context, projectId uuid.UUID, taskName string, fromDate, toDate time.Time, pageSize int, orderBy string, sortDirection string, cursor *models.PageCursor, scopeFilters []models.ScopeFilter, expression *models.FilterNode) ([]models.Task, *models.PageCursor, error) {
+	if !taskPageSortColumns[orderBy] {
+		orderBy = "recorded_at"
+	}
+	desc := sortDirection != "asc"
+
+	whereClause, whereArgs := e.pageWhere(projectId, taskName, fromDate, toDate, scopeFilters, expression)
+	query, args, err := appendKeyset("SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name, trace_id, parent_span_id FROM tasks WHERE "+whereClause,
+		whereArgs, orderBy, desc, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var t models.Task
+		var scopeJSON string
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
+			return nil, nil, err
+		}
+		if scopeJSON != "" && scopeJSON != "{}" {
+			if err := json.Unmarshal([]byte(scopeJSON), &t.Scope); err != nil {
+				t.Scope = nil
+			}
+		}
+		tasks = append(tasks, t)
+	}
+
+	if len(tasks) <= pageSize {
+		return tasks, nil, nil
+	}
+	tasks = tasks[:pageSize]
+	last := tasks[pageSize-1]
+	next := &models.PageCursor{SortBy: orderBy, Desc: desc, Value: last.RecordedAt.Unix(), Id: last.Id}
+	if orderBy == "duration" {
+		next.Value = int64(last.Duration)
+	}
+	return tasks, next, nil
+}
+
+// Count counts the task runs of a listing, it is only run when a total is asked for as it reads every matching row
+func (e *taskRepository) Count(ctx context.Context, projectId uuid.UUID, taskName string, fromDate, toDate time.Time, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (int64, error) {
+	whereClause, whereArgs := e.pageWhere(projectId, taskName, fromDate, toDate, scopeFilters, expression)
+	var count uint64
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM tasks WHERE "+whereClause, whereArgs...).Scan(&count)
+	return int64(count), err
+}
+
 // FindById returns a single task by ID
 func (e *taskRepository) FindById(ctx context.Context, projectId, taskId uuid.UUID) (*models.Task, error) {
-	query := `SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name
+	query := `SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name, trace_id, parent_span_id
 		FROM tasks
 		WHERE project_id = ? AND id = ?
 		LIMIT 1`
@@ -210,7 +295,7 @@ func (e *taskRepository) FindById(ctx context.Context, projectId, taskId uuid.UU
 
 	err := (*chdb.Conn).QueryRow(ctx, query, projectId, taskId).Scan(
 		&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt,
-		&t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName)
+		&t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId)
 
 	if err != nil {
 		return nil, err
@@ -226,6 +311,38 @@ func (e *taskRepository) FindById(ctx context.Context, projectId, taskId uuid.UU
 	return &t, nil
 }
 
+// FindByTraceId returns every task across all projects that belongs to the given trace
+func (e *taskRepository) FindByTraceId(ctx context.Context, traceId string, fromDate, toDate time.Time) ([]models.Task, error) {
+	query := `SELECT id, project_id, task_name, duration, recorded_at, client_ip, scope, app_version, server_name, trace_id, parent_span_id
+		FROM tasks
+		WHERE trace_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		ORDER BY recorded_at ASC`
+
+	rows, err := (*chdb.Conn).Query(ctx, query, traceId, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		var t models.Task
+		var scopeJSON string
+		if err := rows.Scan(&t.Id, &t.ProjectId, &t.TaskName, &t.Duration, &t.RecordedAt, &t.ClientIP, &scopeJSON, &t.AppVersion, &t.ServerName, &t.TraceId, &t.ParentSpanId); err != nil {
+			return nil, err
+		}
+		// Parse scope JSON
+		if scopeJSON != "" && scopeJSON != "{}" {
+			if err := json.Unmarshal([]byte(scopeJSON), &t.Scope); err != nil {
+				t.Scope = nil
+			}
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, nil
+}
+
 // CountByHour returns task counts grouped by hour
 func (e *taskRepository) CountByHour(ctx context.Context, projectId uuid.UUID, start, end time.Time) ([]models.TimeSeriesPoint, error) {
 	query := `SELECT
@@ -284,15 +401,18 @@ func (e *taskRepository) AvgDurationByHour(ctx context.Context, projectId uuid.U
 
 // CountByInterval returns task counts grouped by configurable interval in minutes
 func (e *taskRepository) CountByInterval(ctx context.Context, projectId uuid.UUID, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
-	query := `SELECT
-		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
-		toFloat64(count()) as count
-	FROM tasks
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
-	GROUP BY bucket
-	ORDER BY bucket ASC`
+	query, args, err := appendRollupSource(ctx, `SELECT
+		toStartOfInterval(bucket, INTERVAL ? MINUTE) as period,
+		toFloat64(sum(runs)) as count
+	FROM `, []interface{}{intervalMinutes}, taskRollupSource, "project_id = ?", []interface{}{projectId}, start, end, intervalMinutes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	query += `
+	GROUP BY period
+	ORDER BY period ASC`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, intervalMinutes, projectId, start, end)
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -312,15 +432,18 @@ func (e *taskRepository) CountByInterval(ctx context.Context, projectId uuid.UUI
 
 // AvgDurationByInterval returns average duration in ms grouped by configurable interval
 func (e *taskRepository) AvgDurationByInterval(ctx context.Context, projectId uuid.UUID, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
-	query := `SELECT
-		toStartOfInterval(recorded_at, INTERVAL ? MINUTE) as bucket,
-		avg(duration) / 1000000 as avg_duration_ms
-	FROM tasks
-	WHERE project_id = ? AND recorded_at >= ? AND recorded_at <= ?
-	GROUP BY bucket
-	ORDER BY bucket ASC`
+	query, args, err := appendRollupSource(ctx, `SELECT
+		toStartOfInterval(bucket, INTERVAL ? MINUTE) as period,
+		sum(duration_sum) / sum(runs) / 1000000 as avg_duration_ms
+	FROM `, []interface{}{intervalMinutes}, taskRollupSource, "project_id = ?", []interface{}{projectId}, start, end, intervalMinutes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	query += `
+	GROUP BY period
+	ORDER BY period ASC`
 
-	rows, err := (*chdb.Conn).Query(ctx, query, intervalMinutes, projectId, start, end)
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -375,27 +498,29 @@ func (e *taskRepository) FindWorstTasks(ctx context.Context, projectId uuid.UUID
 	return stats, nil
 }
 
-// GetTaskStats returns aggregate statistics for a specific task
-func (e *taskRepository) GetTaskStats(ctx context.Context, projectId uuid.UUID, taskName string, start, end time.Time) (*models.TaskDetailStats, error) {
+// GetTaskStats returns aggregate statistics for a specific task, limited to the runs matching the scope filters and filter expression
+func (e *taskRepository) GetTaskStats(ctx context.Context, projectId uuid.UUID, taskName string, start, end time.Time, scopeFilters []models.ScopeFilter, expression *models.FilterNode) (*models.TaskDetailStats, error) {
 	// Calculate time range duration for throughput calculation
 	durationMinutes := end.Sub(start).Minutes()
 	if durationMinutes < 1 {
 		durationMinutes = 1
 	}
 
-	query := `SELECT
-		count() as count,
-		avg(duration) / 1000000 as avg_duration_ms,
-		quantile(0.5)(duration) / 1000000 as p50_duration_ms,
-		quantile(0.95)(duration) / 1000000 as p95_duration_ms,
-		quantile(0.99)(duration) / 1000000 as p99_duration_ms
-	FROM tasks
-	WHERE project_id = ? AND task_name = ? AND recorded_at >= ? AND recorded_at <= ?`
+	query, args, err := appendRollupSource(ctx, `SELECT
+		sum(runs) as count,
+		sum(duration_sum) / sum(runs) / 1000000 as avg_duration_ms,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[1] / 1000000 as p50_duration_ms,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[2] / 1000000 as p95_duration_ms,
+		quantilesMerge(0.5, 0.95, 0.99)(duration_quantiles)[3] / 1000000 as p99_duration_ms
+	FROM `, nil, taskRollupSource, "project_id = ? AND task_name = ?", []interface{}{projectId, taskName}, start, end, 0, scopeFilters, expression)
+	if err != nil {
+		return nil, err
+	}
 
 	var stats models.TaskDetailStats
 	var count uint64
 
-	err := (*chdb.Conn).QueryRow(ctx, query, projectId, taskName, start, end).Scan(
+	err = (*chdb.Conn).QueryRow(ctx, query, args...).Scan(
 		&count,
 		&stats.AvgDuration,
 		&stats.MedianDuration,
@@ -413,4 +538,42 @@ func (e *taskRepository) GetTaskStats(ctx context.Context, projectId uuid.UUID,
 	return &stats, nil
 }
 
+// GetLastRunAt returns when the task last ran, nil when it never ran since the given time
+func (e *taskRepository) GetLastRunAt(ctx context.Context, projectId uuid.UUID, taskName string, since time.Time) (*time.Time, error) {
+	var count uint64
+	var lastRunAt time.Time
+	err := (*chdb.Conn).QueryRow(ctx, "SELECT count(), max(recorded_at) FROM tasks WHERE project_id = ? AND task_name = ? AND recorded_at >= ?",
+		projectId, taskName, since).Scan(&count, &lastRunAt)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return &lastRunAt, nil
+}
+
+// FindRuns returns the most recent runs of the task between start and end, newest first
+func (e *taskRepository) FindRuns(ctx context.Context, projectId uuid.UUID, taskName string, start, end time.Time, limit int) ([]models.TaskRun, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT recorded_at, duration / 1000000
+		FROM tasks
+		WHERE project_id = ? AND task_name = ? AND recorded_at >= ? AND recorded_at < ?
+		ORDER BY recorded_at DESC
+		LIMIT ?`, projectId, taskName, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.TaskRun{}
+	for rows.Next() {
+		var run models.TaskRun
+		if err := rows.Scan(&run.RecordedAt, &run.DurationMs); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
 var TaskRepository = taskRepository{}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrTaskCheckInNotFound = errors.New("task check-in not found")
+
+type taskCheckInRepository struct{}
+
+func (r *taskCheckInRepository) InsertAsync(ctx context.Context, checkIns []models.TaskCheckIn) error {
+	if len(checkIns) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO task_check_ins (id, project_id, task_name, check_in_id, status, duration, recorded_at)")
+	if err != nil {
+		return err
+	}
+
+	for _, ci := range checkIns {
+		if err := batch.Append(ci.Id, ci.ProjectId, ci.TaskName, ci.CheckInId, ci.Status, ci.Duration.Nanoseconds(), ci.RecordedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindStarted returns the in_progress check-in that started the run, looking back as far as since
+func (r *taskCheckInRepository) FindStarted(ctx context.Context, projectId uuid.UUID, taskName string, checkInId uuid.UUID, since time.Time) (*models.TaskCheckIn, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT id, project_id, task_name, check_in_id, status, duration, recorded_at
+		FROM task_check_ins
+		WHERE project_id = ? AND task_name = ? AND check_in_id = ? AND status = ? AND recorded_at >= ?
+		ORDER BY recorded_at DESC
+		LIMIT 1`, projectId, taskName, checkInId, models.TaskCheckInInProgress, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, ErrTaskCheckInNotFound
+	}
+	var ci models.TaskCheckIn
+	var duration int64
+	if err := rows.Scan(&ci.Id, &ci.ProjectId, &ci.TaskName, &ci.CheckInId, &ci.Status, &duration, &ci.RecordedAt); err != nil {
+		return nil, err
+	}
+	ci.Duration = time.Duration(duration)
+	return &ci, nil
+}
+
+var TaskCheckInRepository = taskCheckInRepository{}
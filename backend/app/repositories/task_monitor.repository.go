@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrTaskMonitorNotFound = errors.New("task monitor not found")
+
+type taskMonitorRepository struct{}
+
+const taskMonitorColumns = "id, project_id, task_name, schedule_type, cron_expression, timezone, interval_seconds, grace_seconds, max_duration_ms, enabled, created_at, updated_at"
+
+// Save inserts a new version of the monitor, replacing the previous one on merge
+func (r *taskMonitorRepository) Save(ctx context.Context, monitor *models.TaskMonitor) error {
+	monitor.UpdatedAt = time.Now()
+	if monitor.CreatedAt.IsZero() {
+		monitor.CreatedAt = monitor.UpdatedAt
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO task_monitors ("+taskMonitorColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		monitor.Id, monitor.ProjectId, monitor.TaskName, monitor.ScheduleType, monitor.CronExpression, monitor.Timezone,
+		uint32(monitor.IntervalSeconds), uint32(monitor.GraceSeconds), uint32(monitor.MaxDurationMs), monitor.Enabled, monitor.CreatedAt, monitor.UpdatedAt)
+}
+
+// FindAll returns the project's monitors ordered by task name
+func (r *taskMonitorRepository) FindAll(ctx context.Context, projectId uuid.UUID) ([]models.TaskMonitor, error) {
+	return r.query(ctx, "SELECT "+taskMonitorColumns+" FROM task_monitors FINAL WHERE project_id = ? ORDER BY task_name ASC", projectId)
+}
+
+// FindAllEnabled returns the enabled monitors of every project, used by the monitor job
+func (r *taskMonitorRepository) FindAllEnabled(ctx context.Context) ([]models.TaskMonitor, error) {
+	return r.query(ctx, "SELECT "+taskMonitorColumns+" FROM task_monitors FINAL WHERE enabled = true")
+}
+
+func (r *taskMonitorRepository) FindById(ctx context.Context, projectId, id uuid.UUID) (*models.TaskMonitor, error) {
+	monitors, err := r.query(ctx, "SELECT "+taskMonitorColumns+" FROM task_monitors FINAL WHERE project_id = ? AND id = ?", projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(monitors) == 0 {
+		return nil, ErrTaskMonitorNotFound
+	}
+	return &monitors[0], nil
+}
+
+func (r *taskMonitorRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.TaskMonitor, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	monitors := []models.TaskMonitor{}
+	for rows.Next() {
+		var m models.TaskMonitor
+		var intervalSeconds, graceSeconds, maxDurationMs uint32
+		if err := rows.Scan(&m.Id, &m.ProjectId, &m.TaskName, &m.ScheduleType, &m.CronExpression, &m.Timezone,
+			&intervalSeconds, &graceSeconds, &maxDurationMs, &m.Enabled, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		m.IntervalSeconds = int(intervalSeconds)
+		m.GraceSeconds = int(graceSeconds)
+		m.MaxDurationMs = int(maxDurationMs)
+		monitors = append(monitors, m)
+	}
+	return monitors, nil
+}
+
+// Delete removes the monitor and its state, its events are kept
+func (r *taskMonitorRepository) Delete(ctx context.Context, projectId, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	if err := (*chdb.Conn).Exec(ctx, "ALTER TABLE task_monitors DELETE WHERE project_id = ? AND id = ?", projectId, id); err != nil {
+		return err
+	}
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE task_monitor_states DELETE WHERE project_id = ? AND monitor_id = ?", projectId, id)
+}
+
+// FindStates returns the latest state of the project's monitors (every project when projectId is uuid.Nil), keyed by monitor id
+func (r *taskMonitorRepository) FindStates(ctx context.Context, projectId uuid.UUID) (map[uuid.UUID]models.TaskMonitorState, error) {
+	query := "SELECT monitor_id, project_id, status, last_run_at, missed_expected_at, last_checked_at FROM task_monitor_states FINAL"
+	var args []interface{}
+	if projectId != uuid.Nil {
+		query += " WHERE project_id = ?"
+		args = append(args, projectId)
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[uuid.UUID]models.TaskMonitorState)
+	for rows.Next() {
+		var s models.TaskMonitorState
+		if err := rows.Scan(&s.MonitorId, &s.ProjectId, &s.Status, &s.LastRunAt, &s.MissedExpectedAt, &s.LastCheckedAt); err != nil {
+			return nil, err
+		}
+		states[s.MonitorId] = s
+	}
+	return states, nil
+}
+
+// SaveStates stores the outcome of a monitor round
+func (r *taskMonitorRepository) SaveStates(ctx context.Context, states []models.TaskMonitorState) error {
+	if len(states) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO task_monitor_states (monitor_id, project_id, status, last_run_at, missed_expected_at, last_checked_at)")
+	if err != nil {
+		return err
+	}
+
+	for _, s := range states {
+		if err := batch.Append(s.MonitorId, s.ProjectId, s.Status, s.LastRunAt, s.MissedExpectedAt, s.LastCheckedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+var TaskMonitorRepository = taskMonitorRepository{}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+type taskMonitorEventRepository struct{}
+
+func (r *taskMonitorEventRepository) InsertAsync(ctx context.Context, events []models.TaskMonitorEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)),
+		"INSERT INTO task_monitor_events (id, monitor_id, project_id, task_name, event_type, expected_at, run_at, duration_ms, created_at)")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if err := batch.Append(e.Id, e.MonitorId, e.ProjectId, e.TaskName, e.EventType, e.ExpectedAt, e.RunAt, e.DurationMs, e.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindByProject returns the events of a project's monitors (of a single monitor when monitorId is set), most recent first
+func (r *taskMonitorEventRepository) FindByProject(ctx context.Context, projectId uuid.UUID, monitorId *uuid.UUID, page, pageSize int) ([]models.TaskMonitorEvent, int64, error) {
+	offset := (page - 1) * pageSize
+
+	whereClause := "project_id = ?"
+	args := []interface{}{projectId}
+	if monitorId != nil {
+		whereClause += " AND monitor_id = ?"
+		args = append(args, *monitorId)
+	}
+
+	var count uint64
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM task_monitor_events WHERE "+whereClause, args...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := (*chdb.Conn).Query(ctx,
+		"SELECT id, monitor_id, project_id, task_name, event_type, expected_at, run_at, duration_ms, created_at FROM task_monitor_events WHERE "+whereClause+" ORDER BY created_at DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []models.TaskMonitorEvent{}
+	for rows.Next() {
+		var e models.TaskMonitorEvent
+		if err := rows.Scan(&e.Id, &e.MonitorId, &e.ProjectId, &e.TaskName, &e.EventType, &e.ExpectedAt, &e.RunAt, &e.DurationMs, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+
+	return events, int64(count), nil
+}
+
+var TaskMonitorEventRepository = taskMonitorEventRepository{}
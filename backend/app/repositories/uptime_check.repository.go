@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+var ErrUptimeCheckNotFound = errors.New("uptime check not found")
+
+type uptimeCheckRepository struct{}
+
+const uptimeCheckColumns = "id, project_id, name, url, method, interval_seconds, expected_status, body_match, timeout_ms, create_issues, enabled, created_at, updated_at"
+
+// Save inserts a new version of the check, replacing the previous one on merge
+func (r *uptimeCheckRepository) Save(ctx context.Context, check *models.UptimeCheck) error {
+	check.UpdatedAt = time.Now()
+	if check.CreatedAt.IsZero() {
+		check.CreatedAt = check.UpdatedAt
+	}
+
+	return (*chdb.Conn).Exec(ctx, "INSERT INTO uptime_checks ("+uptimeCheckColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		check.Id, check.ProjectId, check.Name, check.Url, check.Method, uint32(check.IntervalSeconds), uint16(check.ExpectedStatus),
+		check.BodyMatch, uint32(check.TimeoutMs), check.CreateIssues, check.Enabled, check.CreatedAt, check.UpdatedAt)
+}
+
+// FindAll returns the project's checks ordered by name
+func (r *uptimeCheckRepository) FindAll(ctx context.Context, projectId uuid.UUID) ([]models.UptimeCheck, error) {
+	return r.query(ctx, "SELECT "+uptimeCheckColumns+" FROM uptime_checks FINAL WHERE project_id = ? ORDER BY name ASC", projectId)
+}
+
+// FindAllEnabled returns the enabled checks of every project, used by the scheduler
+func (r *uptimeCheckRepository) FindAllEnabled(ctx context.Context) ([]models.UptimeCheck, error) {
+	return r.query(ctx, "SELECT "+uptimeCheckColumns+" FROM uptime_checks FINAL WHERE enabled = true")
+}
+
+func (r *uptimeCheckRepository) FindById(ctx context.Context, projectId, id uuid.UUID) (*models.UptimeCheck, error) {
+	checks, err := r.query(ctx, "SELECT "+uptimeCheckColumns+" FROM uptime_checks FINAL WHERE project_id = ? AND id = ?", projectId, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(checks) == 0 {
+		return nil, ErrUptimeCheckNotFound
+	}
+	return &checks[0], nil
+}
+
+func (r *uptimeCheckRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.UptimeCheck, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []models.UptimeCheck{}
+	for rows.Next() {
+		var check models.UptimeCheck
+		var intervalSeconds, timeoutMs uint32
+		var expectedStatus uint16
+		if err := rows.Scan(&check.Id, &check.ProjectId, &check.Name, &check.Url, &check.Method, &intervalSeconds, &expectedStatus,
+			&check.BodyMatch, &timeoutMs, &check.CreateIssues, &check.Enabled, &check.CreatedAt, &check.UpdatedAt); err != nil {
+			return nil, err
+		}
+		check.IntervalSeconds = int(intervalSeconds)
+		check.ExpectedStatus = int(expectedStatus)
+		check.TimeoutMs = int(timeoutMs)
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// Delete removes the check and its results
+func (r *uptimeCheckRepository) Delete(ctx context.Context, projectId, id uuid.UUID) error {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{"mutations_sync": 1}))
+	if err := (*chdb.Conn).Exec(ctx, "ALTER TABLE uptime_checks DELETE WHERE project_id = ? AND id = ?", projectId, id); err != nil {
+		return err
+	}
+	return (*chdb.Conn).Exec(ctx, "ALTER TABLE uptime_results DELETE WHERE project_id = ? AND check_id = ?", projectId, id)
+}
+
+var UptimeCheckRepository = uptimeCheckRepository{}
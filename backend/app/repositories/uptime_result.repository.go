@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"backend/app/chdb"
+	"backend/app/models"
+	"context"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+)
+
+type uptimeResultRepository struct{}
+
+const uptimeResultColumns = "id, check_id, project_id, checked_at, success, status_code, duration_ms, error, tls_expires_at"
+
+func (r *uptimeResultRepository) InsertAsync(ctx context.Context, results []models.UptimeResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	batch, err := (*chdb.Conn).PrepareBatch(clickhouse.Context(context.Background(), clickhouse.WithAsync(false)), "INSERT INTO uptime_results ("+uptimeResultColumns+")")
+	if err != nil {
+		return err
+	}
+
+	for _, res := range results {
+		if err := batch.Append(res.Id, res.CheckId, res.ProjectId, res.CheckedAt, res.Success, uint16(res.StatusCode), res.DurationMs, res.Error, res.TlsExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	return batch.Send()
+}
+
+// FindByCheck returns the results of a check between start and end, most recent first
+func (r *uptimeResultRepository) FindByCheck(ctx context.Context, projectId, checkId uuid.UUID, start, end time.Time, page, pageSize int) ([]models.UptimeResult, int64, error) {
+	offset := (page - 1) * pageSize
+	whereClause := "project_id = ? AND check_id = ? AND checked_at >= ? AND checked_at <= ?"
+	args := []interface{}{projectId, checkId, start, end}
+
+	var count uint64
+	if err := (*chdb.Conn).QueryRow(ctx, "SELECT count() FROM uptime_results WHERE "+whereClause, args...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	results, err := r.query(ctx, "SELECT "+uptimeResultColumns+" FROM uptime_results WHERE "+whereClause+" ORDER BY checked_at DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, int64(count), nil
+}
+
+// FindLatest returns the most recent result of each of the project's checks, keyed by check id
+func (r *uptimeResultRepository) FindLatest(ctx context.Context, projectId uuid.UUID) (map[uuid.UUID]models.UptimeResult, error) {
+	results, err := r.query(ctx, "SELECT "+uptimeResultColumns+" FROM uptime_results WHERE project_id = ? ORDER BY checked_at DESC LIMIT 1 BY check_id", projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[uuid.UUID]models.UptimeResult, len(results))
+	for _, res := range results {
+		latest[res.CheckId] = res
+	}
+	return latest, nil
+}
+
+func (r *uptimeResultRepository) query(ctx context.Context, query string, args ...interface{}) ([]models.UptimeResult, error) {
+	rows, err := (*chdb.Conn).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.UptimeResult{}
+	for rows.Next() {
+		var res models.UptimeResult
+		var statusCode uint16
+		if err := rows.Scan(&res.Id, &res.CheckId, &res.ProjectId, &res.CheckedAt, &res.Success, &statusCode, &res.DurationMs, &res.Error, &res.TlsExpiresAt); err != nil {
+			return nil, err
+		}
+		res.StatusCode = int(statusCode)
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// GetStats summarizes the results of a check between start and end
+func (r *uptimeResultRepository) GetStats(ctx context.Context, projectId, checkId uuid.UUID, start, end time.Time) (*models.UptimeCheckStats, error) {
+	var stats models.UptimeCheckStats
+	err := (*chdb.Conn).QueryRow(ctx, `SELECT
+			count(),
+			countIf(NOT success),
+			if(count() > 0, countIf(success) * 100 / count(), 100),
+			if(count() > 0, avg(duration_ms), 0),
+			if(count() > 0, quantile(0.95)(duration_ms), 0)
+		FROM uptime_results
+		WHERE project_id = ? AND check_id = ? AND checked_at >= ? AND checked_at <= ?`,
+		projectId, checkId, start, end).Scan(&stats.Total, &stats.Failures, &stats.Availability, &stats.AvgDurationMs, &stats.P95DurationMs)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// AvailabilityByInterval returns the percentage of successful runs of a check grouped by configurable interval
+func (r *uptimeResultRepository) AvailabilityByInterval(ctx context.Context, projectId, checkId uuid.UUID, start, end time.Time, intervalMinutes int) ([]models.TimeSeriesPoint, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT
+			toStartOfInterval(checked_at, INTERVAL ? MINUTE) as bucket,
+			countIf(success) * 100 / count() as availability
+		FROM uptime_results
+		WHERE project_id = ? AND check_id = ? AND checked_at >= ? AND checked_at <= ?
+		GROUP BY bucket
+		ORDER BY bucket ASC`, intervalMinutes, projectId, checkId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.TimeSeriesPoint{}
+	for rows.Next() {
+		var p models.TimeSeriesPoint
+		if err := rows.Scan(&p.Timestamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// ResponseTimeByInterval returns the "avg" and "p95" duration in ms of a check's runs grouped by configurable interval
+func (r *uptimeResultRepository) ResponseTimeByInterval(ctx context.Context, projectId, checkId uuid.UUID, start, end time.Time, intervalMinutes int) (map[string][]models.TimeSeriesPoint, error) {
+	rows, err := (*chdb.Conn).Query(ctx, `SELECT
+			toStartOfInterval(checked_at, INTERVAL ? MINUTE) as bucket,
+			avg(duration_ms),
+			quantile(0.95)(duration_ms)
+		FROM uptime_results
+		WHERE project_id = ? AND check_id = ? AND checked_at >= ? AND checked_at <= ?
+		GROUP BY bucket
+		ORDER BY bucket ASC`, intervalMinutes, projectId, checkId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string][]models.TimeSeriesPoint{"avg": {}, "p95": {}}
+	for rows.Next() {
+		var bucket time.Time
+		var avg, p95 float64
+		if err := rows.Scan(&bucket, &avg, &p95); err != nil {
+			return nil, err
+		}
+		result["avg"] = append(result["avg"], models.TimeSeriesPoint{Timestamp: bucket, Value: avg})
+		result["p95"] = append(result["p95"], models.TimeSeriesPoint{Timestamp: bucket, Value: p95})
+	}
+	return result, nil
+}
+
+var UptimeResultRepository = uptimeResultRepository{}
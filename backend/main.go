@@ -4,11 +4,11 @@ import (
 	"backend/app/cache"
 	"backend/app/chdb"
 	"backend/app/controllers"
+	"backend/app/jobs"
 	"backend/app/middleware"
 	"backend/app/migrations"
 	"backend/static"
 	"context"
-	"fmt"
 	"io/fs"
 	"log"
 	"net/http"
@@ -47,6 +47,14 @@ func main() {
 
 	middleware.InitUseClientAuth()
 
+	// Start background jobs
+	jobs.StartAlertEvaluator(ctx)
+	jobs.StartNotificationDispatcher(ctx)
+	jobs.StartUptimeScheduler(ctx)
+	jobs.StartTaskMonitor(ctx)
+	jobs.StartIssueSnoozeMonitor(ctx)
+	jobs.StartRetentionEnforcer(ctx)
+
 	router := gin.Default()
 
 	router.Use(gin.Recovery())